@@ -35,14 +35,88 @@ func Database() *gorm.DB{
 		&entity.User{},
 		&entity.Address{},
 		&entity.Inventory{},
+		&entity.Category{},
 		&entity.Product{}, 
+		&entity.ProductOption{},
+		&entity.ProductVariant{},
+		&entity.ProductImage{},
 		&entity.Order{}, 
 		&entity.OrderProduct{},
 		&entity.Payment{}, 
+		&entity.OrderStatusHistory{},
+		&entity.DocumentSequence{},
+		&entity.Cart{},
+		&entity.CartItem{},
+		&entity.ReturnRequest{},
+		&entity.Refund{},
+		&entity.Review{},
+		&entity.ReviewImage{},
+		&entity.ProductImport{},
+		&entity.ProductImportError{},
+		&entity.ProductPriceHistory{},
+		&entity.ProductPriceSchedule{},
+		&entity.ProductStock{},
+		&entity.StockMovement{},
+		&entity.StockTransfer{},
+		&entity.StockTransferLine{},
+		&entity.Stocktake{},
+		&entity.StocktakeLine{},
+		&entity.Supplier{},
+		&entity.PurchaseOrder{},
+		&entity.PurchaseOrderLine{},
+		&entity.GoodsReceipt{},
+		&entity.GoodsReceiptLine{},
 	)
 	if err != nil {
 		log.Fatal("AutoMigrate failed:", err)
 	}
 
+	//lines are unique per variant now, drop the old product only indexes
+	for _, v := range []struct {
+		model interface{}
+		index string
+	}{
+		{&entity.OrderProduct{}, "idx_order_product"},
+		{&entity.CartItem{}, "idx_cart_product"},
+	} {
+		if db.Migrator().HasIndex(v.model, v.index) {
+			if err := db.Migrator().DropIndex(v.model, v.index); err != nil {
+				log.Fatal("drop index failed:", err)
+			}
+		}
+	}
+
+	//product search uses MATCH ... AGAINST over name and description
+	if !db.Migrator().HasIndex(&entity.Product{}, "idx_product_search") {
+		if err := db.Exec("CREATE FULLTEXT INDEX idx_product_search ON products (name, description)").Error; err != nil {
+			log.Fatal("create fulltext index failed:", err)
+		}
+	}
+
+	//stock is held per location now, products that have no location rows yet
+	//get their old stock at the home location, once
+	for _, v := range []string{
+		"INSERT INTO product_stocks (product_id, variant_id, inventory_id, quantity, created_at, updated_at) " +
+			"SELECT v.product_id, v.id, p.inventory_id, v.stock, NOW(), NOW() FROM product_variants v " +
+			"JOIN products p ON p.id = v.product_id " +
+			"WHERE NOT EXISTS (SELECT 1 FROM product_stocks s WHERE s.product_id = v.product_id)",
+		"INSERT INTO product_stocks (product_id, variant_id, inventory_id, quantity, created_at, updated_at) " +
+			"SELECT p.id, 0, p.inventory_id, p.stock, NOW(), NOW() FROM products p " +
+			"WHERE NOT EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id) " +
+			"AND NOT EXISTS (SELECT 1 FROM product_stocks s WHERE s.product_id = p.id)",
+	} {
+		if err := db.Exec(v).Error; err != nil {
+			log.Fatal("seed product stock failed:", err)
+		}
+	}
+
+	//stock from before the ledger gets one opening movement per location row
+	if err := db.Exec("INSERT INTO stock_movements (product_id, variant_id, inventory_id, delta, balance, reason, ref_id, note, created_at) " +
+		"SELECT s.product_id, s.variant_id, s.inventory_id, s.quantity, s.quantity, 'adjustment', 0, 'opening balance', NOW() " +
+		"FROM product_stocks s WHERE s.quantity <> 0 AND NOT EXISTS (SELECT 1 FROM stock_movements m " +
+		"WHERE m.product_id = s.product_id AND m.variant_id = s.variant_id AND m.inventory_id = s.inventory_id)").Error; err != nil {
+		log.Fatal("seed stock movement failed:", err)
+	}
+
 	return db
 }
\ No newline at end of file
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"log"
+	"os"
+	"simple-toko/storage"
+)
+
+// InitStorage picks where uploads are kept with STORAGE_DRIVER, local (the
+// default) writes below STORAGE_LOCAL_DIR and s3 uses any S3 compatible
+// bucket so every app container sees the same files.
+func InitStorage() storage.Storage {
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "s3":
+		s3, err := storage.NewS3Storage(storage.S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			log.Fatalf("failed init storage %v", err)
+		}
+
+		return s3
+	case "", "local":
+		root := os.Getenv("STORAGE_LOCAL_DIR")
+		if root == "" {
+			root = "uploads/"
+		}
+
+		local, err := storage.NewLocalStorage(root)
+		if err != nil {
+			log.Fatalf("failed init storage %v", err)
+		}
+
+		return local
+	default:
+		log.Fatalf("unknown STORAGE_DRIVER %q", os.Getenv("STORAGE_DRIVER"))
+		return nil
+	}
+}
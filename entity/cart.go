@@ -0,0 +1,14 @@
+package entity
+
+import (
+	"time"
+)
+
+type Cart struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `gorm:"notnull;uniqueIndex"`
+	User      User       `gorm:"foreignKey:UserID;references:ID"`
+	CartItems []CartItem `gorm:"foreignKey:CartID"`
+	CreatedAt time.Time  `gorm:"notnull"`
+	UpdatedAt time.Time  `gorm:"notnull"`
+}
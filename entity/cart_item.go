@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+)
+
+type CartItem struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	CartID    uint      `gorm:"notnull;uniqueIndex:idx_cart_product_variant"`
+	Cart      Cart      `gorm:"foreignKey:CartID;references:ID;OnDelete:CASCADE;"`
+	ProductID uint      `gorm:"notnull;uniqueIndex:idx_cart_product_variant"`
+	Product   Product   `gorm:"foreignKey:ProductID;references:ID;OnDelete:CASCADE;"`
+	VariantID uint      `gorm:"notnull;default:0;uniqueIndex:idx_cart_product_variant"`
+	Qty       int       `gorm:"notnull"`
+	CreatedAt time.Time `gorm:"notnull"`
+	UpdatedAt time.Time `gorm:"notnull"`
+}
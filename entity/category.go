@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Category struct {
+	ID           uint           `gorm:"primaryKey;autoIncrement"`
+	ParentID     *uint          `gorm:"default:null;index"`
+	Parent       *Category      `gorm:"foreignKey:ParentID;references:ID"`
+	Name         string         `gorm:"size:100;notnull"`
+	Description  string         `gorm:"size:255;default:null"`
+	Products     []Product      `gorm:"many2many:product_categories"`
+	ProductCount int64          `gorm:"->;-:migration"`
+	CreatedAt    time.Time      `gorm:"notnull"`
+	UpdatedAt    time.Time      `gorm:"notnull"`
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,7 @@
+package entity
+
+type DocumentSequence struct {
+	Prefix string `gorm:"primaryKey;size:10"`
+	Day    string `gorm:"primaryKey;size:8"`
+	Value  int    `gorm:"notnull"`
+}
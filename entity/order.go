@@ -8,6 +8,8 @@ import (
 
 type Order struct {
 	ID             uint           `gorm:"primaryKey;autoIncrement"`
+	Code           string         `gorm:"size:20;uniqueIndex;default:null"`
+	InvoiceNumber  string         `gorm:"size:20;uniqueIndex;default:null"`
 	UserID         uint           `gorm:"notnull"`
 	User           User           `gorm:"foreignKey:UserID;references:ID"`
 	AddressID      uint           `gorm:"notnull"`
@@ -16,6 +18,7 @@ type Order struct {
 	AmountPay      float64        `gorm:"default:null"`
 	StatusOrder    string         `gorm:"type:enum('waiting','confirmed','canceled');default:'waiting';notnull"`
 	StatusDelivery string         `gorm:"type:enum('waiting','on_process','delivered','canceled');default:'waiting';notnull"`
+	PayBefore      *time.Time     `gorm:"default:null;index"`
 	CreatedAt      time.Time      `gorm:"notnull"`
 	UpdatedAt      time.Time      `gorm:"notnull"`
 	DeletedAt      gorm.DeletedAt `gorm:"index"`
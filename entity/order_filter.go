@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+type OrderFilter struct {
+	UserID         uint
+	Search         string
+	StatusOrder    string
+	StatusDelivery string
+	CreatedFrom    *time.Time
+	CreatedTo      *time.Time
+}
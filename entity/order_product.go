@@ -7,14 +7,18 @@ import (
 )
 
 type OrderProduct struct {
-	ID        uint           `gorm:"primaryKey;autoIncrement"`
-	OrderID   uint           `gorm:"notnull;uniqueIndex:idx_order_product"`
-	Order     Order          `gorm:"foreignKey:OrderID;references:ID;OnDelete:RESTRICT;"`
-	ProductID uint           `gorm:"notnull;uniqueIndex:idx_order_product"`
-	Product   Product        `gorm:"foreignKey:ProductID;references:ID;OnDelete:RESTRICT;"`
-	Qty       int            `gorm:"notnull"`
-	UnitPrice float64        `gorm:"notnull"`
-	CreatedAt time.Time      `gorm:"notnull"`
-	UpdatedAt time.Time      `gorm:"notnull"`
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID          uint           `gorm:"primaryKey;autoIncrement"`
+	OrderID     uint           `gorm:"notnull;uniqueIndex:idx_order_product_variant"`
+	Order       Order          `gorm:"foreignKey:OrderID;references:ID;OnDelete:RESTRICT;"`
+	ProductID   uint           `gorm:"notnull;uniqueIndex:idx_order_product_variant"`
+	Product     Product        `gorm:"foreignKey:ProductID;references:ID;OnDelete:RESTRICT;"`
+	VariantID   uint           `gorm:"notnull;default:0;uniqueIndex:idx_order_product_variant"`
+	InventoryID uint           `gorm:"notnull;default:0;index"` //warehouse the line was allocated from, 0 on lines older than the allocation
+	Sku         string         `gorm:"size:64;default:null"`
+	VariantName string         `gorm:"size:160;default:null"`
+	Qty         int            `gorm:"notnull"`
+	UnitPrice   float64        `gorm:"notnull"`
+	CreatedAt   time.Time      `gorm:"notnull"`
+	UpdatedAt   time.Time      `gorm:"notnull"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"`
 }
@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"time"
+)
+
+type OrderStatusHistory struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	OrderID    uint      `gorm:"notnull;index"`
+	Order      Order     `gorm:"foreignKey:OrderID;references:ID"`
+	UserID     *uint     `gorm:"default:null"`
+	User       *User     `gorm:"foreignKey:UserID;references:ID"`
+	FromStatus string    `gorm:"size:20;notnull"`
+	ToStatus   string    `gorm:"size:20;notnull"`
+	Reason     string    `gorm:"size:255;default:null"`
+	CreatedAt  time.Time `gorm:"notnull"`
+}
@@ -7,16 +7,24 @@ import (
 )
 
 type Product struct {
-	ID            uint           `gorm:"primaryKey;autoIncrement"`
-	InventoryID   uint           `gorm:"notnull"`
-	Inventory     Inventory      `gorm:"foreignKey:InventoryID;references:ID"`
-	Name          string         `gorm:"size:100;notnull"`
-	Price         float64        `gorm:"notnull"`
-	Stock         int            `gorm:"notnull"`
-	Description   string         `gorm:"size:255;notnull"`
-	Image         string         `gorm:"size:255;default:null"`
-	OrderProducts []OrderProduct `gorm:"foreignKey:ProductID"`
-	CreatedAt     time.Time      `gorm:"notnull"`
-	UpdatedAt     time.Time      `gorm:"notnull"`
-	DeletedAt     gorm.DeletedAt `gorm:"index"`
+	ID            uint             `gorm:"primaryKey;autoIncrement"`
+	InventoryID   uint             `gorm:"notnull"` //home location, new stock goes here unless another is named
+	Inventory     Inventory        `gorm:"foreignKey:InventoryID;references:ID"`
+	SKU           *string          `gorm:"size:64;uniqueIndex;default:null"`
+	Name          string           `gorm:"size:100;notnull"`
+	Price         float64          `gorm:"notnull"`
+	Stock         int              `gorm:"notnull"`
+	Description   string           `gorm:"size:255;notnull"`
+	Image         string           `gorm:"size:255;default:null"`
+	OrderProducts []OrderProduct   `gorm:"foreignKey:ProductID"`
+	Categories    []Category       `gorm:"many2many:product_categories"`
+	Options       []ProductOption  `gorm:"foreignKey:ProductID"`
+	Variants      []ProductVariant `gorm:"foreignKey:ProductID"`
+	Images        []ProductImage   `gorm:"foreignKey:ProductID"`
+	Stocks        []ProductStock   `gorm:"foreignKey:ProductID"`
+	RatingAvg     float64          `gorm:"notnull;default:0"`
+	ReviewCount   int              `gorm:"notnull;default:0"`
+	CreatedAt     time.Time        `gorm:"notnull"`
+	UpdatedAt     time.Time        `gorm:"notnull"`
+	DeletedAt     gorm.DeletedAt   `gorm:"index"`
 }
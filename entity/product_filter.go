@@ -0,0 +1,31 @@
+package entity
+
+type ProductFilter struct {
+	Search      string
+	CategoryIDs []uint
+	InventoryID uint
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStock     bool
+	Sort        string
+}
+
+type FacetCount struct {
+	ID    uint
+	Name  string
+	Total int64
+}
+
+// PriceFacet counts the products with Min <= price < Max, Max is nil for the
+// last bucket.
+type PriceFacet struct {
+	Min   float64
+	Max   *float64
+	Total int64
+}
+
+type ProductFacets struct {
+	Categories  []FacetCount
+	Inventories []FacetCount
+	Prices      []PriceFacet
+}
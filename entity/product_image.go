@@ -0,0 +1,15 @@
+package entity
+
+import "time"
+
+// ProductImage is one picture of the product gallery, the file name is the
+// same in the product folder and in its thumb and medium folders.
+type ProductImage struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ProductID uint      `gorm:"notnull;index"`
+	FileName  string    `gorm:"size:255;notnull"`
+	Position  int       `gorm:"notnull"`
+	IsPrimary bool      `gorm:"notnull;default:false"`
+	CreatedAt time.Time `gorm:"notnull"`
+	UpdatedAt time.Time `gorm:"notnull"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+// ProductImport is one uploaded catalog CSV, the rows are applied in the
+// background and the counters are saved while it runs.
+type ProductImport struct {
+	ID         uint                 `gorm:"primaryKey;autoIncrement"`
+	UserID     uint                 `gorm:"notnull;index"`
+	FileName   string               `gorm:"size:255;notnull"`
+	Status     string               `gorm:"size:20;notnull;index"`
+	TotalRows  int                  `gorm:"notnull;default:0"`
+	Processed  int                  `gorm:"notnull;default:0"`
+	Created    int                  `gorm:"notnull;default:0"`
+	Updated    int                  `gorm:"notnull;default:0"`
+	Failed     int                  `gorm:"notnull;default:0"`
+	Error      string               `gorm:"size:500;default:null"`
+	Errors     []ProductImportError `gorm:"foreignKey:ProductImportID"`
+	StartedAt  *time.Time           `gorm:"default:null"`
+	FinishedAt *time.Time           `gorm:"default:null"`
+	CreatedAt  time.Time            `gorm:"notnull"`
+	UpdatedAt  time.Time            `gorm:"notnull"`
+}
+
+type ProductImportError struct {
+	ID              uint   `gorm:"primaryKey;autoIncrement"`
+	ProductImportID uint   `gorm:"notnull;index"`
+	Row             int    `gorm:"notnull"`
+	SKU             string `gorm:"size:64;default:null"`
+	Message         string `gorm:"size:500;notnull"`
+}
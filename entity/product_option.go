@@ -0,0 +1,10 @@
+package entity
+
+// ProductOption names an option type of a product like Size or Color, the
+// values live on the variants in the column matching Position.
+type ProductOption struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	ProductID uint   `gorm:"notnull;uniqueIndex:idx_product_option_position"`
+	Position  int    `gorm:"notnull;uniqueIndex:idx_product_option_position"`
+	Name      string `gorm:"size:50;notnull"`
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// ProductPriceHistory is one change of Product.Price, the first row of a
+// product is its price when it was created. A row with VariantID is a change
+// of that variant's price, 0 is the product itself.
+type ProductPriceHistory struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement"`
+	ProductID  uint      `gorm:"notnull;index"`
+	VariantID  uint      `gorm:"notnull;default:0"`
+	OldPrice   float64   `gorm:"notnull"`
+	NewPrice   float64   `gorm:"notnull"`
+	Source     string    `gorm:"size:20;notnull"`
+	ScheduleID *uint     `gorm:"default:null"`
+	CreatedAt  time.Time `gorm:"notnull;index"`
+}
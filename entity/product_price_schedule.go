@@ -0,0 +1,18 @@
+package entity
+
+import "time"
+
+// ProductPriceSchedule is a price that becomes the product price at StartsAt,
+// applied by the price scheduler.
+type ProductPriceSchedule struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement"`
+	ProductID uint       `gorm:"notnull;index"`
+	Product   Product    `gorm:"foreignKey:ProductID;references:ID;OnDelete:CASCADE;"`
+	Price     float64    `gorm:"notnull"`
+	StartsAt  time.Time  `gorm:"notnull;index"`
+	Status    string     `gorm:"size:20;notnull;index"`
+	Note      string     `gorm:"size:255;default:null"`
+	AppliedAt *time.Time `gorm:"default:null"`
+	CreatedAt time.Time  `gorm:"notnull"`
+	UpdatedAt time.Time  `gorm:"notnull"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// ProductStock is the quantity of a product, or of one of its variants, held
+// at one inventory location. products.stock and product_variants.stock are
+// kept as the sum of these rows.
+type ProductStock struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	ProductID   uint      `gorm:"notnull;uniqueIndex:idx_product_stock"`
+	VariantID   uint      `gorm:"notnull;default:0;uniqueIndex:idx_product_stock"`
+	Product     Product   `gorm:"foreignKey:ProductID"`
+	InventoryID uint      `gorm:"notnull;uniqueIndex:idx_product_stock;index"`
+	Inventory   Inventory `gorm:"foreignKey:InventoryID;references:ID"`
+	Quantity    int       `gorm:"notnull;default:0"`
+	CreatedAt   time.Time `gorm:"notnull"`
+	UpdatedAt   time.Time `gorm:"notnull"`
+}
+
+// StockAt sums the loaded stock rows of the product at one location.
+func (p *Product) StockAt(inventoryId uint) int {
+	total := 0
+	for _, v := range p.Stocks {
+		if v.InventoryID == inventoryId {
+			total += v.Quantity
+		}
+	}
+
+	return total
+}
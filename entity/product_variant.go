@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+// ProductVariant is one sellable combination of option values, a nil Price
+// uses the product price.
+type ProductVariant struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ProductID uint      `gorm:"notnull;index"`
+	SKU       string    `gorm:"size:64;notnull;uniqueIndex"`
+	Option1   string    `gorm:"size:50;notnull"`
+	Option2   string    `gorm:"size:50;notnull"`
+	Option3   string    `gorm:"size:50;notnull"`
+	Price     *float64  `gorm:"default:null"`
+	Stock     int       `gorm:"notnull"`
+	CreatedAt time.Time `gorm:"notnull"`
+	UpdatedAt time.Time `gorm:"notnull"`
+}
+
+// Values returns the option values in option order.
+func (v *ProductVariant) Values() []string {
+	values := make([]string, 0, 3)
+	for _, s := range []string{v.Option1, v.Option2, v.Option3} {
+		if s != "" {
+			values = append(values, s)
+		}
+	}
+
+	return values
+}
+
+// Title joins the option values, for example "M / Black".
+func (v *ProductVariant) Title() string {
+	return strings.Join(v.Values(), " / ")
+}
+
+// EffectivePrice is the variant price or the product price when there is no
+// override.
+func (v *ProductVariant) EffectivePrice(productPrice float64) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+
+	return productPrice
+}
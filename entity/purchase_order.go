@@ -0,0 +1,60 @@
+package entity
+
+import "time"
+
+// PurchaseOrder is stock ordered from a supplier into one inventory location.
+// Stock only moves with a goods receipt, once the order is approved.
+type PurchaseOrder struct {
+	ID          uint                `gorm:"primaryKey;autoIncrement"`
+	Code        string              `gorm:"size:30;notnull;uniqueIndex"`
+	SupplierID  uint                `gorm:"notnull;index"`
+	Supplier    Supplier            `gorm:"foreignKey:SupplierID;references:ID"`
+	InventoryID uint                `gorm:"notnull;index"`
+	Inventory   Inventory           `gorm:"foreignKey:InventoryID;references:ID"`
+	Status      string              `gorm:"size:20;notnull;index"`
+	ExpectedAt  *time.Time          `gorm:"type:date;default:null;index"`
+	Note        string              `gorm:"size:500;default:null"`
+	Total       float64             `gorm:"notnull;default:0"`
+	UserID      uint                `gorm:"notnull;index"`
+	User        User                `gorm:"foreignKey:UserID;references:ID"`
+	Lines       []PurchaseOrderLine `gorm:"foreignKey:PurchaseOrderID"`
+	Receipts    []GoodsReceipt      `gorm:"foreignKey:PurchaseOrderID"`
+	ApprovedBy  *uint               `gorm:"default:null"`
+	ApprovedAt  *time.Time          `gorm:"default:null"`
+	CreatedAt   time.Time           `gorm:"notnull"`
+	UpdatedAt   time.Time           `gorm:"notnull"`
+}
+
+type PurchaseOrderLine struct {
+	ID              uint    `gorm:"primaryKey;autoIncrement"`
+	PurchaseOrderID uint    `gorm:"notnull;uniqueIndex:idx_purchase_order_line"`
+	ProductID       uint    `gorm:"notnull;uniqueIndex:idx_purchase_order_line"`
+	Product         Product `gorm:"foreignKey:ProductID;references:ID"`
+	VariantID       uint    `gorm:"notnull;default:0;uniqueIndex:idx_purchase_order_line"`
+	Qty             int     `gorm:"notnull"`
+	ReceivedQty     int     `gorm:"notnull;default:0"`
+	UnitCost        float64 `gorm:"notnull"`
+}
+
+// GoodsReceipt is one delivery booked against a purchase order, its lines
+// keep the unit cost the stock came in at.
+type GoodsReceipt struct {
+	ID              uint               `gorm:"primaryKey;autoIncrement"`
+	Code            string             `gorm:"size:30;notnull;uniqueIndex"`
+	PurchaseOrderID uint               `gorm:"notnull;index"`
+	Note            string             `gorm:"size:500;default:null"`
+	UserID          uint               `gorm:"notnull;index"`
+	User            User               `gorm:"foreignKey:UserID;references:ID"`
+	Lines           []GoodsReceiptLine `gorm:"foreignKey:GoodsReceiptID"`
+	CreatedAt       time.Time          `gorm:"notnull"`
+}
+
+type GoodsReceiptLine struct {
+	ID                  uint    `gorm:"primaryKey;autoIncrement"`
+	GoodsReceiptID      uint    `gorm:"notnull;index"`
+	PurchaseOrderLineID uint    `gorm:"notnull;index"`
+	ProductID           uint    `gorm:"notnull;index:idx_goods_receipt_product"`
+	VariantID           uint    `gorm:"notnull;default:0;index:idx_goods_receipt_product"`
+	Qty                 int     `gorm:"notnull"`
+	UnitCost            float64 `gorm:"notnull"`
+}
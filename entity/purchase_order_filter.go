@@ -0,0 +1,7 @@
+package entity
+
+type PurchaseOrderFilter struct {
+	Status      string //open is approved or partially received
+	SupplierID  uint
+	InventoryID uint
+}
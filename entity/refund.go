@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type Refund struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement"`
+	ReturnRequestID uint      `gorm:"notnull;uniqueIndex"`
+	OrderID         uint      `gorm:"notnull;index"`
+	Order           Order     `gorm:"foreignKey:OrderID;references:ID"`
+	Qty             int       `gorm:"notnull"`
+	Amount          float64   `gorm:"notnull"`
+	CreatedAt       time.Time `gorm:"notnull;index"`
+}
@@ -1,9 +1,12 @@
 package entity
 
 type SalesReport struct {
-	Month      string  `json:"month"`
-	TotalQty   int     `json:"total_qty"`
-	TotalSales float64 `json:"total_sales"`
+	Month       string  `json:"month"`
+	TotalQty    int     `json:"total_qty"`
+	TotalSales  float64 `json:"total_sales"`
+	ReturnedQty int     `json:"returned_qty"`
+	TotalRefund float64 `json:"total_refund"`
+	NetSales    float64 `json:"net_sales"`
 }
 
 type TopProduct struct {
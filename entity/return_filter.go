@@ -0,0 +1,6 @@
+package entity
+
+type ReturnFilter struct {
+	UserID uint
+	Status string
+}
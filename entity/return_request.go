@@ -0,0 +1,27 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReturnRequest struct {
+	ID             uint           `gorm:"primaryKey;autoIncrement"`
+	OrderID        uint           `gorm:"notnull;index"`
+	Order          Order          `gorm:"foreignKey:OrderID;references:ID"`
+	OrderProductID uint           `gorm:"notnull;index"`
+	OrderProduct   OrderProduct   `gorm:"foreignKey:OrderProductID;references:ID;OnDelete:RESTRICT;"`
+	UserID         uint           `gorm:"notnull;index"`
+	User           User           `gorm:"foreignKey:UserID;references:ID"`
+	Qty            int            `gorm:"notnull"`
+	Reason         string         `gorm:"size:500;notnull"`
+	Image          string         `gorm:"size:255;default:null"`
+	Status         string         `gorm:"type:enum('requested','approved','rejected','received','refunded');default:'requested';notnull"`
+	AdminNote      string         `gorm:"size:500;default:null"`
+	Restock        bool           `gorm:"notnull;default:false"`
+	Refund         *Refund        `gorm:"foreignKey:ReturnRequestID"`
+	CreatedAt      time.Time      `gorm:"notnull"`
+	UpdatedAt      time.Time      `gorm:"notnull"`
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+// Review is written by the buyer of one order line, hidden reviews are kept
+// for the admin but left out of the product rating.
+type Review struct {
+	ID             uint          `gorm:"primaryKey;autoIncrement"`
+	ProductID      uint          `gorm:"notnull;index"`
+	Product        Product       `gorm:"foreignKey:ProductID;references:ID;OnDelete:RESTRICT;"`
+	OrderProductID uint          `gorm:"notnull;uniqueIndex"`
+	OrderProduct   OrderProduct  `gorm:"foreignKey:OrderProductID;references:ID;OnDelete:RESTRICT;"`
+	UserID         uint          `gorm:"notnull;index"`
+	User           User          `gorm:"foreignKey:UserID;references:ID"`
+	Rating         int           `gorm:"notnull"`
+	Comment        string        `gorm:"size:1000;default:null"`
+	Hidden         bool          `gorm:"notnull;default:false"`
+	HiddenReason   string        `gorm:"size:500;default:null"`
+	Images         []ReviewImage `gorm:"foreignKey:ReviewID"`
+	CreatedAt      time.Time     `gorm:"notnull"`
+	UpdatedAt      time.Time     `gorm:"notnull"`
+}
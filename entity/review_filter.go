@@ -0,0 +1,7 @@
+package entity
+
+type ReviewFilter struct {
+	ProductID uint
+	UserID    uint
+	Hidden    *bool //nil lists hidden and visible reviews
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+type ReviewImage struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement"`
+	ReviewID  uint      `gorm:"notnull;index"`
+	FileName  string    `gorm:"size:255;notnull"`
+	CreatedAt time.Time `gorm:"notnull"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// StockMovement is one change of the stock of a product, or of one of its
+// variants, at one location. Rows are only inserted, Balance is the quantity
+// at the location after the change. RefType and RefID point at the order or
+// document that caused it, UserID is nil for changes made by the system.
+type StockMovement struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	ProductID   uint      `gorm:"notnull;index:idx_stock_movement_product"`
+	VariantID   uint      `gorm:"notnull;default:0"`
+	InventoryID uint      `gorm:"notnull;index"`
+	Inventory   Inventory `gorm:"foreignKey:InventoryID;references:ID"`
+	Delta       int       `gorm:"notnull"`
+	Balance     int       `gorm:"notnull"`
+	Reason      string    `gorm:"size:20;notnull;index"`
+	RefType     string    `gorm:"size:20;default:null;index:idx_stock_movement_ref"`
+	RefID       uint      `gorm:"notnull;default:0;index:idx_stock_movement_ref"`
+	UserID      *uint     `gorm:"default:null"`
+	User        *User     `gorm:"foreignKey:UserID;references:ID"`
+	Note        string    `gorm:"size:255;default:null"`
+	CreatedAt   time.Time `gorm:"notnull;index:idx_stock_movement_product"`
+}
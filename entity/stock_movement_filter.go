@@ -0,0 +1,22 @@
+package entity
+
+import "time"
+
+type StockMovementFilter struct {
+	ProductID   uint
+	VariantID   *uint //nil lists the product and every variant
+	InventoryID uint
+	Reason      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// StockMismatch is stock that does not add up to its ledger. InventoryID 0
+// is the total of the product, products.stock.
+type StockMismatch struct {
+	ProductID   uint
+	VariantID   uint
+	InventoryID uint
+	Quantity    int
+	Ledger      int
+}
@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+// StockTransfer moves goods from one inventory location to another. Shipping
+// takes the qty of every line from the source, receipts add what arrived to
+// the destination and Discrepancy keeps what never did.
+type StockTransfer struct {
+	ID            uint                `gorm:"primaryKey;autoIncrement"`
+	Code          string              `gorm:"size:30;notnull;uniqueIndex"`
+	SourceID      uint                `gorm:"notnull;index"`
+	Source        Inventory           `gorm:"foreignKey:SourceID;references:ID"`
+	DestinationID uint                `gorm:"notnull;index"`
+	Destination   Inventory           `gorm:"foreignKey:DestinationID;references:ID"`
+	Status        string              `gorm:"size:20;notnull;index"`
+	Note          string              `gorm:"size:500;default:null"`
+	UserID        uint                `gorm:"notnull;index"`
+	User          User                `gorm:"foreignKey:UserID;references:ID"`
+	Lines         []StockTransferLine `gorm:"foreignKey:StockTransferID"`
+	ShippedBy     *uint               `gorm:"default:null"`
+	ShippedAt     *time.Time          `gorm:"default:null"`
+	ReceivedBy    *uint               `gorm:"default:null"`
+	ReceivedAt    *time.Time          `gorm:"default:null"`
+	CreatedAt     time.Time           `gorm:"notnull"`
+	UpdatedAt     time.Time           `gorm:"notnull"`
+}
+
+type StockTransferLine struct {
+	ID              uint    `gorm:"primaryKey;autoIncrement"`
+	StockTransferID uint    `gorm:"notnull;index"`
+	ProductID       uint    `gorm:"notnull;index"`
+	Product         Product `gorm:"foreignKey:ProductID;references:ID"`
+	VariantID       uint    `gorm:"notnull;default:0"`
+	Qty             int     `gorm:"notnull"`
+	ReceivedQty     int     `gorm:"notnull;default:0"`
+	Discrepancy     int     `gorm:"notnull;default:0"` //shipped but never received, set when the transfer is closed
+	Note            string  `gorm:"size:255;default:null"`
+}
+
+// StockTransferReceipt is the qty of one line that arrived at the
+// destination.
+type StockTransferReceipt struct {
+	LineID uint
+	Qty    int
+	Note   string
+}
@@ -0,0 +1,6 @@
+package entity
+
+type StockTransferFilter struct {
+	Status      string
+	InventoryID uint //source or destination
+}
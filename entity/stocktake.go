@@ -0,0 +1,45 @@
+package entity
+
+import "time"
+
+// Stocktake is a physical count of one inventory location. Expected is the
+// stock of every line when the session was opened and is taken again when
+// the line is counted, approving posts the difference to the counted qty as
+// an adjustment.
+type Stocktake struct {
+	ID          uint            `gorm:"primaryKey;autoIncrement"`
+	Code        string          `gorm:"size:30;notnull;uniqueIndex"`
+	InventoryID uint            `gorm:"notnull;index"`
+	Inventory   Inventory       `gorm:"foreignKey:InventoryID;references:ID"`
+	Status      string          `gorm:"size:20;notnull;index"`
+	Note        string          `gorm:"size:500;default:null"`
+	UserID      uint            `gorm:"notnull;index"`
+	User        User            `gorm:"foreignKey:UserID;references:ID"`
+	Lines       []StocktakeLine `gorm:"foreignKey:StocktakeID"`
+	ApprovedBy  *uint           `gorm:"default:null"`
+	ApprovedAt  *time.Time      `gorm:"default:null"`
+	CreatedAt   time.Time       `gorm:"notnull"`
+	UpdatedAt   time.Time       `gorm:"notnull"`
+}
+
+type StocktakeLine struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement"`
+	StocktakeID uint       `gorm:"notnull;uniqueIndex:idx_stocktake_line"`
+	ProductID   uint       `gorm:"notnull;uniqueIndex:idx_stocktake_line"`
+	Product     Product    `gorm:"foreignKey:ProductID;references:ID"`
+	VariantID   uint       `gorm:"notnull;default:0;uniqueIndex:idx_stocktake_line"`
+	SKU         string     `gorm:"size:64;default:null"`
+	Expected    int        `gorm:"notnull"`
+	Counted     *int       `gorm:"default:null"` //nil is not counted yet, the line is left as it is
+	CountedBy   *uint      `gorm:"default:null"`
+	CountedAt   *time.Time `gorm:"default:null"`
+	Adjusted    int        `gorm:"notnull;default:0"` //qty posted on approval
+}
+
+// StocktakeCount is one counted qty, the line is found by LineID or else by
+// SKU.
+type StocktakeCount struct {
+	LineID  uint
+	SKU     string
+	Counted int
+}
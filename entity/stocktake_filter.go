@@ -0,0 +1,6 @@
+package entity
+
+type StocktakeFilter struct {
+	Status      string
+	InventoryID uint
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Supplier struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement"`
+	Name      string         `gorm:"size:100;notnull"`
+	Email     string         `gorm:"size:100;default:null"`
+	Phone     string         `gorm:"size:30;default:null"`
+	Address   string         `gorm:"size:255;default:null"`
+	CreatedAt time.Time      `gorm:"notnull"`
+	UpdatedAt time.Time      `gorm:"notnull"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
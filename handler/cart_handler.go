@@ -0,0 +1,12 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+type CartHandler interface {
+	FindByUser(ctx *gin.Context)
+	AddItem(ctx *gin.Context)
+	UpdateItem(ctx *gin.Context)
+	RemoveItem(ctx *gin.Context)
+	Clear(ctx *gin.Context)
+	Checkout(ctx *gin.Context)
+}
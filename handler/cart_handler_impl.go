@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	t "simple-toko/web"
+	web "simple-toko/web/cart"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type cartHandlerImpl struct {
+	CartService service.CartService
+}
+
+func NewCartHandlerImpl(cartService service.CartService) *cartHandlerImpl {
+	return &cartHandlerImpl{
+		CartService: cartService,
+	}
+}
+
+func (c *cartHandlerImpl) FindByUser(ctx *gin.Context) {
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	result, err := c.CartService.FindByUserId(ctx, user.UserID)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (c *cartHandlerImpl) AddItem(ctx *gin.Context) {
+	req := web.CartItemRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	req.UserID = user.UserID
+
+	result, err := c.CartService.AddItem(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrProductNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "product not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantRequired):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (c *cartHandlerImpl) UpdateItem(ctx *gin.Context) {
+	req := web.CartItemRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	prodId := ctx.Param("productId")
+	productId, err := strconv.Atoi(prodId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	variantId, ok := variantQuery(ctx)
+	if !ok {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type variant id", nil)
+		return
+	}
+
+	req.UserID = user.UserID
+	req.ProductID = uint(productId)
+	req.VariantID = variantId
+
+	result, err := c.CartService.UpdateItem(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrCartItemNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "cart item not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (c *cartHandlerImpl) RemoveItem(ctx *gin.Context) {
+	prodId := ctx.Param("productId")
+	productId, err := strconv.Atoi(prodId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	variantId, ok := variantQuery(ctx)
+	if !ok {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type variant id", nil)
+		return
+	}
+
+	result, err := c.CartService.RemoveItem(ctx, user.UserID, uint(productId), variantId)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCartItemNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "cart item not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "deleted", result)
+}
+
+func (c *cartHandlerImpl) Clear(ctx *gin.Context) {
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	if err := c.CartService.Clear(ctx, user.UserID); err != nil {
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "deleted", nil)
+}
+
+func (c *cartHandlerImpl) Checkout(ctx *gin.Context) {
+	req := web.CartCheckoutRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	req.UserID = user.UserID
+
+	result, err := c.CartService.Checkout(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrCartEmpty):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "cart is empty", err.Error())
+			return
+		case errors.Is(err, service.ErrInvalidAddress):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "cannot use address", err.Error())
+			return
+		case errors.Is(err, service.ErrAddressNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "address not found", err.Error())
+			return
+		case errors.Is(err, service.ErrProductNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "product not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantRequired):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+			return
+		case errors.Is(err, service.ErrNotEnoughStock):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "stock not enough", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
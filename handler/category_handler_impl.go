@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	web "simple-toko/web/category"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type categoryHandlerImpl struct {
+	CategoryService service.CategoryService
+}
+
+func NewCategoryHandlerImpl(categoryService service.CategoryService) *categoryHandlerImpl {
+	return &categoryHandlerImpl{
+		CategoryService: categoryService,
+	}
+}
+
+func (c *categoryHandlerImpl) Create(ctx *gin.Context) {
+	req := web.CategoryCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	result, err := c.CategoryService.Create(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrInvalidParent):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid parent category", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (c *categoryHandlerImpl) Update(ctx *gin.Context) {
+	req := web.CategoryUpdateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("id")
+	categoryId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(categoryId)
+
+	result, err := c.CategoryService.Update(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrCategoryNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "category not found", err.Error())
+			return
+		case errors.Is(err, service.ErrInvalidParent):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid parent category", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (c *categoryHandlerImpl) Delete(ctx *gin.Context) {
+	id := ctx.Param("id")
+	categoryId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	if err := c.CategoryService.Delete(ctx, uint(categoryId)); err != nil {
+		switch {
+		case errors.Is(err, service.ErrCategoryNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "category not found", err.Error())
+			return
+		case errors.Is(err, service.ErrCategoryHasChildren):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "delete or move the sub categories first", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "deleted", nil)
+}
+
+func (c *categoryHandlerImpl) FindById(ctx *gin.Context) {
+	id := ctx.Param("id")
+	categoryId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := c.CategoryService.FindById(ctx, uint(categoryId))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCategoryNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "category not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (c *categoryHandlerImpl) FindAll(ctx *gin.Context) {
+	result, err := c.CategoryService.FindAll(ctx)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
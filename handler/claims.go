@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"simple-toko/service"
+	t "simple-toko/web"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actorId is the user making the request, admins included, for the records
+// that keep who did something.
+func actorId(ctx *gin.Context) uint {
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	return user.UserID
+}
+
+func ownerId(ctx *gin.Context) uint {
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	if user.Role == service.Admin {
+		return 0
+	}
+
+	return user.UserID
+}
@@ -90,6 +90,9 @@ func (i *inventoryHandlerImpl) Delete(ctx *gin.Context) {
 		case errors.Is(err, service.ErrorIdNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
 			return
+		case errors.Is(err, service.ErrInventoryInUse):
+			helper.ToResponseJson(ctx, http.StatusConflict, "inventory in use", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", nil)
 			return
@@ -8,5 +8,14 @@ type OrderHandler interface {
 	Delete(ctx *gin.Context)
 	FindById(ctx *gin.Context)
 	FindAll(ctx *gin.Context)
+	FindByUser(ctx *gin.Context)
 	ConfirmOrder(ctx *gin.Context)
+	CancelOrder(ctx *gin.Context)
+	CancelOrderByAdmin(ctx *gin.Context)
+	FindHistory(ctx *gin.Context)
+	AddItem(ctx *gin.Context)
+	UpdateItemQty(ctx *gin.Context)
+	RemoveItem(ctx *gin.Context)
+	Invoice(ctx *gin.Context)
+	PackingSlip(ctx *gin.Context)
 }
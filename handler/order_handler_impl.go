@@ -2,6 +2,8 @@ package handler
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"simple-toko/helper"
 	"simple-toko/service"
@@ -50,6 +52,12 @@ func (o *orderHandlerImpl) CreateOrder(ctx *gin.Context) {
 		case errors.Is(err, service.ErrProductNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "product not found", err.Error())
 			return
+		case errors.Is(err, service.ErrVariantNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantRequired):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+			return
 		case errors.Is(err, service.ErrNotEnoughStock):
 			helper.ToResponseJson(ctx, http.StatusBadRequest, "stock not enough", err.Error())
 			return
@@ -78,6 +86,7 @@ func (o *orderHandlerImpl) UpdateAddress(ctx *gin.Context) {
 	}
 
 	req.ID = uint(orderId)
+	req.UserID = ownerId(ctx)
 
 	result, err := o.OrderService.UpdateAddress(ctx, &req)
 	if err != nil {
@@ -132,7 +141,7 @@ func (o *orderHandlerImpl) FindById(ctx *gin.Context) {
 		return
 	}
 
-	result, err := o.OrderService.FindById(ctx, uint(orderId))
+	result, err := o.OrderService.FindById(ctx, uint(orderId), ownerId(ctx))
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrOrderNotFound):
@@ -149,6 +158,7 @@ func (o *orderHandlerImpl) FindById(ctx *gin.Context) {
 func (o *orderHandlerImpl) FindAll(ctx *gin.Context) {
 	pageStr := ctx.DefaultQuery("page", "1")
 	pageSizeStr := ctx.DefaultQuery("page_size", "5")
+	search := ctx.DefaultQuery("search", "")
 
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
@@ -160,7 +170,7 @@ func (o *orderHandlerImpl) FindAll(ctx *gin.Context) {
 		pageSize = 5
 	}
 
-	result, err := o.OrderService.FindAll(ctx, page, pageSize)
+	result, err := o.OrderService.FindAll(ctx, page, pageSize, search)
 	if err != nil {
 		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
 		return
@@ -169,6 +179,46 @@ func (o *orderHandlerImpl) FindAll(ctx *gin.Context) {
 	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
 }
 
+func (o *orderHandlerImpl) FindByUser(ctx *gin.Context) {
+	pageStr := ctx.DefaultQuery("page", "1")
+	pageSizeStr := ctx.DefaultQuery("page_size", "5")
+
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	req := web.OrderFilterRequest{
+		UserID:         user.UserID,
+		StatusOrder:    ctx.Query("status_order"),
+		StatusDelivery: ctx.Query("status_delivery"),
+		DateFrom:       ctx.Query("date_from"),
+		DateTo:         ctx.Query("date_to"),
+	}
+
+	result, err := o.OrderService.FindByUser(ctx, page, pageSize, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
 func (o *orderHandlerImpl) ConfirmOrder(ctx *gin.Context) {
 	req := web.OrderUpdateStatusRequest{}
 
@@ -184,7 +234,11 @@ func (o *orderHandlerImpl) ConfirmOrder(ctx *gin.Context) {
 		return
 	}
 
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
 	req.ID = uint(orderId)
+	req.UserID = user.UserID
 
 	result, err := o.OrderService.ConfirmOrder(ctx, &req)
 	if err != nil {
@@ -195,6 +249,15 @@ func (o *orderHandlerImpl) ConfirmOrder(ctx *gin.Context) {
 		case errors.Is(err, service.ErrorValidation):
 			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
 			return
+		case errors.Is(err, service.ErrInvalidTransition):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid status transition", err.Error())
+			return
+		case errors.Is(err, service.ErrPaymentNotConfirmed):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "payment not confirmed", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderStatusChanged):
+			helper.ToResponseJson(ctx, http.StatusConflict, "order status changed", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
 			return
@@ -203,3 +266,346 @@ func (o *orderHandlerImpl) ConfirmOrder(ctx *gin.Context) {
 
 	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
 }
+
+func (o *orderHandlerImpl) CancelOrder(ctx *gin.Context) {
+	req := web.OrderCancelRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("id")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	req.ID = uint(orderId)
+	req.UserID = user.UserID
+
+	result, err := o.OrderService.CancelOrder(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "orders not found", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotCancel):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "order cannot be canceled", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderStatusChanged):
+			helper.ToResponseJson(ctx, http.StatusConflict, "order status changed", err.Error())
+			return
+		case errors.Is(err, service.ErrStockNotRestorable):
+			helper.ToResponseJson(ctx, http.StatusConflict, "stock of the order cannot be put back", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "canceled", result)
+}
+
+func (o *orderHandlerImpl) CancelOrderByAdmin(ctx *gin.Context) {
+	req := web.OrderCancelRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("id")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	req.ID = uint(orderId)
+	req.UserID = user.UserID
+
+	result, err := o.OrderService.CancelOrderByAdmin(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "orders not found", err.Error())
+			return
+		case errors.Is(err, service.ErrInvalidTransition):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "order cannot be canceled", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderStatusChanged):
+			helper.ToResponseJson(ctx, http.StatusConflict, "order status changed", err.Error())
+			return
+		case errors.Is(err, service.ErrStockNotRestorable):
+			helper.ToResponseJson(ctx, http.StatusConflict, "stock of the order cannot be put back", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "canceled", result)
+}
+
+func (o *orderHandlerImpl) FindHistory(ctx *gin.Context) {
+	id := ctx.Param("id")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := o.OrderService.FindHistory(ctx, uint(orderId), ownerId(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "orders not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (o *orderHandlerImpl) AddItem(ctx *gin.Context) {
+	req := web.OrderItemRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("id")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(orderId)
+	req.UserID = ownerId(ctx)
+	req.ActorID = actorId(ctx)
+
+	result, err := o.OrderService.AddItem(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "orders not found", err.Error())
+			return
+		case errors.Is(err, service.ErrProductNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "product not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantRequired):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotEditable):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "order cannot be edited", err.Error())
+			return
+		case errors.Is(err, service.ErrNotEnoughStock):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "stock not enough", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (o *orderHandlerImpl) UpdateItemQty(ctx *gin.Context) {
+	req := web.OrderItemRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("id")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	prodId := ctx.Param("productId")
+	productId, err := strconv.Atoi(prodId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	variantId, ok := variantQuery(ctx)
+	if !ok {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type variant id", nil)
+		return
+	}
+
+	req.ID = uint(orderId)
+	req.ProductID = uint(productId)
+	req.VariantID = variantId
+	req.UserID = ownerId(ctx)
+	req.ActorID = actorId(ctx)
+
+	result, err := o.OrderService.UpdateItemQty(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "orders not found", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderItemNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "order item not found", err.Error())
+			return
+		case errors.Is(err, service.ErrProductNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "product not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantRequired):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotEditable):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "order cannot be edited", err.Error())
+			return
+		case errors.Is(err, service.ErrStockNotRestorable):
+			helper.ToResponseJson(ctx, http.StatusConflict, "stock of the item cannot be put back", err.Error())
+			return
+		case errors.Is(err, service.ErrNotEnoughStock):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "stock not enough", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (o *orderHandlerImpl) RemoveItem(ctx *gin.Context) {
+	id := ctx.Param("id")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	prodId := ctx.Param("productId")
+	productId, err := strconv.Atoi(prodId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	variantId, ok := variantQuery(ctx)
+	if !ok {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type variant id", nil)
+		return
+	}
+
+	result, err := o.OrderService.RemoveItem(ctx, uint(orderId), uint(productId), variantId, ownerId(ctx), actorId(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "orders not found", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderItemNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "order item not found", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotEditable):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "order cannot be edited", err.Error())
+			return
+		case errors.Is(err, service.ErrStockNotRestorable):
+			helper.ToResponseJson(ctx, http.StatusConflict, "stock of the item cannot be put back", err.Error())
+			return
+		case errors.Is(err, service.ErrEmptyItems):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "cannot remove last item, cancel the order instead", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (o *orderHandlerImpl) Invoice(ctx *gin.Context) {
+	id := ctx.Param("id")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := o.OrderService.Invoice(ctx, uint(orderId), ownerId(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "orders not found", err.Error())
+			return
+		case errors.Is(err, service.ErrPaymentNotConfirmed):
+			helper.ToResponseJson(ctx, http.StatusForbidden, "invoice available after payment confirmed", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", result.FileName))
+	ctx.Data(http.StatusOK, "application/pdf", result.Content)
+}
+
+func (o *orderHandlerImpl) PackingSlip(ctx *gin.Context) {
+	id := ctx.Param("id")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := o.OrderService.PackingSlip(ctx, uint(orderId))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "orders not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", result.FileName))
+	ctx.Data(http.StatusOK, "application/pdf", result.Content)
+}
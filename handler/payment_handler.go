@@ -10,4 +10,6 @@ type PaymentHandler interface {
 	FindAll(ctx *gin.Context)
 	Delete(ctx *gin.Context)
 	PreviewImage(ctx *gin.Context)
+	CreateLink(ctx *gin.Context)
+	LinkImage(ctx *gin.Context)
 }
@@ -2,65 +2,72 @@ package handler
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
-	"os"
 	"simple-toko/helper"
 	"simple-toko/service"
 	web "simple-toko/web/payment"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type paymentHandlerImpl struct {
 	PaymentService service.PaymentService
+	UploadService  service.UploadService
 }
 
-func NewPaymentHandlerImpl(paymentService service.PaymentService) *paymentHandlerImpl {
+func NewPaymentHandlerImpl(paymentService service.PaymentService, uploadService service.UploadService) *paymentHandlerImpl {
 	return &paymentHandlerImpl{
 		PaymentService: paymentService,
+		UploadService:  uploadService,
 	}
 }
 
-var Path = "uploads/payment/"
+var Path = "payment/"
+
+// linkPath is the public route of LinkImage.
+const linkPath = "/api/v1/files/payment/"
 
 func (pay *paymentHandlerImpl) UploadPayment(ctx *gin.Context) {
 	req := web.PaymentCreateRequest{}
 
+	limitBody(ctx, pay.UploadService, 1)
+
 	if err := ctx.ShouldBind(&req); err != nil {
+		if bodyTooLarge(err) {
+			uploadError(ctx, err)
+			return
+		}
 		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
 		return
 	}
 
-	file, err := ctx.FormFile("image")
+	fileName, err := saveUpload(ctx, pay.UploadService, "image", Path, service.ProofTypes...)
 	if err != nil {
-		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type file", err.Error())
-		return
-	}
-
-	fileName := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-	if err := ctx.SaveUploadedFile(file, Path+fileName); err != nil {
-		helper.ToResponseJson(ctx, http.StatusInternalServerError, "failed upload file", err.Error())
+		uploadError(ctx, err)
 		return
 	}
 
 	req.Image = fileName
+	req.UserID = ownerId(ctx)
 
 	result, err := pay.PaymentService.UploadPayment(ctx, &req)
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrorValidation):
-			os.Remove(Path + fileName)
+			pay.UploadService.Remove(ctx, Path, fileName)
 			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
 			return
 		case errors.Is(err, service.ErrOrderNotFound):
-			os.Remove(Path + fileName)
+			pay.UploadService.Remove(ctx, Path, fileName)
 			helper.ToResponseJson(ctx, http.StatusNotFound, "order not found", err.Error())
 			return
+		case errors.Is(err, service.ErrOrderNotPayable):
+			pay.UploadService.Remove(ctx, Path, fileName)
+			helper.ToResponseJson(ctx, http.StatusConflict, "order is no longer waiting for payment", err.Error())
+			return
 		default:
-			os.Remove(Path + fileName)
+			pay.UploadService.Remove(ctx, Path, fileName)
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "failed save file", err.Error())
 			return
 		}
@@ -95,6 +102,12 @@ func (pay *paymentHandlerImpl) UpdateStatus(ctx *gin.Context) {
 		case errors.Is(err, service.ErrOrderNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "order not found", err.Error())
 			return
+		case errors.Is(err, service.ErrPaymentNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "payment not found", err.Error())
+			return
+		case errors.Is(err, service.ErrPaymentNotConfirmable):
+			helper.ToResponseJson(ctx, http.StatusConflict, "order is canceled", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
 			return
@@ -134,12 +147,15 @@ func (pay *paymentHandlerImpl) FindByOrderId(ctx *gin.Context) {
 		return
 	}
 
-	result, err := pay.PaymentService.FindByOrderId(ctx, uint(orderId))
+	result, err := pay.PaymentService.FindByOrderId(ctx, uint(orderId), ownerId(ctx))
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrOrderNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "order not found", err.Error())
 			return
+		case errors.Is(err, service.ErrPaymentNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "payment not found", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
 			return
@@ -202,12 +218,15 @@ func (pay *paymentHandlerImpl) PreviewImage(ctx *gin.Context) {
 		return
 	}
 
-	result, err := pay.PaymentService.FindByOrderId(ctx, uint(orderId))
+	result, err := pay.PaymentService.FindByOrderId(ctx, uint(orderId), ownerId(ctx))
 	if err != nil {
 		switch {
 		case errors.Is(err, service.ErrOrderNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "order not found", err.Error())
 			return
+		case errors.Is(err, service.ErrPaymentNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "payment not found", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
 			return
@@ -219,12 +238,81 @@ func (pay *paymentHandlerImpl) PreviewImage(ctx *gin.Context) {
 		return
 	}
 
-	file := Path + result.Image
-	download := ctx.DefaultQuery("download", "false")
+	serveUpload(ctx, pay.UploadService, Path, result.Image)
+}
 
-	if download == "true" {
-		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", result.Image))
+// CreateLink returns a signed url of the payment proof that works without a
+// token until it expires. expires_in is in minutes, an admin may add user_id
+// to limit the link to that customer.
+func (pay *paymentHandlerImpl) CreateLink(ctx *gin.Context) {
+	id := ctx.Param("orderId")
+	orderId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input tpye id", err.Error())
+		return
+	}
+
+	expiresIn, err := strconv.Atoi(ctx.DefaultQuery("expires_in", "0"))
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type expires_in", err.Error())
+		return
+	}
+
+	req := web.PaymentLinkRequest{
+		OrderID:   uint(orderId),
+		UserID:    ownerId(ctx),
+		ExpiresIn: expiresIn,
+	}
+
+	if req.UserID == 0 {
+		userId, err := strconv.Atoi(ctx.DefaultQuery("user_id", "0"))
+		if err != nil || userId < 0 {
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type user_id", nil)
+			return
+		}
+		req.ScopeUserID = uint(userId)
+	}
+
+	result, err := pay.PaymentService.CreateLink(ctx, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "order not found", err.Error())
+			return
+		case errors.Is(err, service.ErrPaymentNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "payment not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	result.URL = baseURL(ctx) + linkPath + result.Image + "?" + result.Query
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+// LinkImage serves a payment proof to anyone holding a link made by
+// CreateLink, it is not behind the token middleware.
+func (pay *paymentHandlerImpl) LinkImage(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	if err := pay.PaymentService.CheckLink(ctx, name, ctx.Request.URL.Query()); err != nil {
+		switch {
+		case errors.Is(err, service.ErrLinkInvalid), errors.Is(err, service.ErrLinkExpired):
+			helper.ToResponseJson(ctx, http.StatusForbidden, "invalid or expired link", err.Error())
+			return
+		case errors.Is(err, service.ErrPaymentNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "payment not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
 	}
 
-	ctx.File(file)
+	serveUpload(ctx, pay.UploadService, Path, name)
 }
@@ -12,4 +12,13 @@ type ProductHandler interface {
 	ReduceStock(ctx *gin.Context)
 	UpdateImage(ctx *gin.Context)
 	PreviewImage(ctx *gin.Context)
+	UploadImage(ctx *gin.Context)
+	ReorderImages(ctx *gin.Context)
+	SetPrimaryImage(ctx *gin.Context)
+	DeleteImage(ctx *gin.Context)
+	PreviewGalleryImage(ctx *gin.Context)
+	SaveOptions(ctx *gin.Context)
+	CreateVariant(ctx *gin.Context)
+	UpdateVariant(ctx *gin.Context)
+	DeleteVariant(ctx *gin.Context)
 }
@@ -2,24 +2,25 @@ package handler
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
 	"simple-toko/helper"
 	"simple-toko/service"
+	"simple-toko/utils"
 	web "simple-toko/web/product"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type productHandlerImpl struct {
 	ProductService service.ProductService
+	UploadService  service.UploadService
 }
 
-func NewProductHandlerImpl(productService service.ProductService) *productHandlerImpl {
+func NewProductHandlerImpl(productService service.ProductService, uploadService service.UploadService) *productHandlerImpl {
 	return &productHandlerImpl{
 		ProductService: productService,
+		UploadService:  uploadService,
 	}
 }
 
@@ -31,6 +32,8 @@ func (p *productHandlerImpl) Create(ctx *gin.Context) {
 		return
 	}
 
+	req.UserID = actorId(ctx)
+
 	result, err := p.ProductService.Create(ctx, &req)
 	if err != nil {
 		switch {
@@ -40,6 +43,12 @@ func (p *productHandlerImpl) Create(ctx *gin.Context) {
 		case errors.Is(err, service.ErrorIdNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
 			return
+		case errors.Is(err, service.ErrCategoryNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "category not found", err.Error())
+			return
+		case errors.Is(err, service.ErrSkuExists):
+			helper.ToResponseJson(ctx, http.StatusConflict, "sku already exists", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", nil)
 			return
@@ -74,6 +83,12 @@ func (p *productHandlerImpl) Update(ctx *gin.Context) {
 		case errors.Is(err, service.ErrorIdNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
 			return
+		case errors.Is(err, service.ErrCategoryNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "category not found", err.Error())
+			return
+		case errors.Is(err, service.ErrSkuExists):
+			helper.ToResponseJson(ctx, http.StatusConflict, "sku already exists", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", nil)
 			return
@@ -131,6 +146,7 @@ func (p *productHandlerImpl) FindAll(ctx *gin.Context) {
 	pageStr := ctx.DefaultQuery("page", "1")
 	pageSizeStr := ctx.DefaultQuery("page_size", "5")
 	search := ctx.DefaultQuery("search", "")
+	categoryStr := ctx.DefaultQuery("category", "0")
 
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
@@ -142,14 +158,71 @@ func (p *productHandlerImpl) FindAll(ctx *gin.Context) {
 		pageSize = 5
 	}
 
-	result, err := p.ProductService.FindAll(ctx, page, pageSize, search)
-	if err != nil {
-		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	categoryId, err := strconv.Atoi(categoryStr)
+	if err != nil || categoryId < 0 {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type category", nil)
+		return
+	}
+
+	inventoryId, err := strconv.Atoi(ctx.DefaultQuery("inventory", "0"))
+	if err != nil || inventoryId < 0 {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type inventory", nil)
+		return
+	}
+
+	minPrice, ok := priceQuery(ctx, "min_price")
+	if !ok {
+		return
+	}
+
+	maxPrice, ok := priceQuery(ctx, "max_price")
+	if !ok {
 		return
 	}
+
+	req := web.ProductFilterRequest{
+		Search:      search,
+		CategoryID:  uint(categoryId),
+		InventoryID: uint(inventoryId),
+		MinPrice:    minPrice,
+		MaxPrice:    maxPrice,
+		InStock:     ctx.DefaultQuery("in_stock", "false") == "true",
+		Sort:        ctx.DefaultQuery("sort", ""),
+	}
+
+	result, err := p.ProductService.FindAll(ctx, page, pageSize, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrCategoryNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "category not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
 	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
 }
 
+// priceQuery reads an optional price filter, a missing value is nil.
+func priceQuery(ctx *gin.Context, key string) (*float64, bool) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, true
+	}
+
+	price, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type "+key, nil)
+		return nil, false
+	}
+
+	return &price, true
+}
+
 func (p *productHandlerImpl) AddStock(ctx *gin.Context) {
 	req := web.ProductStockUpdateRequest{}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -165,6 +238,7 @@ func (p *productHandlerImpl) AddStock(ctx *gin.Context) {
 	}
 
 	req.ID = uint(productId)
+	req.UserID = actorId(ctx)
 
 	result, err := p.ProductService.AddStock(ctx, &req)
 	if err != nil {
@@ -175,6 +249,15 @@ func (p *productHandlerImpl) AddStock(ctx *gin.Context) {
 		case errors.Is(err, service.ErrorIdNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
 			return
+		case errors.Is(err, service.ErrVariantNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantRequired):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+			return
+		case errors.Is(err, service.ErrInventoryNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "inventory not found", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", nil)
 			return
@@ -198,6 +281,7 @@ func (p *productHandlerImpl) ReduceStock(ctx *gin.Context) {
 	}
 
 	req.ID = uint(productId)
+	req.UserID = actorId(ctx)
 
 	result, err := p.ProductService.ReduceStock(ctx, &req)
 	if err != nil {
@@ -211,6 +295,15 @@ func (p *productHandlerImpl) ReduceStock(ctx *gin.Context) {
 		case errors.Is(err, service.ErrNotEnoughStock):
 			helper.ToResponseJson(ctx, http.StatusBadRequest, "not enough stock", nil)
 			return
+		case errors.Is(err, service.ErrVariantNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+			return
+		case errors.Is(err, service.ErrVariantRequired):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+			return
+		case errors.Is(err, service.ErrInventoryNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "inventory not found", err.Error())
+			return
 		default:
 			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", nil)
 			return
@@ -219,7 +312,18 @@ func (p *productHandlerImpl) ReduceStock(ctx *gin.Context) {
 	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
 }
 
+// UpdateImage is the single image upload, it now adds the file to the gallery
+// as the primary image instead of overwriting the old one.
 func (p *productHandlerImpl) UpdateImage(ctx *gin.Context) {
+	p.addImage(ctx, true)
+}
+
+func (p *productHandlerImpl) UploadImage(ctx *gin.Context) {
+	limitBody(ctx, p.UploadService, 1)
+	p.addImage(ctx, ctx.PostForm("primary") == "true")
+}
+
+func (p *productHandlerImpl) addImage(ctx *gin.Context, primary bool) {
 	id := ctx.Param("productId")
 	productId, err := strconv.Atoi(id)
 	if err != nil {
@@ -227,20 +331,15 @@ func (p *productHandlerImpl) UpdateImage(ctx *gin.Context) {
 		return
 	}
 
-	file, err := ctx.FormFile("image")
+	fileName, err := saveImage(ctx, p.UploadService, "image", utils.ProductImagePath)
 	if err != nil {
-		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type file", err.Error())
-		return
-	}
-
-	fileName := fmt.Sprintf("%d_%s", time.Now().Unix(), file.Filename)
-	if err := ctx.SaveUploadedFile(file, "uploads/product/"+fileName); err != nil {
-		helper.ToResponseJson(ctx, http.StatusInternalServerError, "failed upload file", err.Error())
+		uploadError(ctx, err)
 		return
 	}
 
-	result, err := p.ProductService.UpdateImage(ctx, uint(productId), fileName)
+	result, err := p.ProductService.AddImage(ctx, uint(productId), fileName, primary)
 	if err != nil {
+		p.UploadService.RemoveImage(ctx, utils.ProductImagePath, fileName)
 		switch {
 		case errors.Is(err, service.ErrorIdNotFound):
 			helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
@@ -253,6 +352,109 @@ func (p *productHandlerImpl) UpdateImage(ctx *gin.Context) {
 	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
 }
 
+func (p *productHandlerImpl) ReorderImages(ctx *gin.Context) {
+	req := web.ProductImageOrderRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ProductID = uint(productId)
+
+	result, err := p.ProductService.ReorderImages(ctx, &req)
+	if err != nil {
+		imageError(ctx, err)
+		return
+	}
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (p *productHandlerImpl) SetPrimaryImage(ctx *gin.Context) {
+	productId, imageId, ok := imageParams(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := p.ProductService.SetPrimaryImage(ctx, productId, imageId)
+	if err != nil {
+		imageError(ctx, err)
+		return
+	}
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (p *productHandlerImpl) DeleteImage(ctx *gin.Context) {
+	productId, imageId, ok := imageParams(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := p.ProductService.DeleteImage(ctx, productId, imageId)
+	if err != nil {
+		imageError(ctx, err)
+		return
+	}
+	helper.ToResponseJson(ctx, http.StatusOK, "deleted", result)
+}
+
+// PreviewGalleryImage serves an image of the gallery, size thumb or medium
+// picks a scaled copy and falls back to the original when it is missing.
+func (p *productHandlerImpl) PreviewGalleryImage(ctx *gin.Context) {
+	productId, imageId, ok := imageParams(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := p.ProductService.FindImage(ctx, productId, imageId)
+	if err != nil {
+		imageError(ctx, err)
+		return
+	}
+
+	serveImage(ctx, p.UploadService, utils.ProductImagePath, result.Image)
+}
+
+func imageParams(ctx *gin.Context) (uint, uint, bool) {
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return 0, 0, false
+	}
+
+	imgId := ctx.Param("imageId")
+	imageId, err := strconv.Atoi(imgId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type image id", nil)
+		return 0, 0, false
+	}
+
+	return uint(productId), uint(imageId), true
+}
+
+func imageError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrorIdNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
+	case errors.Is(err, service.ErrImageNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "image not found", err.Error())
+	case errors.Is(err, service.ErrInvalidImageOrder):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid image order", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
+
 func (p *productHandlerImpl) PreviewImage(ctx *gin.Context) {
 	id := ctx.Param("productId")
 	productId, err := strconv.Atoi(id)
@@ -278,12 +480,133 @@ func (p *productHandlerImpl) PreviewImage(ctx *gin.Context) {
 		return
 	}
 
-	file := "uploads/product/" + result.Image
-	download := ctx.DefaultQuery("download", "false")
+	serveUpload(ctx, p.UploadService, utils.ProductImagePath, result.Image)
+}
+
+func (p *productHandlerImpl) SaveOptions(ctx *gin.Context) {
+	req := web.ProductOptionRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ProductID = uint(productId)
+
+	result, err := p.ProductService.SaveOptions(ctx, &req)
+	if err != nil {
+		variantError(ctx, err)
+		return
+	}
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (p *productHandlerImpl) CreateVariant(ctx *gin.Context) {
+	req := web.ProductVariantCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ProductID = uint(productId)
+	req.UserID = actorId(ctx)
+
+	result, err := p.ProductService.CreateVariant(ctx, &req)
+	if err != nil {
+		variantError(ctx, err)
+		return
+	}
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (p *productHandlerImpl) UpdateVariant(ctx *gin.Context) {
+	req := web.ProductVariantUpdateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	varId := ctx.Param("variantId")
+	variantId, err := strconv.Atoi(varId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type variant id", nil)
+		return
+	}
+
+	req.ProductID = uint(productId)
+	req.ID = uint(variantId)
+
+	result, err := p.ProductService.UpdateVariant(ctx, &req)
+	if err != nil {
+		variantError(ctx, err)
+		return
+	}
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (p *productHandlerImpl) DeleteVariant(ctx *gin.Context) {
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	varId := ctx.Param("variantId")
+	variantId, err := strconv.Atoi(varId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type variant id", nil)
+		return
+	}
 
-	if download == "true" {
-		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", result.Image))
+	result, err := p.ProductService.DeleteVariant(ctx, uint(productId), uint(variantId), actorId(ctx))
+	if err != nil {
+		variantError(ctx, err)
+		return
 	}
+	helper.ToResponseJson(ctx, http.StatusOK, "deleted", result)
+}
 
-	ctx.File(file)
+func variantError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrorIdNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
+	case errors.Is(err, service.ErrVariantNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+	case errors.Is(err, service.ErrInvalidOptions):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid options", err.Error())
+	case errors.Is(err, service.ErrVariantExists):
+		helper.ToResponseJson(ctx, http.StatusConflict, "variant already exists", err.Error())
+	case errors.Is(err, service.ErrSkuExists):
+		helper.ToResponseJson(ctx, http.StatusConflict, "sku already exists", err.Error())
+	case errors.Is(err, service.ErrProductStockLeft):
+		helper.ToResponseJson(ctx, http.StatusConflict, "product still has stock", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
 }
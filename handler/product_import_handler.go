@@ -0,0 +1,9 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+type ProductImportHandler interface {
+	Import(ctx *gin.Context)
+	FindById(ctx *gin.Context)
+	Export(ctx *gin.Context)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	t "simple-toko/web"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type productImportHandlerImpl struct {
+	ImportService service.ProductImportService
+	UploadService service.UploadService
+}
+
+func NewProductImportHandlerImpl(importService service.ProductImportService, uploadService service.UploadService) *productImportHandlerImpl {
+	return &productImportHandlerImpl{
+		ImportService: importService,
+		UploadService: uploadService,
+	}
+}
+
+// Import only stores the file and queues it, the rows are read by the import
+// worker and the result is read from FindById.
+func (p *productImportHandlerImpl) Import(ctx *gin.Context) {
+	fileName, err := saveUpload(ctx, p.UploadService, "file", service.ProductImportPath, service.CsvTypes...)
+	if err != nil {
+		uploadError(ctx, err)
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	result, err := p.ImportService.Create(ctx, user.UserID, fileName)
+	if err != nil {
+		p.UploadService.Remove(ctx, service.ProductImportPath, fileName)
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusAccepted, "accepted", result)
+}
+
+func (p *productImportHandlerImpl) FindById(ctx *gin.Context) {
+	id := ctx.Param("id")
+	importId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := p.ImportService.FindById(ctx, uint(importId))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "import not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+// Export streams the catalog, once the first rows are sent a failure can only
+// end the file early.
+func (p *productImportHandlerImpl) Export(ctx *gin.Context) {
+	name := fmt.Sprintf("products-%s.csv", time.Now().Format("20060102"))
+
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+	ctx.Status(http.StatusOK)
+
+	if err := p.ImportService.Export(ctx, ctx.Writer); err != nil {
+		log.Printf("product export: %v\n", err)
+	}
+}
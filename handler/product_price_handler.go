@@ -0,0 +1,10 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+type ProductPriceHandler interface {
+	CreateSchedule(ctx *gin.Context)
+	FindSchedules(ctx *gin.Context)
+	CancelSchedule(ctx *gin.Context)
+	Timeline(ctx *gin.Context)
+}
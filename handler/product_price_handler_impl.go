@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	web "simple-toko/web/product"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type productPriceHandlerImpl struct {
+	PriceService service.ProductPriceService
+}
+
+func NewProductPriceHandlerImpl(priceService service.ProductPriceService) *productPriceHandlerImpl {
+	return &productPriceHandlerImpl{
+		PriceService: priceService,
+	}
+}
+
+func (p *productPriceHandlerImpl) CreateSchedule(ctx *gin.Context) {
+	req := web.ProductPriceScheduleRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ProductID = uint(productId)
+
+	result, err := p.PriceService.CreateSchedule(ctx, &req)
+	if err != nil {
+		priceError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (p *productPriceHandlerImpl) FindSchedules(ctx *gin.Context) {
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	status := ctx.DefaultQuery("status", "")
+
+	result, err := p.PriceService.FindSchedules(ctx, uint(productId), status)
+	if err != nil {
+		priceError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (p *productPriceHandlerImpl) CancelSchedule(ctx *gin.Context) {
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	schId := ctx.Param("scheduleId")
+	scheduleId, err := strconv.Atoi(schId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type schedule id", nil)
+		return
+	}
+
+	result, err := p.PriceService.CancelSchedule(ctx, uint(productId), uint(scheduleId))
+	if err != nil {
+		priceError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "canceled", result)
+}
+
+func (p *productPriceHandlerImpl) Timeline(ctx *gin.Context) {
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := p.PriceService.Timeline(ctx, uint(productId))
+	if err != nil {
+		priceError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func priceError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrScheduleInPast):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid start time", err.Error())
+	case errors.Is(err, service.ErrorIdNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
+	case errors.Is(err, service.ErrScheduleNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "price schedule not found", err.Error())
+	case errors.Is(err, service.ErrScheduleNotPending):
+		helper.ToResponseJson(ctx, http.StatusConflict, "price schedule cannot be canceled", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
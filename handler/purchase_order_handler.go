@@ -0,0 +1,13 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+type PurchaseOrderHandler interface {
+	Create(ctx *gin.Context)
+	Update(ctx *gin.Context)
+	FindById(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+	Approve(ctx *gin.Context)
+	Receive(ctx *gin.Context)
+	Cancel(ctx *gin.Context)
+}
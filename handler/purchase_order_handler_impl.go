@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	web "simple-toko/web/purchase"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type purchaseOrderHandlerImpl struct {
+	PurchaseService service.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandlerImpl(purchaseService service.PurchaseOrderService) *purchaseOrderHandlerImpl {
+	return &purchaseOrderHandlerImpl{
+		PurchaseService: purchaseService,
+	}
+}
+
+func (p *purchaseOrderHandlerImpl) Create(ctx *gin.Context) {
+	req := web.PurchaseOrderCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	req.UserID = actorId(ctx)
+
+	result, err := p.PurchaseService.Create(ctx, &req)
+	if err != nil {
+		purchaseError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (p *purchaseOrderHandlerImpl) Update(ctx *gin.Context) {
+	req := web.PurchaseOrderCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("poId")
+	poId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(poId)
+
+	result, err := p.PurchaseService.Update(ctx, &req)
+	if err != nil {
+		purchaseError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (p *purchaseOrderHandlerImpl) FindById(ctx *gin.Context) {
+	id := ctx.Param("poId")
+	poId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := p.PurchaseService.FindById(ctx, uint(poId))
+	if err != nil {
+		purchaseError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+// FindAll lists purchase orders, status=open lists the approved and partly
+// received ones by expected date.
+func (p *purchaseOrderHandlerImpl) FindAll(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "5"))
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	req := web.PurchaseOrderFilterRequest{
+		Status: ctx.Query("status"),
+	}
+
+	if v := ctx.Query("supplier_id"); v != "" {
+		supplierId, err := strconv.Atoi(v)
+		if err != nil || supplierId < 0 {
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type supplier id", nil)
+			return
+		}
+		req.SupplierID = uint(supplierId)
+	}
+
+	if v := ctx.Query("inventory_id"); v != "" {
+		inventoryId, err := strconv.Atoi(v)
+		if err != nil || inventoryId < 0 {
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type inventory id", nil)
+			return
+		}
+		req.InventoryID = uint(inventoryId)
+	}
+
+	result, err := p.PurchaseService.FindAll(ctx, page, pageSize, &req)
+	if err != nil {
+		purchaseError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (p *purchaseOrderHandlerImpl) Approve(ctx *gin.Context) {
+	id := ctx.Param("poId")
+	poId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := p.PurchaseService.Approve(ctx, uint(poId), actorId(ctx))
+	if err != nil {
+		purchaseError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (p *purchaseOrderHandlerImpl) Receive(ctx *gin.Context) {
+	req := web.GoodsReceiptRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("poId")
+	poId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(poId)
+	req.UserID = actorId(ctx)
+
+	result, err := p.PurchaseService.Receive(ctx, &req)
+	if err != nil {
+		purchaseError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (p *purchaseOrderHandlerImpl) Cancel(ctx *gin.Context) {
+	id := ctx.Param("poId")
+	poId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := p.PurchaseService.Cancel(ctx, uint(poId))
+	if err != nil {
+		purchaseError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func purchaseError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrPurchaseNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "purchase order not found", err.Error())
+	case errors.Is(err, service.ErrPurchaseLineNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "purchase order line not found", err.Error())
+	case errors.Is(err, service.ErrSupplierNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "supplier not found", err.Error())
+	case errors.Is(err, service.ErrInventoryNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "inventory not found", err.Error())
+	case errors.Is(err, service.ErrProductNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "product not found", err.Error())
+	case errors.Is(err, service.ErrVariantNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+	case errors.Is(err, service.ErrVariantRequired):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+	case errors.Is(err, service.ErrPurchaseDuplicateLine):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "duplicate purchase order line", err.Error())
+	case errors.Is(err, service.ErrPurchaseQtyExceeded):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "received qty exceeded", err.Error())
+	case errors.Is(err, service.ErrPurchaseNotReceived):
+		helper.ToResponseJson(ctx, http.StatusConflict, "nothing received yet, cancel the purchase order instead", err.Error())
+	case errors.Is(err, service.ErrPurchaseStatusChanged):
+		helper.ToResponseJson(ctx, http.StatusConflict, "purchase order status has changed, reload and try again", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// variantQuery reads the optional variant_id query of the item routes, a line
+// of a product without variants uses 0.
+func variantQuery(ctx *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(ctx.DefaultQuery("variant_id", "0"))
+	if err != nil || id < 0 {
+		return 0, false
+	}
+
+	return uint(id), true
+}
+
+// baseURL is the address clients use to reach the api, APP_URL when it is set
+// because a proxy in front may change the host and scheme.
+func baseURL(ctx *gin.Context) string {
+	if app := os.Getenv("APP_URL"); app != "" {
+		return strings.TrimSuffix(app, "/")
+	}
+
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + ctx.Request.Host
+}
@@ -0,0 +1,15 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+type ReturnHandler interface {
+	Create(ctx *gin.Context)
+	FindById(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+	FindByUser(ctx *gin.Context)
+	Approve(ctx *gin.Context)
+	Reject(ctx *gin.Context)
+	Receive(ctx *gin.Context)
+	Refund(ctx *gin.Context)
+	PreviewImage(ctx *gin.Context)
+}
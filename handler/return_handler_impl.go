@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	t "simple-toko/web"
+	web "simple-toko/web/returns"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type returnHandlerImpl struct {
+	ReturnService service.ReturnService
+	UploadService service.UploadService
+}
+
+func NewReturnHandlerImpl(returnService service.ReturnService, uploadService service.UploadService) *returnHandlerImpl {
+	return &returnHandlerImpl{
+		ReturnService: returnService,
+		UploadService: uploadService,
+	}
+}
+
+var ReturnPath = "return/"
+
+func (r *returnHandlerImpl) Create(ctx *gin.Context) {
+	req := web.ReturnCreateRequest{}
+
+	limitBody(ctx, r.UploadService, 1)
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		if bodyTooLarge(err) {
+			uploadError(ctx, err)
+			return
+		}
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	//photo is optional
+	fileName, err := saveUpload(ctx, r.UploadService, "image", ReturnPath, service.ImageTypes...)
+	if err != nil && !errors.Is(err, http.ErrMissingFile) {
+		uploadError(ctx, err)
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	req.Image = fileName
+	req.UserID = user.UserID
+
+	result, err := r.ReturnService.Create(ctx, &req)
+	if err != nil {
+		r.UploadService.Remove(ctx, ReturnPath, fileName)
+
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderItemNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "order item not found", err.Error())
+			return
+		case errors.Is(err, service.ErrOrderNotDelivered):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "order not delivered yet", err.Error())
+			return
+		case errors.Is(err, service.ErrReturnQtyExceeded):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "return qty exceeded", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (r *returnHandlerImpl) FindById(ctx *gin.Context) {
+	id := ctx.Param("id")
+	returnId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := r.ReturnService.FindById(ctx, uint(returnId), ownerId(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrReturnNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "return not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (r *returnHandlerImpl) FindAll(ctx *gin.Context) {
+	r.findAll(ctx, 0)
+}
+
+func (r *returnHandlerImpl) FindByUser(ctx *gin.Context) {
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	r.findAll(ctx, user.UserID)
+}
+
+func (r *returnHandlerImpl) Approve(ctx *gin.Context) {
+	req, ok := r.bindReview(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := r.ReturnService.Approve(ctx, req)
+	if err != nil {
+		returnError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (r *returnHandlerImpl) Reject(ctx *gin.Context) {
+	req, ok := r.bindReview(ctx)
+	if !ok {
+		return
+	}
+
+	result, err := r.ReturnService.Reject(ctx, req)
+	if err != nil {
+		returnError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (r *returnHandlerImpl) Receive(ctx *gin.Context) {
+	req := web.ReturnReceiveRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("id")
+	returnId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(returnId)
+	req.UserID = actorId(ctx)
+
+	result, err := r.ReturnService.Receive(ctx, &req)
+	if err != nil {
+		returnError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (r *returnHandlerImpl) Refund(ctx *gin.Context) {
+	req := web.ReturnRefundRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("id")
+	returnId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(returnId)
+
+	result, err := r.ReturnService.Refund(ctx, &req)
+	if err != nil {
+		returnError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (r *returnHandlerImpl) PreviewImage(ctx *gin.Context) {
+	id := ctx.Param("id")
+	returnId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := r.ReturnService.FindById(ctx, uint(returnId), ownerId(ctx))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrReturnNotFound):
+			helper.ToResponseJson(ctx, http.StatusNotFound, "return not found", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	if result.Image == "" {
+		helper.ToResponseJson(ctx, http.StatusNotFound, "image not found", nil)
+		return
+	}
+
+	serveUpload(ctx, r.UploadService, ReturnPath, result.Image)
+}
+
+func (r *returnHandlerImpl) findAll(ctx *gin.Context, userId uint) {
+	pageStr := ctx.DefaultQuery("page", "1")
+	pageSizeStr := ctx.DefaultQuery("page_size", "5")
+	status := ctx.DefaultQuery("status", "")
+
+	page, err := strconv.Atoi(pageStr)
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(pageSizeStr)
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	result, err := r.ReturnService.FindAll(ctx, page, pageSize, userId, status)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrorValidation):
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+			return
+		default:
+			helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (r *returnHandlerImpl) bindReview(ctx *gin.Context) (*web.ReturnReviewRequest, bool) {
+	req := web.ReturnReviewRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return nil, false
+	}
+
+	id := ctx.Param("id")
+	returnId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return nil, false
+	}
+
+	req.ID = uint(returnId)
+	return &req, true
+}
+
+func returnError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrReturnNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "return not found", err.Error())
+	case errors.Is(err, service.ErrReturnNotAllowed):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "return cannot be processed", err.Error())
+	case errors.Is(err, service.ErrRefundAmountExceeded):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "refund amount exceeded", err.Error())
+	case errors.Is(err, service.ErrReturnStatusChanged):
+		helper.ToResponseJson(ctx, http.StatusConflict, "return status has changed, reload and try again", err.Error())
+	case errors.Is(err, service.ErrStockNotRestorable):
+		helper.ToResponseJson(ctx, http.StatusConflict, "stock cannot be put back, receive without restock", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
@@ -0,0 +1,13 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+type ReviewHandler interface {
+	Create(ctx *gin.Context)
+	FindByProduct(ctx *gin.Context)
+	FindByUser(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+	Hide(ctx *gin.Context)
+	Unhide(ctx *gin.Context)
+	PreviewImage(ctx *gin.Context)
+}
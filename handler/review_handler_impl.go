@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	t "simple-toko/web"
+	web "simple-toko/web/review"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type reviewHandlerImpl struct {
+	ReviewService service.ReviewService
+	UploadService service.UploadService
+}
+
+func NewReviewHandlerImpl(reviewService service.ReviewService, uploadService service.UploadService) *reviewHandlerImpl {
+	return &reviewHandlerImpl{
+		ReviewService: reviewService,
+		UploadService: uploadService,
+	}
+}
+
+var ReviewPath = "review/"
+
+func (r *reviewHandlerImpl) Create(ctx *gin.Context) {
+	req := web.ReviewCreateRequest{}
+
+	limitBody(ctx, r.UploadService, service.MaxReviewImages)
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		if bodyTooLarge(err) {
+			uploadError(ctx, err)
+			return
+		}
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	req.UserID = user.UserID
+
+	//the order line is checked before the photos are decoded and stored
+	if err := r.ReviewService.CheckReviewable(ctx, &req); err != nil {
+		reviewError(ctx, err)
+		return
+	}
+
+	//photos are optional
+	var files []string
+	if form, err := ctx.MultipartForm(); err == nil {
+		if len(form.File["images"]) > service.MaxReviewImages {
+			reviewError(ctx, service.ErrReviewImages)
+			return
+		}
+
+		for _, v := range form.File["images"] {
+			fileName, err := r.UploadService.SaveImage(ctx, v, ReviewPath)
+			if err != nil {
+				r.removeImages(ctx, files)
+				uploadError(ctx, err)
+				return
+			}
+			files = append(files, fileName)
+		}
+	}
+
+	req.Images = files
+
+	result, err := r.ReviewService.Create(ctx, &req)
+	if err != nil {
+		r.removeImages(ctx, files)
+		reviewError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (r *reviewHandlerImpl) FindByProduct(ctx *gin.Context) {
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	page, pageSize := reviewPage(ctx)
+
+	result, err := r.ReviewService.FindByProduct(ctx, page, pageSize, uint(productId))
+	if err != nil {
+		reviewError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (r *reviewHandlerImpl) FindByUser(ctx *gin.Context) {
+	claims, _ := ctx.Get("user")
+	user := claims.(*t.TokenClaim)
+
+	page, pageSize := reviewPage(ctx)
+
+	result, err := r.ReviewService.FindByUser(ctx, page, pageSize, user.UserID)
+	if err != nil {
+		reviewError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (r *reviewHandlerImpl) FindAll(ctx *gin.Context) {
+	page, pageSize := reviewPage(ctx)
+	hidden := ctx.DefaultQuery("hidden", "")
+
+	result, err := r.ReviewService.FindAll(ctx, page, pageSize, hidden)
+	if err != nil {
+		reviewError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (r *reviewHandlerImpl) Hide(ctx *gin.Context) {
+	req := web.ReviewHideRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("id")
+	reviewId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(reviewId)
+
+	result, err := r.ReviewService.Hide(ctx, &req)
+	if err != nil {
+		reviewError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (r *reviewHandlerImpl) Unhide(ctx *gin.Context) {
+	id := ctx.Param("id")
+	reviewId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := r.ReviewService.Unhide(ctx, uint(reviewId))
+	if err != nil {
+		reviewError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (r *reviewHandlerImpl) PreviewImage(ctx *gin.Context) {
+	id := ctx.Param("id")
+	reviewId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	imgId := ctx.Param("imageId")
+	imageId, err := strconv.Atoi(imgId)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type image id", nil)
+		return
+	}
+
+	result, err := r.ReviewService.FindById(ctx, uint(reviewId), ownerId(ctx))
+	if err != nil {
+		reviewError(ctx, err)
+		return
+	}
+
+	for _, v := range result.Images {
+		if v.ID == uint(imageId) {
+			serveImage(ctx, r.UploadService, ReviewPath, v.Image)
+			return
+		}
+	}
+
+	helper.ToResponseJson(ctx, http.StatusNotFound, "image not found", nil)
+}
+
+func (r *reviewHandlerImpl) removeImages(ctx *gin.Context, files []string) {
+	for _, v := range files {
+		r.UploadService.RemoveImage(ctx, ReviewPath, v)
+	}
+}
+
+func reviewPage(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "5"))
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	return page, pageSize
+}
+
+func reviewError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrReviewImages):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "too many images", err.Error())
+	case errors.Is(err, service.ErrOrderItemNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "order item not found", err.Error())
+	case errors.Is(err, service.ErrReviewNotDelivered):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "order not delivered yet", err.Error())
+	case errors.Is(err, service.ErrReviewExists):
+		helper.ToResponseJson(ctx, http.StatusConflict, "order item already reviewed", err.Error())
+	case errors.Is(err, service.ErrReviewNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "review not found", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
@@ -0,0 +1,9 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+type StockHandler interface {
+	FindByProduct(ctx *gin.Context)
+	FindByInventory(ctx *gin.Context)
+	FindMovements(ctx *gin.Context)
+}
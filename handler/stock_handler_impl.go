@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	web "simple-toko/web/stock"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type stockHandlerImpl struct {
+	StockService service.StockService
+}
+
+func NewStockHandlerImpl(stockService service.StockService) *stockHandlerImpl {
+	return &stockHandlerImpl{
+		StockService: stockService,
+	}
+}
+
+func (s *stockHandlerImpl) FindByProduct(ctx *gin.Context) {
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := s.StockService.FindByProduct(ctx, uint(productId))
+	if err != nil {
+		stockError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (s *stockHandlerImpl) FindByInventory(ctx *gin.Context) {
+	id := ctx.Param("invId")
+	invId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "5"))
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	result, err := s.StockService.FindByInventory(ctx, uint(invId), page, pageSize)
+	if err != nil {
+		stockError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (s *stockHandlerImpl) FindMovements(ctx *gin.Context) {
+	id := ctx.Param("productId")
+	productId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "5"))
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	req := web.StockMovementFilterRequest{
+		ProductID: uint(productId),
+		Reason:    ctx.Query("reason"),
+		DateFrom:  ctx.Query("date_from"),
+		DateTo:    ctx.Query("date_to"),
+	}
+
+	if v := ctx.Query("variant_id"); v != "" {
+		variantId, err := strconv.Atoi(v)
+		if err != nil || variantId < 0 {
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type variant id", nil)
+			return
+		}
+		vid := uint(variantId)
+		req.VariantID = &vid
+	}
+
+	if v := ctx.Query("inventory_id"); v != "" {
+		inventoryId, err := strconv.Atoi(v)
+		if err != nil || inventoryId < 0 {
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type inventory id", nil)
+			return
+		}
+		req.InventoryID = uint(inventoryId)
+	}
+
+	result, err := s.StockService.FindMovements(ctx, page, pageSize, &req)
+	if err != nil {
+		stockError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func stockError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrorIdNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "id not found", nil)
+	case errors.Is(err, service.ErrInventoryNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "inventory not found", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	web "simple-toko/web/stock"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type stockTransferHandlerImpl struct {
+	TransferService service.StockTransferService
+}
+
+func NewStockTransferHandlerImpl(transferService service.StockTransferService) *stockTransferHandlerImpl {
+	return &stockTransferHandlerImpl{
+		TransferService: transferService,
+	}
+}
+
+func (s *stockTransferHandlerImpl) Create(ctx *gin.Context) {
+	req := web.StockTransferCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	req.UserID = actorId(ctx)
+
+	result, err := s.TransferService.Create(ctx, &req)
+	if err != nil {
+		transferError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (s *stockTransferHandlerImpl) Update(ctx *gin.Context) {
+	req := web.StockTransferCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("transferId")
+	transferId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(transferId)
+
+	result, err := s.TransferService.Update(ctx, &req)
+	if err != nil {
+		transferError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (s *stockTransferHandlerImpl) FindById(ctx *gin.Context) {
+	id := ctx.Param("transferId")
+	transferId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := s.TransferService.FindById(ctx, uint(transferId))
+	if err != nil {
+		transferError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (s *stockTransferHandlerImpl) FindAll(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "5"))
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	req := web.StockTransferFilterRequest{
+		Status: ctx.Query("status"),
+	}
+
+	if v := ctx.Query("inventory_id"); v != "" {
+		inventoryId, err := strconv.Atoi(v)
+		if err != nil || inventoryId < 0 {
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type inventory id", nil)
+			return
+		}
+		req.InventoryID = uint(inventoryId)
+	}
+
+	result, err := s.TransferService.FindAll(ctx, page, pageSize, &req)
+	if err != nil {
+		transferError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (s *stockTransferHandlerImpl) Ship(ctx *gin.Context) {
+	id := ctx.Param("transferId")
+	transferId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := s.TransferService.Ship(ctx, uint(transferId), actorId(ctx))
+	if err != nil {
+		transferError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (s *stockTransferHandlerImpl) Receive(ctx *gin.Context) {
+	req := web.StockTransferReceiveRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("transferId")
+	transferId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(transferId)
+	req.UserID = actorId(ctx)
+
+	result, err := s.TransferService.Receive(ctx, &req)
+	if err != nil {
+		transferError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (s *stockTransferHandlerImpl) Cancel(ctx *gin.Context) {
+	id := ctx.Param("transferId")
+	transferId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := s.TransferService.Cancel(ctx, uint(transferId))
+	if err != nil {
+		transferError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func transferError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrTransferNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "stock transfer not found", err.Error())
+	case errors.Is(err, service.ErrTransferLineNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "stock transfer line not found", err.Error())
+	case errors.Is(err, service.ErrInventoryNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "inventory not found", err.Error())
+	case errors.Is(err, service.ErrProductNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "product not found", err.Error())
+	case errors.Is(err, service.ErrVariantNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+	case errors.Is(err, service.ErrVariantRequired):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+	case errors.Is(err, service.ErrTransferSameLocation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "same source and destination", err.Error())
+	case errors.Is(err, service.ErrTransferDuplicateLine):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "duplicate transfer line", err.Error())
+	case errors.Is(err, service.ErrTransferQtyExceeded):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "received qty exceeded", err.Error())
+	case errors.Is(err, service.ErrNotEnoughStock):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "not enough stock at the source", err.Error())
+	case errors.Is(err, service.ErrTransferStatusChanged):
+		helper.ToResponseJson(ctx, http.StatusConflict, "stock transfer status has changed, reload and try again", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
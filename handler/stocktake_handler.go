@@ -0,0 +1,13 @@
+package handler
+
+import "github.com/gin-gonic/gin"
+
+type StocktakeHandler interface {
+	Create(ctx *gin.Context)
+	FindById(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+	Count(ctx *gin.Context)
+	UploadCounts(ctx *gin.Context)
+	Approve(ctx *gin.Context)
+	Cancel(ctx *gin.Context)
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	web "simple-toko/web/stock"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type stocktakeHandlerImpl struct {
+	StocktakeService service.StocktakeService
+	UploadService    service.UploadService
+}
+
+func NewStocktakeHandlerImpl(stocktakeService service.StocktakeService, uploadService service.UploadService) *stocktakeHandlerImpl {
+	return &stocktakeHandlerImpl{
+		StocktakeService: stocktakeService,
+		UploadService:    uploadService,
+	}
+}
+
+func (s *stocktakeHandlerImpl) Create(ctx *gin.Context) {
+	req := web.StocktakeCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	req.UserID = actorId(ctx)
+
+	result, err := s.StocktakeService.Create(ctx, &req)
+	if err != nil {
+		stocktakeError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+// FindById returns the session with its lines, variance=true keeps only the
+// counted lines that differ from expected.
+func (s *stocktakeHandlerImpl) FindById(ctx *gin.Context) {
+	id := ctx.Param("stocktakeId")
+	stocktakeId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	varianceOnly, _ := strconv.ParseBool(ctx.Query("variance"))
+
+	result, err := s.StocktakeService.FindById(ctx, uint(stocktakeId), varianceOnly)
+	if err != nil {
+		stocktakeError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (s *stocktakeHandlerImpl) FindAll(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "5"))
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	req := web.StocktakeFilterRequest{
+		Status: ctx.Query("status"),
+	}
+
+	if v := ctx.Query("inventory_id"); v != "" {
+		inventoryId, err := strconv.Atoi(v)
+		if err != nil || inventoryId < 0 {
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type inventory id", nil)
+			return
+		}
+		req.InventoryID = uint(inventoryId)
+	}
+
+	result, err := s.StocktakeService.FindAll(ctx, page, pageSize, &req)
+	if err != nil {
+		stocktakeError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (s *stocktakeHandlerImpl) Count(ctx *gin.Context) {
+	req := web.StocktakeCountsRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("stocktakeId")
+	stocktakeId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	req.ID = uint(stocktakeId)
+	req.UserID = actorId(ctx)
+
+	result, err := s.StocktakeService.Count(ctx, &req)
+	if err != nil {
+		stocktakeError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+// UploadCounts takes the counts as a csv file with the columns sku and
+// counted.
+func (s *stocktakeHandlerImpl) UploadCounts(ctx *gin.Context) {
+	id := ctx.Param("stocktakeId")
+	stocktakeId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	fileName, err := saveUpload(ctx, s.UploadService, "file", service.StocktakePath, service.CsvTypes...)
+	if err != nil {
+		uploadError(ctx, err)
+		return
+	}
+
+	result, err := s.StocktakeService.UploadCounts(ctx, uint(stocktakeId), actorId(ctx), fileName)
+	if err != nil {
+		stocktakeError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (s *stocktakeHandlerImpl) Approve(ctx *gin.Context) {
+	id := ctx.Param("stocktakeId")
+	stocktakeId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := s.StocktakeService.Approve(ctx, uint(stocktakeId), actorId(ctx))
+	if err != nil {
+		stocktakeError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (s *stocktakeHandlerImpl) Cancel(ctx *gin.Context) {
+	id := ctx.Param("stocktakeId")
+	stocktakeId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := s.StocktakeService.Cancel(ctx, uint(stocktakeId))
+	if err != nil {
+		stocktakeError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func stocktakeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrStocktakeNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "stocktake not found", err.Error())
+	case errors.Is(err, service.ErrStocktakeLineNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "stocktake line not found", err.Error())
+	case errors.Is(err, service.ErrStocktakeSkuNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "sku not found", err.Error())
+	case errors.Is(err, service.ErrInventoryNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "inventory not found", err.Error())
+	case errors.Is(err, service.ErrVariantNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "variant not found", err.Error())
+	case errors.Is(err, service.ErrVariantRequired):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "variant is required", err.Error())
+	case errors.Is(err, service.ErrStocktakeDuplicate):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "duplicate count", err.Error())
+	case errors.Is(err, service.ErrStocktakeFile):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid count file", err.Error())
+	case errors.Is(err, service.ErrNotEnoughStock):
+		helper.ToResponseJson(ctx, http.StatusConflict, "stock was sold below the shortage while counting, count again", err.Error())
+	case errors.Is(err, service.ErrStocktakeOpen):
+		helper.ToResponseJson(ctx, http.StatusConflict, "inventory already has an open stocktake", err.Error())
+	case errors.Is(err, service.ErrStocktakeStatusChanged):
+		helper.ToResponseJson(ctx, http.StatusConflict, "stocktake status has changed, reload and try again", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/service"
+	web "simple-toko/web/supplier"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type supplierHandlerImpl struct {
+	SupplierService service.SupplierService
+}
+
+func NewSupplierHandlerImpl(supplierService service.SupplierService) *supplierHandlerImpl {
+	return &supplierHandlerImpl{
+		SupplierService: supplierService,
+	}
+}
+
+func (s *supplierHandlerImpl) Create(ctx *gin.Context) {
+	req := web.SupplierCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	result, err := s.SupplierService.Create(ctx, &req)
+	if err != nil {
+		supplierError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusCreated, "created", result)
+}
+
+func (s *supplierHandlerImpl) Update(ctx *gin.Context) {
+	req := web.SupplierCreateRequest{}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid request", err.Error())
+		return
+	}
+
+	id := ctx.Param("supplierId")
+	supplierId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := s.SupplierService.Update(ctx, uint(supplierId), &req)
+	if err != nil {
+		supplierError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "updated", result)
+}
+
+func (s *supplierHandlerImpl) Delete(ctx *gin.Context) {
+	id := ctx.Param("supplierId")
+	supplierId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	if err := s.SupplierService.Delete(ctx, uint(supplierId)); err != nil {
+		supplierError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "deleted", nil)
+}
+
+func (s *supplierHandlerImpl) FindById(ctx *gin.Context) {
+	id := ctx.Param("supplierId")
+	supplierId, err := strconv.Atoi(id)
+	if err != nil {
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type id", nil)
+		return
+	}
+
+	result, err := s.SupplierService.FindById(ctx, uint(supplierId))
+	if err != nil {
+		supplierError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func (s *supplierHandlerImpl) FindAll(ctx *gin.Context) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("page_size", "5"))
+	if err != nil || pageSize < 1 {
+		pageSize = 5
+	}
+
+	result, err := s.SupplierService.FindAll(ctx, page, pageSize, ctx.Query("search"))
+	if err != nil {
+		supplierError(ctx, err)
+		return
+	}
+
+	helper.ToResponseJson(ctx, http.StatusOK, "success", result)
+}
+
+func supplierError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrorValidation):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input", err.Error())
+	case errors.Is(err, service.ErrSupplierNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "supplier not found", err.Error())
+	case errors.Is(err, service.ErrSupplierInUse):
+		helper.ToResponseJson(ctx, http.StatusConflict, "supplier in use", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+	}
+}
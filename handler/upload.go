@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"simple-toko/helper"
+	"simple-toko/imaging"
+	"simple-toko/service"
+	"simple-toko/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// saveUpload stores the multipart file of field in dir through the upload
+// service and returns the stored file name. A missing file returns
+// http.ErrMissingFile.
+func saveUpload(ctx *gin.Context, uploads service.UploadService, field, dir string, allowed ...string) (string, error) {
+	limitBody(ctx, uploads, 1)
+
+	file, err := ctx.FormFile(field)
+	if err != nil {
+		return "", err
+	}
+
+	return uploads.Save(ctx, file, dir, allowed...)
+}
+
+// saveImage is saveUpload for images, the thumbnail and medium size are
+// stored with it.
+func saveImage(ctx *gin.Context, uploads service.UploadService, field, dir string) (string, error) {
+	limitBody(ctx, uploads, 1)
+
+	file, err := ctx.FormFile(field)
+	if err != nil {
+		return "", err
+	}
+
+	return uploads.SaveImage(ctx, file, dir)
+}
+
+// limitBody stops reading the request once it is larger than files uploads
+// can be, so an oversized body is never spooled to disk. The form is read on
+// the first bind, a handler binding it before saveUpload calls this first.
+func limitBody(ctx *gin.Context, uploads service.UploadService, files int) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, uploads.MaxBodySize(files))
+}
+
+// bodyTooLarge reports whether err comes from a body cut off by limitBody.
+func bodyTooLarge(err error) bool {
+	var tooLarge *http.MaxBytesError
+	return errors.As(err, &tooLarge)
+}
+
+// serveUpload streams a stored file to the client, whichever storage holds it.
+func serveUpload(ctx *gin.Context, uploads service.UploadService, dir, name string) {
+	file, err := uploads.Open(ctx, dir, name)
+	if err != nil {
+		fileError(ctx, err)
+		return
+	}
+
+	sendFile(ctx, file, name)
+}
+
+// serveImage is serveUpload for a file saved by saveImage, the size query
+// picks the thumbnail or medium size and falls back to the original.
+func serveImage(ctx *gin.Context, uploads service.UploadService, dir, name string) {
+	sized := dir
+
+	size := ctx.DefaultQuery("size", "original")
+	for _, s := range imaging.Sizes {
+		if s.Name == size {
+			sized += s.Dir
+		}
+	}
+
+	file, err := uploads.Open(ctx, sized, name)
+	if errors.Is(err, service.ErrFileNotFound) && sized != dir {
+		file, err = uploads.Open(ctx, dir, name)
+	}
+
+	if err != nil {
+		fileError(ctx, err)
+		return
+	}
+
+	sendFile(ctx, file, name)
+}
+
+// sendFile writes an opened file and closes it, download=true asks the
+// browser to save it instead of showing it.
+func sendFile(ctx *gin.Context, file *storage.Object, name string) {
+	defer file.Body.Close()
+
+	if ctx.DefaultQuery("download", "false") == "true" {
+		ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+	}
+
+	if file.ContentType != "" {
+		ctx.Header("Content-Type", file.ContentType)
+	}
+
+	//a local file can seek, which keeps range and cache requests working
+	if body, ok := file.Body.(io.ReadSeeker); ok {
+		http.ServeContent(ctx.Writer, ctx.Request, name, file.ModTime, body)
+		return
+	}
+
+	ctx.DataFromReader(http.StatusOK, file.Size, file.ContentType, file.Body, nil)
+}
+
+func fileError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrFileNotFound):
+		helper.ToResponseJson(ctx, http.StatusNotFound, "image not found", nil)
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "failed read file", err.Error())
+	}
+}
+
+func uploadError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, http.ErrMissingFile):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "file is required", err.Error())
+	case errors.Is(err, service.ErrFileEmpty):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "file is empty", err.Error())
+	case errors.Is(err, service.ErrFileTooLarge), bodyTooLarge(err):
+		helper.ToResponseJson(ctx, http.StatusRequestEntityTooLarge, "file too large", err.Error())
+	case errors.Is(err, service.ErrFileType):
+		helper.ToResponseJson(ctx, http.StatusUnsupportedMediaType, "file type not allowed", err.Error())
+	case errors.Is(err, http.ErrNotMultipart), errors.Is(err, http.ErrMissingBoundary):
+		helper.ToResponseJson(ctx, http.StatusBadRequest, "invalid input type file", err.Error())
+	default:
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "failed upload file", err.Error())
+	}
+}
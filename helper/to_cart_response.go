@@ -0,0 +1,72 @@
+package helper
+
+import (
+	"fmt"
+	"simple-toko/entity"
+	web "simple-toko/web/cart"
+)
+
+func ToCartResponse(items []entity.CartItem, products map[uint]*entity.Product) *web.CartResponse {
+	response := &web.CartResponse{
+		Items: make([]web.CartItemInfo, 0, len(items)),
+	}
+
+	for _, v := range items {
+		info := web.CartItemInfo{
+			ProductID: v.ProductID,
+			VariantID: v.VariantID,
+			Qty:       v.Qty,
+		}
+
+		p, ok := products[v.ProductID]
+		if !ok {
+			info.Warning = "product is no longer available"
+			response.Items = append(response.Items, info)
+			continue
+		}
+
+		info.Name = p.Name
+		info.Image = p.Image
+
+		price, stock := p.Price, p.Stock
+		if v.VariantID != 0 {
+			variant := findVariant(p.Variants, v.VariantID)
+			if variant == nil {
+				info.Warning = "variant is no longer available"
+				response.Items = append(response.Items, info)
+				continue
+			}
+
+			info.Sku = variant.SKU
+			info.VariantName = variant.Title()
+			price, stock = variant.EffectivePrice(p.Price), variant.Stock
+		}
+
+		info.Price = price
+		info.Stock = stock
+		info.Subtotal = price * float64(v.Qty)
+
+		switch {
+		case stock == 0:
+			info.Warning = "out of stock"
+		case v.Qty > stock:
+			info.Warning = fmt.Sprintf("only %d left in stock", stock)
+		}
+
+		response.Items = append(response.Items, info)
+		response.TotalQty += v.Qty
+		response.TotalPrice += info.Subtotal
+	}
+
+	return response
+}
+
+func findVariant(variants []entity.ProductVariant, id uint) *entity.ProductVariant {
+	for i := range variants {
+		if variants[i].ID == id {
+			return &variants[i]
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/category"
+)
+
+func ToCategoryResponse(c *entity.Category) *web.CategoryResponse {
+	return &web.CategoryResponse{
+		ID:           c.ID,
+		ParentID:     c.ParentID,
+		Name:         c.Name,
+		Description:  c.Description,
+		ProductCount: c.ProductCount,
+		CreatedAt:    c.CreatedAt,
+		UpdatedAt:    c.UpdatedAt,
+	}
+}
+
+// ToCategoryTree nests the flat category list under their parents and returns
+// the top level categories.
+func ToCategoryTree(categories []*entity.Category) []*web.CategoryResponse {
+	nodes := make(map[uint]*web.CategoryResponse, len(categories))
+	for _, v := range categories {
+		nodes[v.ID] = ToCategoryResponse(v)
+	}
+
+	roots := make([]*web.CategoryResponse, 0)
+	for _, v := range categories {
+		node := nodes[v.ID]
+		if v.ParentID != nil {
+			if parent, ok := nodes[*v.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	return roots
+}
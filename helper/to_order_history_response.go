@@ -0,0 +1,25 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	adr "simple-toko/web/address"
+	web "simple-toko/web/order"
+)
+
+func ToOrderHistoryResponse(h *entity.OrderStatusHistory) *web.OrderHistoryResponse {
+	response := &web.OrderHistoryResponse{
+		FromStatus: h.FromStatus,
+		ToStatus:   h.ToStatus,
+		Reason:     h.Reason,
+		CreatedAt:  h.CreatedAt,
+	}
+
+	if h.User != nil {
+		response.ChangedBy = &adr.UserInfo{
+			Name:  h.User.Name,
+			Email: h.User.Email,
+		}
+	}
+
+	return response
+}
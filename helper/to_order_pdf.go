@@ -0,0 +1,212 @@
+package helper
+
+import (
+	"math"
+	"os"
+	"simple-toko/entity"
+	"simple-toko/pdf"
+	"strconv"
+	"strings"
+)
+
+const (
+	marginLeft  = 40.0
+	marginRight = pdf.PageWidth - 40.0
+	pageBottom  = pdf.PageHeight - 60.0
+	rowHeight   = 18.0
+)
+
+type pdfColumn struct {
+	title string
+	right float64
+	align string
+}
+
+var invoiceColumns = []pdfColumn{
+	{title: "No", right: 70},
+	{title: "Product", right: 320},
+	{title: "Qty", right: 370, align: "right"},
+	{title: "Unit Price", right: 460, align: "right"},
+	{title: "Subtotal", right: marginRight, align: "right"},
+}
+
+var packingColumns = []pdfColumn{
+	{title: "No", right: 70},
+	{title: "Product", right: 470},
+	{title: "Qty", right: 515, align: "right"},
+	{title: "Check", right: marginRight, align: "right"},
+}
+
+func ToInvoicePdf(o *entity.Order, paymentStatus string) []byte {
+	doc := pdf.New()
+
+	title := "INVOICE"
+	invoiceNumber := o.InvoiceNumber
+	if invoiceNumber == "" {
+		title = "PROFORMA INVOICE"
+		invoiceNumber = "-"
+	}
+
+	y := storeHeader(doc, title)
+
+	doc.Text(marginLeft, y, 10, true, "Bill To")
+	doc.TextRight(marginRight, y, 10, false, "Invoice No: "+invoiceNumber)
+	y += 14
+	doc.Text(marginLeft, y, 10, false, o.User.Name)
+	doc.TextRight(marginRight, y, 10, false, "Order: "+o.Code)
+	y += 14
+	doc.Text(marginLeft, y, 10, false, o.User.Email)
+	doc.TextRight(marginRight, y, 10, false, "Date: "+o.CreatedAt.Format("02 Jan 2006"))
+	y += 14
+	doc.Text(marginLeft, y, 10, false, pdf.Truncate(o.Address.Addresses, 330, 10, false))
+	doc.TextRight(marginRight, y, 10, false, "Payment: "+paymentStatus)
+	y += 30
+
+	y = tableHeader(doc, y, invoiceColumns)
+
+	for i, v := range o.OrderProducts {
+		if y > pageBottom {
+			doc.AddPage()
+			y = tableHeader(doc, 50, invoiceColumns)
+		}
+
+		tableRow(doc, y, invoiceColumns, []string{
+			strconv.Itoa(i + 1),
+			orderLineName(v),
+			strconv.Itoa(v.Qty),
+			FormatRupiah(v.UnitPrice),
+			FormatRupiah(v.UnitPrice * float64(v.Qty)),
+		}, false)
+		y += rowHeight
+	}
+
+	doc.Line(marginLeft, y-12, marginRight, y-12)
+	y += 4
+	doc.TextRight(460, y, 11, true, "Total")
+	doc.TextRight(marginRight, y, 11, true, FormatRupiah(o.AmountPay))
+
+	return doc.Bytes()
+}
+
+func ToPackingSlipPdf(o *entity.Order) []byte {
+	doc := pdf.New()
+
+	y := storeHeader(doc, "PACKING SLIP")
+
+	doc.Text(marginLeft, y, 10, true, "Ship To")
+	doc.TextRight(marginRight, y, 10, false, "Order: "+o.Code)
+	y += 14
+	doc.Text(marginLeft, y, 10, false, o.User.Name)
+	doc.TextRight(marginRight, y, 10, false, "Date: "+o.CreatedAt.Format("02 Jan 2006"))
+	y += 14
+	doc.Text(marginLeft, y, 10, false, pdf.Truncate(o.Address.Addresses, 330, 10, false))
+	y += 30
+
+	y = tableHeader(doc, y, packingColumns)
+
+	totalQty := 0
+	for i, v := range o.OrderProducts {
+		if y > pageBottom {
+			doc.AddPage()
+			y = tableHeader(doc, 50, packingColumns)
+		}
+
+		tableRow(doc, y, packingColumns, []string{
+			strconv.Itoa(i + 1),
+			orderLineName(v),
+			strconv.Itoa(v.Qty),
+			"",
+		}, false)
+		doc.Rect(marginRight-10, y-9, 10, 10)
+		y += rowHeight
+		totalQty += v.Qty
+	}
+
+	doc.Line(marginLeft, y-12, marginRight, y-12)
+	y += 4
+	doc.TextRight(470, y, 11, true, "Total Items")
+	doc.TextRight(515, y, 11, true, strconv.Itoa(totalQty))
+
+	return doc.Bytes()
+}
+
+// orderLineName adds the variant to the product name, like "Kaos (M / Black)".
+func orderLineName(v entity.OrderProduct) string {
+	if v.VariantName == "" {
+		return v.Product.Name
+	}
+
+	return v.Product.Name + " (" + v.VariantName + ")"
+}
+
+// FormatRupiah formats an amount like Rp 1.250.000.
+func FormatRupiah(amount float64) string {
+	n := int64(math.Round(amount))
+
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+
+	digits := strconv.FormatInt(n, 10)
+
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+
+	return sign + "Rp " + b.String()
+}
+
+// storeHeader draws the store identity from STORE_NAME, STORE_ADDRESS and
+// STORE_PHONE and returns the y position below it.
+func storeHeader(doc *pdf.Document, title string) float64 {
+	name := os.Getenv("STORE_NAME")
+	if name == "" {
+		name = "Simple Toko"
+	}
+
+	doc.Text(marginLeft, 60, 18, true, name)
+	doc.TextRight(marginRight, 60, 16, true, title)
+
+	y := 78.0
+	for _, v := range []string{os.Getenv("STORE_ADDRESS"), os.Getenv("STORE_PHONE")} {
+		if v == "" {
+			continue
+		}
+		doc.Text(marginLeft, y, 9, false, v)
+		y += 12
+	}
+
+	doc.Line(marginLeft, y, marginRight, y)
+
+	return y + 24
+}
+
+func tableHeader(doc *pdf.Document, y float64, columns []pdfColumn) float64 {
+	titles := make([]string, 0, len(columns))
+	for _, c := range columns {
+		titles = append(titles, c.title)
+	}
+
+	tableRow(doc, y, columns, titles, true)
+	doc.Line(marginLeft, y+6, marginRight, y+6)
+
+	return y + rowHeight + 4
+}
+
+func tableRow(doc *pdf.Document, y float64, columns []pdfColumn, values []string, bold bool) {
+	left := marginLeft
+	for i, c := range columns {
+		if c.align == "right" {
+			doc.TextRight(c.right, y, 10, bold, values[i])
+		} else {
+			doc.Text(left, y, 10, bold, pdf.Truncate(values[i], c.right-left-10, 10, bold))
+		}
+		left = c.right + 10
+	}
+}
@@ -17,14 +17,21 @@ func ToOrderResponse(o *entity.Order) *web.OrderResponse {
 				Description: v.Product.Description,
 				Image:       v.Product.Image,
 			},
-			Qty:       v.Qty,
-			UnitPrice: v.UnitPrice,
+			VariantID:   v.VariantID,
+			Sku:         v.Sku,
+			VariantName: v.VariantName,
+			InventoryID: v.InventoryID,
+			Qty:         v.Qty,
+			UnitPrice:   v.UnitPrice,
 		})
 	}
 
 	return &web.OrderResponse{
-		ID:        o.ID,
-		AmountPay: o.AmountPay,
+		ID:            o.ID,
+		Code:          o.Code,
+		InvoiceNumber: o.InvoiceNumber,
+		AmountPay:     o.AmountPay,
+		UserID:        o.UserID,
 		User: adr.UserInfo{
 			Name:  o.User.Name,
 			Email: o.User.Email,
@@ -36,6 +43,7 @@ func ToOrderResponse(o *entity.Order) *web.OrderResponse {
 		OrderProducts:  orderProducts,
 		StatusOrder:    o.StatusOrder,
 		StatusDelivery: o.StatusDelivery,
+		PayBefore:      o.PayBefore,
 		CreatedAt:      o.CreatedAt,
 		UpdatedAt:      o.UpdatedAt,
 	}
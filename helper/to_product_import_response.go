@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/product"
+)
+
+func ToProductImportResponse(job *entity.ProductImport) *web.ProductImportResponse {
+	errs := make([]web.ImportErrorInfo, 0, len(job.Errors))
+	for _, v := range job.Errors {
+		errs = append(errs, web.ImportErrorInfo{
+			Row:     v.Row,
+			SKU:     v.SKU,
+			Message: v.Message,
+		})
+	}
+
+	return &web.ProductImportResponse{
+		ID:         job.ID,
+		Status:     job.Status,
+		TotalRows:  job.TotalRows,
+		Processed:  job.Processed,
+		Created:    job.Created,
+		Updated:    job.Updated,
+		Failed:     job.Failed,
+		Error:      job.Error,
+		Errors:     errs,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+		CreatedAt:  job.CreatedAt,
+	}
+}
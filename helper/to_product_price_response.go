@@ -0,0 +1,46 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/product"
+)
+
+func ToPriceScheduleResponse(s *entity.ProductPriceSchedule) *web.PriceScheduleResponse {
+	return &web.PriceScheduleResponse{
+		ID:        s.ID,
+		ProductID: s.ProductID,
+		Price:     s.Price,
+		StartsAt:  s.StartsAt,
+		Status:    s.Status,
+		Note:      s.Note,
+		AppliedAt: s.AppliedAt,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+func ToPriceTimelineResponse(product *entity.Product, history []*entity.ProductPriceHistory, scheduled []*entity.ProductPriceSchedule) *web.PriceTimelineResponse {
+	response := &web.PriceTimelineResponse{
+		ProductID: product.ID,
+		Name:      product.Name,
+		Price:     product.Price,
+		History:   make([]web.PriceHistoryInfo, 0, len(history)),
+		Scheduled: make([]web.PriceScheduleResponse, 0, len(scheduled)),
+	}
+
+	for _, v := range history {
+		response.History = append(response.History, web.PriceHistoryInfo{
+			VariantID:  v.VariantID,
+			OldPrice:   v.OldPrice,
+			NewPrice:   v.NewPrice,
+			Source:     v.Source,
+			ScheduleID: v.ScheduleID,
+			ChangedAt:  v.CreatedAt,
+		})
+	}
+
+	for _, v := range scheduled {
+		response.Scheduled = append(response.Scheduled, *ToPriceScheduleResponse(v))
+	}
+
+	return response
+}
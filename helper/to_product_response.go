@@ -3,6 +3,7 @@ package helper
 import (
 	"simple-toko/entity"
 	web "simple-toko/web/product"
+	"sort"
 )
 
 func ToProductResponse(product *entity.Product) *web.ProductResponse {
@@ -12,12 +13,164 @@ func ToProductResponse(product *entity.Product) *web.ProductResponse {
 		Inventory: web.InventInfo{
 			Location: product.Inventory.Location,
 		},
+		SKU:         ProductSKU(product),
 		Name:        product.Name,
 		Price:       product.Price,
 		Stock:       product.Stock,
+		Locations:   ToProductLocations(product.Stocks),
 		Description: product.Description,
 		Image:       product.Image,
+		Categories:  ToProductCategories(product.Categories),
+		Options:     ToProductOptions(product),
+		Variants:    ToProductVariants(product),
+		Images:      ToProductImages(product.Images),
+		Rating:      product.RatingAvg,
+		ReviewCount: product.ReviewCount,
 		CreatedAt:   product.CreatedAt,
 		UpdatedAt:   product.UpdatedAt,
 	}
 }
+
+func ProductSKU(product *entity.Product) string {
+	if product.SKU == nil {
+		return ""
+	}
+
+	return *product.SKU
+}
+
+// ToProductLocations sums the stock rows per location, ordered by inventory.
+func ToProductLocations(stocks []entity.ProductStock) []web.LocationInfo {
+	infos := make([]web.LocationInfo, 0, len(stocks))
+	index := map[uint]int{}
+	for _, v := range stocks {
+		i, ok := index[v.InventoryID]
+		if !ok {
+			i = len(infos)
+			index[v.InventoryID] = i
+			infos = append(infos, web.LocationInfo{
+				InventoryID: v.InventoryID,
+				Location:    v.Inventory.Location,
+			})
+		}
+
+		infos[i].Quantity += v.Quantity
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].InventoryID < infos[j].InventoryID })
+
+	return infos
+}
+
+func ToProductCategories(categories []entity.Category) []web.CategoryInfo {
+	infos := make([]web.CategoryInfo, 0, len(categories))
+	for _, v := range categories {
+		infos = append(infos, web.CategoryInfo{
+			ID:   v.ID,
+			Name: v.Name,
+		})
+	}
+
+	return infos
+}
+
+// ToProductOptions lists every option with the values used by the variants,
+// in the order the values first appear.
+func ToProductOptions(product *entity.Product) []web.OptionInfo {
+	options := make([]entity.ProductOption, len(product.Options))
+	copy(options, product.Options)
+	sort.Slice(options, func(i, j int) bool { return options[i].Position < options[j].Position })
+
+	infos := make([]web.OptionInfo, 0, len(options))
+	for _, o := range options {
+		info := web.OptionInfo{
+			Name:   o.Name,
+			Values: []string{},
+		}
+
+		seen := map[string]bool{}
+		for _, v := range product.Variants {
+			values := []string{v.Option1, v.Option2, v.Option3}
+			if o.Position < 1 || o.Position > len(values) {
+				continue
+			}
+
+			value := values[o.Position-1]
+			if value == "" || seen[value] {
+				continue
+			}
+
+			seen[value] = true
+			info.Values = append(info.Values, value)
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+func ToProductVariants(product *entity.Product) []web.VariantInfo {
+	infos := make([]web.VariantInfo, 0, len(product.Variants))
+	for _, v := range product.Variants {
+		infos = append(infos, web.VariantInfo{
+			ID:            v.ID,
+			SKU:           v.SKU,
+			Options:       v.Values(),
+			Price:         v.EffectivePrice(product.Price),
+			PriceOverride: v.Price,
+			Stock:         v.Stock,
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+
+	return infos
+}
+
+func ToProductImages(images []entity.ProductImage) []web.ImageInfo {
+	infos := make([]web.ImageInfo, 0, len(images))
+	for _, v := range images {
+		infos = append(infos, ToProductImage(&v))
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Position != infos[j].Position {
+			return infos[i].Position < infos[j].Position
+		}
+		return infos[i].ID < infos[j].ID
+	})
+
+	return infos
+}
+
+func ToProductImage(image *entity.ProductImage) web.ImageInfo {
+	return web.ImageInfo{
+		ID:        image.ID,
+		Image:     image.FileName,
+		Position:  image.Position,
+		IsPrimary: image.IsPrimary,
+	}
+}
+
+func ToProductFacets(facets *entity.ProductFacets) web.ProductFacets {
+	result := web.ProductFacets{
+		Categories:  []web.FacetInfo{},
+		Inventories: []web.FacetInfo{},
+		Prices:      []web.PriceFacet{},
+	}
+
+	for _, v := range facets.Categories {
+		result.Categories = append(result.Categories, web.FacetInfo{ID: v.ID, Name: v.Name, Total: v.Total})
+	}
+
+	for _, v := range facets.Inventories {
+		result.Inventories = append(result.Inventories, web.FacetInfo{ID: v.ID, Name: v.Name, Total: v.Total})
+	}
+
+	for _, v := range facets.Prices {
+		result.Prices = append(result.Prices, web.PriceFacet{Min: v.Min, Max: v.Max, Total: v.Total})
+	}
+
+	return result
+}
@@ -0,0 +1,82 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/purchase"
+)
+
+func ToPurchaseOrderResponse(order *entity.PurchaseOrder) *web.PurchaseOrderResponse {
+	response := web.PurchaseOrderResponse{
+		ID:           order.ID,
+		Code:         order.Code,
+		SupplierID:   order.SupplierID,
+		SupplierName: order.Supplier.Name,
+		InventoryID:  order.InventoryID,
+		Location:     order.Inventory.Location,
+		Status:       order.Status,
+		ExpectedAt:   order.ExpectedAt,
+		Note:         order.Note,
+		Total:        order.Total,
+		UserID:       order.UserID,
+		UserName:     order.User.Name,
+		Lines:        make([]web.PurchaseOrderLineResponse, 0, len(order.Lines)),
+		ApprovedBy:   order.ApprovedBy,
+		ApprovedAt:   order.ApprovedAt,
+		CreatedAt:    order.CreatedAt,
+		UpdatedAt:    order.UpdatedAt,
+	}
+
+	for _, v := range order.Lines {
+		line := web.PurchaseOrderLineResponse{
+			ID:          v.ID,
+			ProductID:   v.ProductID,
+			ProductName: v.Product.Name,
+			VariantID:   v.VariantID,
+			SKU:         ProductSKU(&v.Product),
+			Qty:         v.Qty,
+			ReceivedQty: v.ReceivedQty,
+			UnitCost:    v.UnitCost,
+			Subtotal:    float64(v.Qty) * v.UnitCost,
+		}
+
+		//nothing more is coming once the order is closed
+		if order.Status != "received" && order.Status != "canceled" {
+			line.OpenQty = v.Qty - v.ReceivedQty
+		}
+
+		for _, variant := range v.Product.Variants {
+			if variant.ID == v.VariantID {
+				line.SKU = variant.SKU
+				line.Variant = variant.Title()
+			}
+		}
+
+		response.Lines = append(response.Lines, line)
+	}
+
+	for _, v := range order.Receipts {
+		receipt := web.GoodsReceiptResponse{
+			ID:        v.ID,
+			Code:      v.Code,
+			Note:      v.Note,
+			UserID:    v.UserID,
+			UserName:  v.User.Name,
+			Lines:     make([]web.GoodsReceiptLineResponse, 0, len(v.Lines)),
+			CreatedAt: v.CreatedAt,
+		}
+
+		for _, line := range v.Lines {
+			receipt.Lines = append(receipt.Lines, web.GoodsReceiptLineResponse{
+				LineID:    line.PurchaseOrderLineID,
+				ProductID: line.ProductID,
+				VariantID: line.VariantID,
+				Qty:       line.Qty,
+				UnitCost:  line.UnitCost,
+			})
+		}
+
+		response.Receipts = append(response.Receipts, receipt)
+	}
+
+	return &response
+}
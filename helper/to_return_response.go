@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/returns"
+)
+
+func ToReturnResponse(r *entity.ReturnRequest) *web.ReturnResponse {
+	response := &web.ReturnResponse{
+		ID:             r.ID,
+		OrderID:        r.OrderID,
+		OrderCode:      r.Order.Code,
+		OrderProductID: r.OrderProductID,
+		ProductID:      r.OrderProduct.ProductID,
+		ProductName:    r.OrderProduct.Product.Name,
+		UnitPrice:      r.OrderProduct.UnitPrice,
+		UserID:         r.UserID,
+		Qty:            r.Qty,
+		Reason:         r.Reason,
+		Image:          r.Image,
+		Status:         r.Status,
+		AdminNote:      r.AdminNote,
+		Restock:        r.Restock,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+
+	if r.Refund != nil {
+		response.Refund = &web.RefundInfo{
+			Qty:       r.Refund.Qty,
+			Amount:    r.Refund.Amount,
+			CreatedAt: r.Refund.CreatedAt,
+		}
+	}
+
+	return response
+}
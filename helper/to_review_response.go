@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/review"
+)
+
+func ToReviewResponse(r *entity.Review) *web.ReviewResponse {
+	images := make([]web.ReviewImageInfo, 0, len(r.Images))
+	for _, v := range r.Images {
+		images = append(images, web.ReviewImageInfo{
+			ID:    v.ID,
+			Image: v.FileName,
+		})
+	}
+
+	return &web.ReviewResponse{
+		ID:             r.ID,
+		ProductID:      r.ProductID,
+		ProductName:    r.Product.Name,
+		OrderProductID: r.OrderProductID,
+		UserID:         r.UserID,
+		UserName:       r.User.Name,
+		Rating:         r.Rating,
+		Comment:        r.Comment,
+		Images:         images,
+		Hidden:         r.Hidden,
+		HiddenReason:   r.HiddenReason,
+		CreatedAt:      r.CreatedAt,
+		UpdatedAt:      r.UpdatedAt,
+	}
+}
@@ -0,0 +1,72 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/stock"
+)
+
+func ToStockResponse(stock *entity.ProductStock) *web.StockResponse {
+	response := web.StockResponse{
+		ID:          stock.ID,
+		ProductID:   stock.ProductID,
+		ProductName: stock.Product.Name,
+		VariantID:   stock.VariantID,
+		SKU:         ProductSKU(&stock.Product),
+		InventoryID: stock.InventoryID,
+		Location:    stock.Inventory.Location,
+		Quantity:    stock.Quantity,
+		UpdatedAt:   stock.UpdatedAt,
+	}
+
+	for _, v := range stock.Product.Variants {
+		if v.ID == stock.VariantID {
+			response.SKU = v.SKU
+			response.Variant = v.Title()
+		}
+	}
+
+	return &response
+}
+
+func ToStockResponses(stocks []*entity.ProductStock) []*web.StockResponse {
+	responses := make([]*web.StockResponse, 0, len(stocks))
+	for _, v := range stocks {
+		responses = append(responses, ToStockResponse(v))
+	}
+
+	return responses
+}
+
+func ToStockMovementResponse(movement *entity.StockMovement) *web.StockMovementResponse {
+	response := web.StockMovementResponse{
+		ID:          movement.ID,
+		ProductID:   movement.ProductID,
+		VariantID:   movement.VariantID,
+		InventoryID: movement.InventoryID,
+		Location:    movement.Inventory.Location,
+		Delta:       movement.Delta,
+		Balance:     movement.Balance,
+		Reason:      movement.Reason,
+		RefType:     movement.RefType,
+		RefID:       movement.RefID,
+		UserID:      movement.UserID,
+		Note:        movement.Note,
+		CreatedAt:   movement.CreatedAt,
+	}
+
+	if movement.User != nil {
+		response.UserName = movement.User.Name
+	}
+
+	return &response
+}
+
+func ToStockMismatchResponse(mismatch entity.StockMismatch) web.StockMismatchResponse {
+	return web.StockMismatchResponse{
+		ProductID:   mismatch.ProductID,
+		VariantID:   mismatch.VariantID,
+		InventoryID: mismatch.InventoryID,
+		Quantity:    mismatch.Quantity,
+		Ledger:      mismatch.Ledger,
+	}
+}
@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/stock"
+)
+
+func ToStockTransferResponse(transfer *entity.StockTransfer) *web.StockTransferResponse {
+	response := web.StockTransferResponse{
+		ID:            transfer.ID,
+		Code:          transfer.Code,
+		SourceID:      transfer.SourceID,
+		Source:        transfer.Source.Location,
+		DestinationID: transfer.DestinationID,
+		Destination:   transfer.Destination.Location,
+		Status:        transfer.Status,
+		Note:          transfer.Note,
+		UserID:        transfer.UserID,
+		UserName:      transfer.User.Name,
+		Lines:         make([]web.StockTransferLineResponse, 0, len(transfer.Lines)),
+		ShippedBy:     transfer.ShippedBy,
+		ShippedAt:     transfer.ShippedAt,
+		ReceivedBy:    transfer.ReceivedBy,
+		ReceivedAt:    transfer.ReceivedAt,
+		CreatedAt:     transfer.CreatedAt,
+		UpdatedAt:     transfer.UpdatedAt,
+	}
+
+	for _, v := range transfer.Lines {
+		line := web.StockTransferLineResponse{
+			ID:          v.ID,
+			ProductID:   v.ProductID,
+			ProductName: v.Product.Name,
+			VariantID:   v.VariantID,
+			SKU:         ProductSKU(&v.Product),
+			Qty:         v.Qty,
+			ReceivedQty: v.ReceivedQty,
+			Discrepancy: v.Discrepancy,
+			Note:        v.Note,
+		}
+
+		for _, variant := range v.Product.Variants {
+			if variant.ID == v.VariantID {
+				line.SKU = variant.SKU
+				line.Variant = variant.Title()
+			}
+		}
+
+		response.Lines = append(response.Lines, line)
+	}
+
+	return &response
+}
@@ -0,0 +1,81 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/stock"
+)
+
+func ToStocktakeResponse(stocktake *entity.Stocktake) *web.StocktakeResponse {
+	return &web.StocktakeResponse{
+		ID:          stocktake.ID,
+		Code:        stocktake.Code,
+		InventoryID: stocktake.InventoryID,
+		Location:    stocktake.Inventory.Location,
+		Status:      stocktake.Status,
+		Note:        stocktake.Note,
+		UserID:      stocktake.UserID,
+		UserName:    stocktake.User.Name,
+		ApprovedBy:  stocktake.ApprovedBy,
+		ApprovedAt:  stocktake.ApprovedAt,
+		CreatedAt:   stocktake.CreatedAt,
+		UpdatedAt:   stocktake.UpdatedAt,
+	}
+}
+
+// ToStocktakeDetail adds the summary and the lines, varianceOnly keeps the
+// counted lines that differ from expected.
+func ToStocktakeDetail(stocktake *entity.Stocktake, varianceOnly bool) *web.StocktakeResponse {
+	response := ToStocktakeResponse(stocktake)
+	response.Summary = &web.StocktakeSummary{Lines: len(stocktake.Lines)}
+	response.Lines = make([]web.StocktakeLineResponse, 0, len(stocktake.Lines))
+
+	for _, v := range stocktake.Lines {
+		line := web.StocktakeLineResponse{
+			ID:          v.ID,
+			ProductID:   v.ProductID,
+			ProductName: v.Product.Name,
+			VariantID:   v.VariantID,
+			SKU:         v.SKU,
+			Expected:    v.Expected,
+			Counted:     v.Counted,
+			Adjusted:    v.Adjusted,
+			CountedBy:   v.CountedBy,
+			CountedAt:   v.CountedAt,
+		}
+
+		for _, variant := range v.Product.Variants {
+			if variant.ID == v.VariantID {
+				line.Variant = variant.Title()
+			}
+		}
+
+		if v.Counted == nil {
+			response.Summary.Uncounted++
+			if !varianceOnly {
+				response.Lines = append(response.Lines, line)
+			}
+			continue
+		}
+
+		variance := *v.Counted - v.Expected
+		line.Variance = &variance
+		response.Summary.Counted++
+
+		switch {
+		case variance > 0:
+			response.Summary.Surplus += variance
+		case variance < 0:
+			response.Summary.Shortage -= variance
+		}
+
+		if variance != 0 {
+			response.Summary.Variances++
+		}
+
+		if variance != 0 || !varianceOnly {
+			response.Lines = append(response.Lines, line)
+		}
+	}
+
+	return response
+}
@@ -0,0 +1,18 @@
+package helper
+
+import (
+	"simple-toko/entity"
+	web "simple-toko/web/supplier"
+)
+
+func ToSupplierResponse(supplier *entity.Supplier) *web.SupplierResponse {
+	return &web.SupplierResponse{
+		ID:        supplier.ID,
+		Name:      supplier.Name,
+		Email:     supplier.Email,
+		Phone:     supplier.Phone,
+		Address:   supplier.Address,
+		CreatedAt: supplier.CreatedAt,
+		UpdatedAt: supplier.UpdatedAt,
+	}
+}
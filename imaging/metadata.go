@@ -0,0 +1,223 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+)
+
+// StripMetadata drops EXIF, XMP, IPTC and text metadata from a JPEG or PNG
+// without touching the pixels. A JPEG that is only shown upright through its
+// EXIF orientation is rotated first, since the tag is removed with the rest.
+// Other types are returned unchanged.
+func StripMetadata(data []byte, mime string) ([]byte, error) {
+	switch mime {
+	case "image/jpeg":
+		return stripJpeg(data)
+	case "image/png":
+		return stripPng(data)
+	default:
+		return data, nil
+	}
+}
+
+func stripJpeg(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, ErrUnsupported
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+
+	orientation := 1
+	pos := 2
+	for {
+		if pos+2 > len(data) || data[pos] != 0xFF {
+			return nil, ErrUnsupported
+		}
+
+		marker := data[pos+1]
+		switch {
+		case marker == 0xFF:
+			//fill byte before the marker
+			pos++
+			continue
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			out.Write(data[pos : pos+2])
+			pos += 2
+			continue
+		case marker == 0xDA:
+			//start of scan, the compressed image data follows until the end
+			out.Write(data[pos:])
+			return rotateJpeg(out.Bytes(), orientation)
+		}
+
+		if pos+4 > len(data) {
+			return nil, ErrUnsupported
+		}
+
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:pos+4]))
+		if end > len(data) || end < pos+4 {
+			return nil, ErrUnsupported
+		}
+
+		segment := data[pos:end]
+		switch marker {
+		case 0xE1:
+			//APP1 holds EXIF or XMP
+			if o := exifOrientation(segment[4:]); o != 0 {
+				orientation = o
+			}
+		case 0xED, 0xFE:
+			//APP13 IPTC and comments
+		default:
+			out.Write(segment)
+		}
+
+		pos = end
+	}
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+var pngMetadata = map[string]bool{
+	"eXIf": true,
+	"tEXt": true,
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+func stripPng(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrUnsupported
+	}
+
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(pngSignature)
+
+	pos := len(pngSignature)
+	for {
+		if pos+12 > len(data) {
+			return nil, ErrUnsupported
+		}
+
+		end := pos + 12 + int(binary.BigEndian.Uint32(data[pos:pos+4]))
+		if end > len(data) || end < pos+12 {
+			return nil, ErrUnsupported
+		}
+
+		kind := string(data[pos+4 : pos+8])
+		if !pngMetadata[kind] {
+			out.Write(data[pos:end])
+		}
+
+		if kind == "IEND" {
+			return out.Bytes(), nil
+		}
+
+		pos = end
+	}
+}
+
+// exifOrientation reads tag 0x0112 from the first IFD of an APP1 payload, 0
+// means there is no EXIF or no orientation.
+func exifOrientation(payload []byte) int {
+	if len(payload) < 14 || string(payload[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+
+	tiff := payload[6:]
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			o := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if o < 1 || o > 8 {
+				return 0
+			}
+			return o
+		}
+	}
+
+	return 0
+}
+
+// rotateJpeg applies an EXIF orientation to the pixels, orientation 1 means
+// the image is already upright and nothing is re-encoded.
+func rotateJpeg(data []byte, orientation int) ([]byte, error) {
+	if orientation <= 1 {
+		return data, nil
+	}
+
+	if err := checkPixels(data); err != nil {
+		return nil, err
+	}
+
+	src, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+
+	rgba := toRGBA(src)
+	w, h := rgba.Rect.Dx(), rgba.Rect.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			default:
+				dx, dy = y, w-1-x
+			}
+
+			i, j := rgba.PixOffset(x, y), dst.PixOffset(dx, dy)
+			copy(dst.Pix[j:j+4], rgba.Pix[i:i+4])
+		}
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,162 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+var (
+	ErrUnsupported = errors.New("unsupported image format")
+	ErrTooLarge    = errors.New("image has too many pixels")
+)
+
+// MaxPixels caps width x height of an image that is decoded. A small file
+// can declare a huge canvas and decoding it would take gigabytes of memory.
+const MaxPixels = 40_000_000
+
+// Size is a scaled copy of an image, stored in Dir below the original under
+// the same name and fitted inside Max x Max pixels.
+type Size struct {
+	Name string
+	Dir  string
+	Max  int
+}
+
+var (
+	Thumbnail = Size{Name: "thumb", Dir: "thumb/", Max: 150}
+	Medium    = Size{Name: "medium", Dir: "medium/", Max: 600}
+)
+
+var Sizes = []Size{Thumbnail, Medium}
+
+// GenerateSizes decodes data once and encodes every entry of Sizes in the
+// same format, so the file extension still matches the content. The result
+// follows the order of Sizes.
+func GenerateSizes(data []byte) ([][]byte, error) {
+	if err := checkPixels(data); err != nil {
+		return nil, err
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	rgba := toRGBA(src)
+
+	result := make([][]byte, 0, len(Sizes))
+	for _, s := range Sizes {
+		var out bytes.Buffer
+		if err := encode(&out, Resize(rgba, s.Max), format); err != nil {
+			return nil, fmt.Errorf("encode %s: %w", s.Name, err)
+		}
+
+		result = append(result, out.Bytes())
+	}
+
+	return result, nil
+}
+
+// checkPixels reads only the header of data and refuses an image above
+// MaxPixels before anything is decoded.
+func checkPixels(data []byte) error {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return fmt.Errorf("%w: %dx%d, max %d pixels", ErrTooLarge, config.Width, config.Height, MaxPixels)
+	}
+
+	return nil
+}
+
+// Resize fits src inside max x max keeping the aspect ratio. Every target
+// pixel is the average of the source pixels it covers, which keeps thin lines
+// and text readable when shrinking a lot. Images already small enough are
+// returned as they are.
+func Resize(src *image.RGBA, max int) *image.RGBA {
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w <= max && h <= max {
+		return src
+	}
+
+	nw, nh := max, h*max/w
+	if h > w {
+		nw, nh = w*max/h, max
+	}
+	if nw < 1 {
+		nw = 1
+	}
+	if nh < 1 {
+		nh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+
+	for y := 0; y < nh; y++ {
+		y0, y1 := y*h/nh, (y+1)*h/nh
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < nw; x++ {
+			x0, x1 := x*w/nw, (x+1)*w/nw
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(src.Rect.Min.X+x0, src.Rect.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					b += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					n++
+					i += 4
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(b / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	if rgba, ok := src.(*image.RGBA); ok {
+		return rgba
+	}
+
+	b := src.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	return rgba
+}
+
+func encode(w io.Writer, img image.Image, format string) error {
+	switch format {
+	case "png":
+		return png.Encode(w, img)
+	case "gif":
+		return gif.Encode(w, img, nil)
+	default:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"simple-toko/config"
@@ -8,22 +9,23 @@ import (
 	"simple-toko/repository"
 	"simple-toko/route"
 	"simple-toko/service"
+	"simple-toko/worker"
 
 	"github.com/go-playground/validator/v10"
 )
 
 func main() {
-	if err := os.MkdirAll("uploads/product/", os.ModePerm); err != nil {
-		log.Fatal("failed make folder product")
-	}
+	db := config.Database()
 
-	if err := os.MkdirAll("uploads/payment/", os.ModePerm); err != nil {
-		log.Fatal("failed make folder payment")
+	//one off command, the exit code tells whether stock matches the ledger
+	if len(os.Args) > 1 && os.Args[1] == "reconcile-stock" {
+		os.Exit(reconcileStock(db))
 	}
 
-	db := config.Database()
 	redisClient := config.InitRedis()
+	store := config.InitStorage()
 	validate := validator.New()
+	uploadService := service.NewUploadServiceImpl(store)
 
 	userRepo := repository.NewUserRepositoryImpl(db)
 	userService := service.NewUserServiceImpl(userRepo, validate)
@@ -37,17 +39,70 @@ func main() {
 	addressService := service.NewAddressServiceImpl(addresRepo, userRepo, validate)
 	addressHandler := handler.NewAddressHandlerImpl(addressService)
 
+	categoryRepo := repository.NewCategoryRepositoryImpl(db)
+	categoryService := service.NewCategoryServiceImpl(categoryRepo, validate, redisClient)
+	categoryHandler := handler.NewCategoryHandlerImpl(categoryService)
+
 	productRepo := repository.NewProductRepositoryImpl(db)
-	productService := service.NewProductServiceImpl(productRepo, inventoryRepo, validate, redisClient)
-	productHandler := handler.NewProductHandlerImpl(productService)
+	productService := service.NewProductServiceImpl(productRepo, inventoryRepo, categoryRepo, validate, redisClient, store)
+	productHandler := handler.NewProductHandlerImpl(productService, uploadService)
+
+	worker.StartImageCleanup(context.Background(), productService)
+
+	importRepo := repository.NewProductImportRepositoryImpl(db)
+	importService := service.NewProductImportServiceImpl(importRepo, productRepo, inventoryRepo, categoryRepo, validate, redisClient, store)
+	importHandler := handler.NewProductImportHandlerImpl(importService, uploadService)
+
+	worker.StartProductImport(context.Background(), importService)
+
+	priceRepo := repository.NewProductPriceRepositoryImpl(db)
+	priceService := service.NewProductPriceServiceImpl(priceRepo, productRepo, validate, redisClient)
+	priceHandler := handler.NewProductPriceHandlerImpl(priceService)
+
+	worker.StartPriceScheduler(context.Background(), priceService)
+
+	stockRepo := repository.NewStockRepositoryImpl(db)
+	stockService := service.NewStockServiceImpl(stockRepo, validate)
+	stockHandler := handler.NewStockHandlerImpl(stockService)
+
+	transferRepo := repository.NewStockTransferRepositoryImpl(db)
+	transferService := service.NewStockTransferServiceImpl(transferRepo, validate, redisClient)
+	transferHandler := handler.NewStockTransferHandlerImpl(transferService)
+
+	stocktakeRepo := repository.NewStocktakeRepositoryImpl(db)
+	stocktakeService := service.NewStocktakeServiceImpl(stocktakeRepo, validate, redisClient, store)
+	stocktakeHandler := handler.NewStocktakeHandlerImpl(stocktakeService, uploadService)
+
+	supplierRepo := repository.NewSupplierRepositoryImpl(db)
+	supplierService := service.NewSupplierServiceImpl(supplierRepo, validate)
+	supplierHandler := handler.NewSupplierHandlerImpl(supplierService)
+
+	purchaseRepo := repository.NewPurchaseOrderRepositoryImpl(db)
+	purchaseService := service.NewPurchaseOrderServiceImpl(purchaseRepo, validate, redisClient)
+	purchaseHandler := handler.NewPurchaseOrderHandlerImpl(purchaseService)
+
+	payRepo := repository.NewPaymentRepositoryImpl(db)
 
 	orderRepo := repository.NewOrderRepositoryImpl(db)
-	orderService := service.NewOrderServiceImpl(orderRepo, addresRepo, validate, redisClient)
+	orderService := service.NewOrderServiceImpl(orderRepo, addresRepo, payRepo, validate, redisClient)
 	orderHandler := handler.NewOrderHandlerImpl(orderService)
 
-	payRepo := repository.NewPaymentRepositoryImpl(db)
-	payService := service.NewPaymentServiceImpl(payRepo, orderRepo, validate)
-	payHandler := handler.NewPaymentHandlerImpl(payService)
+	cartRepo := repository.NewCartRepositoryImpl(db)
+	cartService := service.NewCartServiceImpl(cartRepo, productRepo, orderService, validate, redisClient)
+	cartHandler := handler.NewCartHandlerImpl(cartService)
+
+	worker.StartOrderExpiry(context.Background(), orderService)
+
+	payService := service.NewPaymentServiceImpl(payRepo, orderRepo, validate, redisClient)
+	payHandler := handler.NewPaymentHandlerImpl(payService, uploadService)
+
+	returnRepo := repository.NewReturnRepositoryImpl(db)
+	returnService := service.NewReturnServiceImpl(returnRepo, validate, redisClient)
+	returnHandler := handler.NewReturnHandlerImpl(returnService, uploadService)
+
+	reviewRepo := repository.NewReviewRepositoryImpl(db)
+	reviewService := service.NewReviewServiceImpl(reviewRepo, validate, redisClient)
+	reviewHandler := handler.NewReviewHandlerImpl(reviewService, uploadService)
 
 	reportRepo := repository.NewReportRepositoryImpl(db)
 	reportService := service.NewReportServiceImpl(reportRepo)
@@ -61,6 +116,18 @@ func main() {
 		orderHandler,
 		payHandler,
 		reportHndler,
+		cartHandler,
+		returnHandler,
+		categoryHandler,
+		reviewHandler,
+		importHandler,
+		priceHandler,
+		stockHandler,
+		transferHandler,
+		stocktakeHandler,
+		supplierHandler,
+		purchaseHandler,
+		redisClient,
 	)
 
 	port := os.Getenv("PORT_APP")
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"simple-toko/helper"
+	"simple-toko/web"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	IdempotencyHeader = "Idempotency-Key"
+	idempotencyLock   = 30 * time.Second
+	processing        = "processing"
+)
+
+// The processing lock is refreshed while the handler runs, so it only expires
+// when the instance holding it is gone. The lock carries a token and is only
+// refreshed, replaced or deleted while it still holds that token.
+var (
+	refreshLock = redis.NewScript(`if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	releaseLock = redis.NewScript(`if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == "" then
+	return redis.call("DEL", KEYS[1])
+end
+return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])`)
+)
+
+type idempotencyRecord struct {
+	State       string `json:"state"`
+	Token       string `json:"token,omitempty"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the first response stored for the same user and
+// Idempotency-Key header. Requests without the header pass through untouched.
+func Idempotency(rds *redis.Client) gin.HandlerFunc {
+	ttl := idempotencyTTL()
+
+	return func(ctx *gin.Context) {
+		idemKey := ctx.GetHeader(IdempotencyHeader)
+		if idemKey == "" {
+			ctx.Next()
+			return
+		}
+
+		if len(idemKey) > 255 {
+			helper.ToResponseJson(ctx, http.StatusBadRequest, "idempotency key too long", nil)
+			ctx.Abort()
+			return
+		}
+
+		var userId uint
+		if claims, exist := ctx.Get("user"); exist {
+			userId = claims.(*web.TokenClaim).UserID
+		}
+
+		cacheKey := fmt.Sprintf("idempotency:%d:%s", userId, idemKey)
+		path := ctx.FullPath()
+
+		token := make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			fmt.Printf("idempotency token error: %v\n", err)
+			ctx.Next()
+			return
+		}
+
+		lock, _ := json.Marshal(idempotencyRecord{State: processing, Token: hex.EncodeToString(token), Method: ctx.Request.Method, Path: path})
+
+		ok, err := rds.SetNX(ctx, cacheKey, lock, idempotencyLock).Result()
+		if err != nil {
+			fmt.Printf("Redis error: %v\n", err)
+			ctx.Next()
+			return
+		}
+
+		if !ok {
+			replay(ctx, rds, cacheKey, path)
+			return
+		}
+
+		stop := keepLock(rds, cacheKey, string(lock))
+		defer stop()
+
+		recorder := &bodyRecorder{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = recorder
+
+		ctx.Next()
+
+		// a server error is not stored so the client can retry with the same key
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := releaseLock.Run(context.Background(), rds, []string{cacheKey}, string(lock), "", 0).Err(); err != nil {
+				fmt.Printf("Redis del error: %v\n", err)
+			}
+			return
+		}
+
+		jsonData, _ := json.Marshal(idempotencyRecord{
+			State:       "done",
+			Method:      ctx.Request.Method,
+			Path:        path,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+
+		if err := releaseLock.Run(context.Background(), rds, []string{cacheKey}, string(lock), jsonData, ttl.Milliseconds()).Err(); err != nil {
+			fmt.Printf("Redis set error: %v\n", err)
+		}
+	}
+}
+
+// keepLock extends the processing lock until the returned func is called, so
+// a slow handler keeps it however long it runs.
+func keepLock(rds *redis.Client, cacheKey, lock string) func() {
+	done := make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(idempotencyLock / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				err := refreshLock.Run(context.Background(), rds, []string{cacheKey}, lock, idempotencyLock.Milliseconds()).Err()
+				if err != nil {
+					fmt.Printf("Redis refresh error: %v\n", err)
+				}
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+func replay(ctx *gin.Context, rds *redis.Client, cacheKey, path string) {
+	cached, err := rds.Get(ctx, cacheKey).Result()
+	if err != nil {
+		// the lock expired between SETNX and GET, treat it as still in flight
+		helper.ToResponseJson(ctx, http.StatusConflict, "request with this idempotency key is in progress", nil)
+		ctx.Abort()
+		return
+	}
+
+	record := idempotencyRecord{}
+	if err := json.Unmarshal([]byte(cached), &record); err != nil {
+		helper.ToResponseJson(ctx, http.StatusInternalServerError, "internal server error", err.Error())
+		ctx.Abort()
+		return
+	}
+
+	if record.Method != ctx.Request.Method || record.Path != path {
+		helper.ToResponseJson(ctx, http.StatusUnprocessableEntity, "idempotency key already used for another request", nil)
+		ctx.Abort()
+		return
+	}
+
+	if record.State == processing {
+		helper.ToResponseJson(ctx, http.StatusConflict, "request with this idempotency key is in progress", nil)
+		ctx.Abort()
+		return
+	}
+
+	ctx.Header("Idempotent-Replayed", "true")
+	ctx.Data(record.Status, record.ContentType, record.Body)
+	ctx.Abort()
+}
+
+// idempotencyTTL reads IDEMPOTENCY_TTL in hours, default 24 hours.
+func idempotencyTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL"))
+	if err != nil || hours <= 0 {
+		hours = 24
+	}
+
+	return time.Duration(hours) * time.Hour
+}
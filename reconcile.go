@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"simple-toko/repository"
+	"simple-toko/service"
+
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+// reconcileStock prints every stock that does not match its ledger and
+// returns the exit code, 1 when anything differs.
+func reconcileStock(db *gorm.DB) int {
+	stockService := service.NewStockServiceImpl(repository.NewStockRepositoryImpl(db), validator.New())
+
+	result, err := stockService.Reconcile(context.Background())
+	if err != nil {
+		log.Println(err)
+		return 2
+	}
+
+	for _, v := range result {
+		location := fmt.Sprintf("inventory %d", v.InventoryID)
+		if v.InventoryID == 0 {
+			location = "total"
+		}
+
+		fmt.Printf("product %d variant %d %s: stock %d, ledger %d\n", v.ProductID, v.VariantID, location, v.Quantity, v.Ledger)
+	}
+
+	if len(result) > 0 {
+		log.Printf("%d stock rows do not match the ledger", len(result))
+		return 1
+	}
+
+	log.Println("stock matches the ledger")
+	return 0
+}
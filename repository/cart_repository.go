@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type CartRepository interface {
+	FindByUserId(ctx context.Context, userId uint) (*entity.Cart, error)
+	AddItem(ctx context.Context, userId uint, item *entity.CartItem) (*entity.Cart, error)
+	UpdateItem(ctx context.Context, userId uint, item *entity.CartItem) (*entity.Cart, error)
+	RemoveItem(ctx context.Context, userId, productId, variantId uint) (*entity.Cart, error)
+	Clear(ctx context.Context, userId uint) error
+	RemoveOrdered(ctx context.Context, userId uint, items []entity.CartItem) (*entity.Cart, error)
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type cartRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewCartRepositoryImpl(db *gorm.DB) *cartRepositoryImpl {
+	return &cartRepositoryImpl{
+		Db: db,
+	}
+}
+
+var ErrCartItemNotFound = errors.New("cart item not found")
+
+func (c *cartRepositoryImpl) FindByUserId(ctx context.Context, userId uint) (*entity.Cart, error) {
+	cart, err := c.findOrCreate(c.Db.WithContext(ctx), userId)
+	if err != nil {
+		return nil, fmt.Errorf("cart repo: find by user id: %w", err)
+	}
+
+	if err := c.Db.WithContext(ctx).Where("cart_id = ?", cart.ID).Order("id ASC").
+		Find(&cart.CartItems).Error; err != nil {
+		return nil, fmt.Errorf("cart repo: find items: %w", err)
+	}
+
+	return cart, nil
+}
+
+func (c *cartRepositoryImpl) AddItem(ctx context.Context, userId uint, item *entity.CartItem) (*entity.Cart, error) {
+	cart, err := c.findOrCreate(c.Db.WithContext(ctx), userId)
+	if err != nil {
+		return nil, fmt.Errorf("cart repo: add item: %w", err)
+	}
+
+	item.CartID = cart.ID
+
+	//merge with the existing line, cart_id, product_id and variant_id are unique
+	if err := c.Db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "cart_id"}, {Name: "product_id"}, {Name: "variant_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"qty": gorm.Expr("qty + ?", item.Qty)}),
+	}).Create(item).Error; err != nil {
+		return nil, fmt.Errorf("cart repo: add item: %w", err)
+	}
+
+	return c.FindByUserId(ctx, userId)
+}
+
+func (c *cartRepositoryImpl) UpdateItem(ctx context.Context, userId uint, item *entity.CartItem) (*entity.Cart, error) {
+	cart, err := c.findOrCreate(c.Db.WithContext(ctx), userId)
+	if err != nil {
+		return nil, fmt.Errorf("cart repo: update item: %w", err)
+	}
+
+	result := c.Db.WithContext(ctx).Model(&entity.CartItem{}).
+		Where("cart_id = ? AND product_id = ? AND variant_id = ?", cart.ID, item.ProductID, item.VariantID).
+		Update("qty", item.Qty)
+	if result.Error != nil {
+		return nil, fmt.Errorf("cart repo: update item: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, ErrCartItemNotFound
+	}
+
+	return c.FindByUserId(ctx, userId)
+}
+
+func (c *cartRepositoryImpl) RemoveItem(ctx context.Context, userId, productId, variantId uint) (*entity.Cart, error) {
+	cart, err := c.findOrCreate(c.Db.WithContext(ctx), userId)
+	if err != nil {
+		return nil, fmt.Errorf("cart repo: remove item: %w", err)
+	}
+
+	result := c.Db.WithContext(ctx).Where("cart_id = ? AND product_id = ? AND variant_id = ?", cart.ID, productId, variantId).
+		Delete(&entity.CartItem{})
+	if result.Error != nil {
+		return nil, fmt.Errorf("cart repo: remove item: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, ErrCartItemNotFound
+	}
+
+	return c.FindByUserId(ctx, userId)
+}
+
+func (c *cartRepositoryImpl) Clear(ctx context.Context, userId uint) error {
+	if err := c.Db.WithContext(ctx).
+		Where("cart_id IN (?)", c.Db.Model(&entity.Cart{}).Select("id").Where("user_id = ?", userId)).
+		Delete(&entity.CartItem{}).Error; err != nil {
+		return fmt.Errorf("cart repo: clear: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveOrdered takes the checked out qty of items off the cart, a line whose
+// qty was raised while the order was created keeps the difference.
+func (c *cartRepositoryImpl) RemoveOrdered(ctx context.Context, userId uint, items []entity.CartItem) (*entity.Cart, error) {
+	err := c.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		cart, err := c.findOrCreate(tx, userId)
+		if err != nil {
+			return err
+		}
+
+		for _, v := range items {
+			line := tx.Model(&entity.CartItem{}).Where("cart_id = ? AND product_id = ? AND variant_id = ?", cart.ID, v.ProductID, v.VariantID)
+
+			if err := line.Session(&gorm.Session{}).Where("qty <= ?", v.Qty).Delete(&entity.CartItem{}).Error; err != nil {
+				return fmt.Errorf("delete item: %w", err)
+			}
+
+			if err := line.Session(&gorm.Session{}).Where("qty > ?", v.Qty).
+				UpdateColumn("qty", gorm.Expr("qty - ?", v.Qty)).Error; err != nil {
+				return fmt.Errorf("update item: %w", err)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("cart repo: remove ordered: %w", err)
+	}
+
+	return c.FindByUserId(ctx, userId)
+}
+
+func (c *cartRepositoryImpl) findOrCreate(db *gorm.DB, userId uint) (*entity.Cart, error) {
+	cart := entity.Cart{}
+	if err := db.Where(entity.Cart{UserID: userId}).FirstOrCreate(&cart).Error; err != nil {
+		return nil, err
+	}
+
+	return &cart, nil
+}
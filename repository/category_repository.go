@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type CategoryRepository interface {
+	Create(ctx context.Context, category *entity.Category) (*entity.Category, error)
+	Update(ctx context.Context, category *entity.Category) (*entity.Category, error)
+	Delete(ctx context.Context, id uint) error
+	FindById(ctx context.Context, id uint) (*entity.Category, error)
+	FindByIds(ctx context.Context, ids []uint) ([]*entity.Category, error)
+	FindAll(ctx context.Context) ([]*entity.Category, error)
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+
+	"gorm.io/gorm"
+)
+
+type categoryRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewCategoryRepositoryImpl(db *gorm.DB) *categoryRepositoryImpl {
+	return &categoryRepositoryImpl{
+		Db: db,
+	}
+}
+
+var ErrCategoryHasChildren = errors.New("category still has sub categories")
+
+// productCount counts the live products linked directly to the category.
+const productCount = "(SELECT COUNT(*) FROM product_categories pc JOIN products p ON p.id = pc.product_id " +
+	"AND p.deleted_at IS NULL WHERE pc.category_id = categories.id) AS product_count"
+
+func (c *categoryRepositoryImpl) Create(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	if err := c.Db.WithContext(ctx).Create(category).Error; err != nil {
+		return nil, fmt.Errorf("category repo: create: %w", err)
+	}
+
+	return c.FindById(ctx, category.ID)
+}
+
+func (c *categoryRepositoryImpl) Update(ctx context.Context, category *entity.Category) (*entity.Category, error) {
+	data := map[string]interface{}{
+		"parent_id":   category.ParentID,
+		"name":        category.Name,
+		"description": category.Description,
+	}
+
+	result := c.Db.WithContext(ctx).Model(&entity.Category{}).Where("id = ?", category.ID).Updates(data)
+	if result.Error != nil {
+		return nil, fmt.Errorf("category repo: update: %w", result.Error)
+	}
+
+	return c.FindById(ctx, category.ID)
+}
+
+func (c *categoryRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	err := c.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var children int64
+		if err := tx.Model(&entity.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return fmt.Errorf("count children: %w", err)
+		}
+
+		if children > 0 {
+			return ErrCategoryHasChildren
+		}
+
+		result := tx.Delete(&entity.Category{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("delete: %w", result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrorIdNotFound
+		}
+
+		if err := tx.Exec("DELETE FROM product_categories WHERE category_id = ?", id).Error; err != nil {
+			return fmt.Errorf("delete product link: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrCategoryHasChildren) || errors.Is(err, ErrorIdNotFound) {
+			return err
+		}
+		return fmt.Errorf("category repo: delete: %w", err)
+	}
+
+	return nil
+}
+
+func (c *categoryRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.Category, error) {
+	category := entity.Category{}
+
+	if err := c.Db.WithContext(ctx).Select("categories.*, "+productCount).First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrorIdNotFound
+		}
+		return nil, fmt.Errorf("category repo: find by id: %w", err)
+	}
+
+	return &category, nil
+}
+
+func (c *categoryRepositoryImpl) FindByIds(ctx context.Context, ids []uint) ([]*entity.Category, error) {
+	var category []*entity.Category
+
+	if len(ids) == 0 {
+		return category, nil
+	}
+
+	if err := c.Db.WithContext(ctx).Where("id IN ?", ids).Find(&category).Error; err != nil {
+		return nil, fmt.Errorf("category repo: find by ids: %w", err)
+	}
+
+	return category, nil
+}
+
+func (c *categoryRepositoryImpl) FindAll(ctx context.Context) ([]*entity.Category, error) {
+	var category []*entity.Category
+
+	if err := c.Db.WithContext(ctx).Select("categories.*, " + productCount).
+		Order("name ASC, id ASC").Find(&category).Error; err != nil {
+		return nil, fmt.Errorf("category repo: find all: %w", err)
+	}
+
+	return category, nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"fmt"
+	"simple-toko/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	OrderCodePrefix         = "TK"
+	InvoiceNumberPrefix     = "INV"
+	TransferCodePrefix      = "TRF"
+	StocktakeCodePrefix     = "STK"
+	PurchaseOrderCodePrefix = "PO"
+	GoodsReceiptCodePrefix  = "GR"
+)
+
+// nextDocumentNumber returns the next number of the day for prefix, formatted
+// as PREFIX-YYYYMMDD-00001. The upsert keeps the sequence row locked until tx
+// ends, so concurrent transactions never get the same number.
+func nextDocumentNumber(tx *gorm.DB, prefix string, at time.Time) (string, error) {
+	day := at.Format("20060102")
+
+	seq := entity.DocumentSequence{
+		Prefix: prefix,
+		Day:    day,
+		Value:  1,
+	}
+
+	if err := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"value": gorm.Expr("value + 1")}),
+	}).Create(&seq).Error; err != nil {
+		return "", fmt.Errorf("next sequence %s: %w", prefix, err)
+	}
+
+	if err := tx.Where("prefix = ? AND day = ?", prefix, day).Take(&seq).Error; err != nil {
+		return "", fmt.Errorf("read sequence %s: %w", prefix, err)
+	}
+
+	return fmt.Sprintf("%s-%s-%05d", prefix, day, seq.Value), nil
+}
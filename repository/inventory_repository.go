@@ -11,4 +11,5 @@ type InventoryRepository interface {
 	Delete(ctx context.Context, invId uint) error
 	FindById(ctx context.Context, invId uint) (*entity.Inventory, error)
 	FindAll(ctx context.Context, page, pageSize int) ([]*entity.Inventory, int64, error)
+	FindByLocation(ctx context.Context, location string) (*entity.Inventory, error)
 }
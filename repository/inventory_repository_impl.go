@@ -47,15 +47,45 @@ func (i *inventoryRepositoryImpl) Update(ctx context.Context, invId uint, inv *e
 	return &dataInv, nil
 }
 
+var ErrInventoryInUse = errors.New("inventory still holds stock or is the home of a product")
+
+// Delete refuses a location that still holds stock or that products fall
+// back to, the stock would be counted but could never be shipped.
 func (i *inventoryRepositoryImpl) Delete(ctx context.Context, invId uint) error {
-	dataInv := entity.Inventory{}
-	result := i.Db.WithContext(ctx).Delete(&dataInv, invId)
-	if result.Error != nil {
-		return fmt.Errorf("inventory repo: delete: %w", result.Error)
-	}
+	err := i.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var total int64
+		if err := tx.Model(&entity.ProductStock{}).Where("inventory_id = ? AND quantity > 0", invId).
+			Count(&total).Error; err != nil {
+			return fmt.Errorf("count stock: %w", err)
+		}
+
+		if total == 0 {
+			if err := tx.Model(&entity.Product{}).Where("inventory_id = ?", invId).Count(&total).Error; err != nil {
+				return fmt.Errorf("count product: %w", err)
+			}
+		}
+
+		if total > 0 {
+			return ErrInventoryInUse
+		}
+
+		result := tx.Delete(&entity.Inventory{}, invId)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrorIdNotFound
+		}
+
+		return nil
+	})
 
-	if result.RowsAffected == 0 {
-		return ErrorIdNotFound
+	if err != nil {
+		if errors.Is(err, ErrorIdNotFound) || errors.Is(err, ErrInventoryInUse) {
+			return err
+		}
+		return fmt.Errorf("inventory repo: delete: %w", err)
 	}
 
 	return nil
@@ -92,3 +122,18 @@ func (i *inventoryRepositoryImpl) FindAll(ctx context.Context, page, pageSize in
 	
 	return dataInv, totalItems, nil
 }
+
+func (i *inventoryRepositoryImpl) FindByLocation(ctx context.Context, location string) (*entity.Inventory, error) {
+	dataInv := entity.Inventory{}
+
+	result := i.Db.WithContext(ctx).Where("location = ?", location).Order("id").First(&dataInv)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrorIdNotFound
+		}
+
+		return nil, fmt.Errorf("inventory repo: find location: %w", result.Error)
+	}
+
+	return &dataInv, nil
+}
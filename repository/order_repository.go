@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"simple-toko/entity"
+	"time"
 )
 
 type OrderRepository interface {
@@ -10,11 +11,13 @@ type OrderRepository interface {
 	UpdateAddress(ctx context.Context, order *entity.Order) (*entity.Order, error)
 	Delete(ctx context.Context, id uint) error
 	FindById(ctx context.Context, id uint) (*entity.Order, error)
-	FindAll(ctx context.Context, page, pageSize int) ([]*entity.Order, int64, error)
+	FindAll(ctx context.Context, page, pageSize int, filter *entity.OrderFilter) ([]*entity.Order, int64, error)
 	FindByOrderId(ctx context.Context, orderId uint) ([]*entity.OrderProduct, error)
-	ConfirmOrder(ctx context.Context, orderId uint, order *entity.Order) (*entity.Order, error)
-	// RemoveOrderItem(ctx context.Context, orderId, productId uint) (*entity.Order, error)
-	// UpdateOrderQty(ctx context.Context, orderId, productId uint, qty int) (*entity.Order, error)
-	//AddOrderItem(ctx context.Context, orderId uint, item *entity.Order) (*entity.Order, error)
-
+	FindExpired(ctx context.Context, now, createdBefore time.Time, limit int) ([]*entity.Order, error)
+	ChangeStatus(ctx context.Context, order *entity.Order, statusOrder, statusDelivery string, history *entity.OrderStatusHistory) (*entity.Order, error)
+	CancelOrder(ctx context.Context, order *entity.Order, history *entity.OrderStatusHistory) (*entity.Order, error)
+	FindHistory(ctx context.Context, orderId uint) ([]*entity.OrderStatusHistory, error)
+	RemoveOrderItem(ctx context.Context, orderId, productId, variantId, userId uint) (*entity.Order, error)
+	UpdateOrderQty(ctx context.Context, orderId, productId, variantId uint, qty int, userId uint) (*entity.Order, error)
+	AddOrderItem(ctx context.Context, orderId uint, item *entity.OrderProduct, userId uint) (*entity.Order, error)
 }
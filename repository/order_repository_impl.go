@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"simple-toko/entity"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type orderRepositoryImpl struct {
@@ -23,15 +25,17 @@ const (
 	Waiting   string = "waiting"
 	Confirmed string = "confirmed"
 	Canceled  string = "canceled"
-	OnProcess string = "on process"
 	Delivered string = "delivered"
 )
 
 var (
-	ErrEmptyItems      = errors.New("order has no items")
-	ErrProductNotFound = errors.New("product not found")
-	ErrOrderNotFound   = errors.New("order not found")
-	ErrAddressNotFound = errors.New("address not found")
+	ErrEmptyItems         = errors.New("order has no items")
+	ErrProductNotFound    = errors.New("product not found")
+	ErrOrderNotFound      = errors.New("order not found")
+	ErrAddressNotFound    = errors.New("address not found")
+	ErrOrderStatusChanged = errors.New("order status has changed")
+	ErrOrderNotEditable   = errors.New("order can no longer be edited")
+	ErrOrderItemNotFound  = errors.New("order item not found")
 )
 
 func (o *orderRepositoryImpl) CreateOrder(ctx context.Context, order *entity.Order) (*entity.Order, error) {
@@ -54,34 +58,34 @@ func (o *orderRepositoryImpl) CreateOrder(ctx context.Context, order *entity.Ord
 			}
 		}
 
+		code, err := nextDocumentNumber(tx, OrderCodePrefix, time.Now())
+		if err != nil {
+			return err
+		}
+		order.Code = code
+
 		if err := tx.Omit("OrderProducts").Create(order).Error; err != nil {
 			return fmt.Errorf("create order: %w", err)
 		}
 
 		//create data on table pivot
+		used := map[uint]bool{}
+		move := &entity.StockMovement{Reason: StockSale, RefType: RefOrder, RefID: order.ID, UserID: &order.UserID}
 		for i := range order.OrderProducts {
 			item := &order.OrderProducts[i]
 			item.OrderID = order.ID
 
-			var p entity.Product
-			if err := tx.Select("id, price").First(&p, item.ProductID).Error; err != nil {
-				if errors.Is(err, gorm.ErrRecordNotFound) {
-					return ErrProductNotFound
-				}
-				return fmt.Errorf("find product: %w", err)
+			if err := o.priceOrderItem(tx, item); err != nil {
+				return err
 			}
-			item.UnitPrice = p.Price
 
-			//reduce stock
-			stock := tx.Model(&entity.Product{}).Where("id = ? AND stock >= ?", item.ProductID, item.Qty).
-				UpdateColumn("stock", gorm.Expr("stock - ?", item.Qty))
-			if stock.Error != nil {
-				return fmt.Errorf("reduce stock: %w", stock.Error)
-			}
-
-			if stock.RowsAffected == 0 {
-				return ErrNotEnoughStock
+			//take the stock of the variant or of the product without variants
+			//from one warehouse
+			inventoryId, err := allocateStock(tx, item.ProductID, item.VariantID, item.Qty, address.Addresses, used, move)
+			if err != nil {
+				return err
 			}
+			item.InventoryID = inventoryId
 		}
 
 		if err := tx.Create(&order.OrderProducts).Error; err != nil {
@@ -179,17 +183,43 @@ func (o *orderRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.Or
 	return &order, nil
 }
 
-func (o *orderRepositoryImpl) FindAll(ctx context.Context, page, pageSize int) ([]*entity.Order, int64, error) {
+func (o *orderRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, filter *entity.OrderFilter) ([]*entity.Order, int64, error) {
 	var order []*entity.Order
 	var totalItems int64
 
-	if err := o.Db.WithContext(ctx).Model(&entity.Order{}).Count(&totalItems).Error; err != nil {
+	query := o.Db.WithContext(ctx).Model(&entity.Order{})
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	if filter.Search != "" {
+		query = query.Where("code LIKE ? OR invoice_number LIKE ?", "%"+filter.Search+"%", "%"+filter.Search+"%")
+	}
+
+	if filter.StatusOrder != "" {
+		query = query.Where("status_order = ?", filter.StatusOrder)
+	}
+
+	if filter.StatusDelivery != "" {
+		query = query.Where("status_delivery = ?", filter.StatusDelivery)
+	}
+
+	if filter.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+
+	if filter.CreatedTo != nil {
+		query = query.Where("created_at < ?", *filter.CreatedTo)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
 
-	if err := o.Db.WithContext(ctx).Limit(pageSize).Offset(offset).Preload("OrderProducts").
+	if err := query.Order("created_at DESC, id DESC").Limit(pageSize).Offset(offset).Preload("OrderProducts").
 		Preload("OrderProducts.Product").Preload("User").
 		Preload("Address").Find(&order).Error; err != nil {
 		return nil, 0, err
@@ -198,6 +228,20 @@ func (o *orderRepositoryImpl) FindAll(ctx context.Context, page, pageSize int) (
 	return order, totalItems, nil
 }
 
+func (o *orderRepositoryImpl) FindExpired(ctx context.Context, now, createdBefore time.Time, limit int) ([]*entity.Order, error) {
+	var order []*entity.Order
+
+	if err := o.Db.WithContext(ctx).
+		Where("status_order = ?", Waiting).
+		Where("(pay_before <= ? OR (pay_before IS NULL AND created_at <= ?))", now, createdBefore).
+		Where("NOT EXISTS (SELECT 1 FROM payments WHERE payments.order_id = orders.id AND payments.deleted_at IS NULL)").
+		Order("id ASC").Limit(limit).Find(&order).Error; err != nil {
+		return nil, fmt.Errorf("order repo: find expired: %w", err)
+	}
+
+	return order, nil
+}
+
 func (o *orderRepositoryImpl) FindByOrderId(ctx context.Context, orderId uint) ([]*entity.OrderProduct, error) {
 	var order []*entity.OrderProduct
 
@@ -212,90 +256,411 @@ func (o *orderRepositoryImpl) FindByOrderId(ctx context.Context, orderId uint) (
 	return order, nil
 }
 
-func (o *orderRepositoryImpl) ConfirmOrder(ctx context.Context, orderId uint, order *entity.Order) (*entity.Order, error) {
-	data := map[string]interface{}{}
+func (o *orderRepositoryImpl) ChangeStatus(ctx context.Context, order *entity.Order, statusOrder, statusDelivery string, history *entity.OrderStatusHistory) (*entity.Order, error) {
+	err := o.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return o.updateStatus(tx, order, statusOrder, statusDelivery, history)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrOrderStatusChanged) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("order repo: change status: %w", err)
+	}
+
+	result := entity.Order{}
+	if err := o.Db.WithContext(ctx).
+		Preload("OrderProducts").Preload("OrderProducts.Product").
+		Preload("User").Preload("Address").First(&result, order.ID).Error; err != nil {
+		return nil, fmt.Errorf("order repo: preload order change status: %w", err)
+	}
+
+	return &result, nil
+}
+
+func (o *orderRepositoryImpl) CancelOrder(ctx context.Context, order *entity.Order, history *entity.OrderStatusHistory) (*entity.Order, error) {
+	err := o.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := o.updateStatus(tx, order, Canceled, Canceled, history); err != nil {
+			return err
+		}
+
+		var items []entity.OrderProduct
+		if err := tx.Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+			return fmt.Errorf("find order item: %w", err)
+		}
+
+		//restore stock
+		move := &entity.StockMovement{Reason: StockCancel, RefType: RefOrder, RefID: order.ID, UserID: history.UserID}
+		for _, v := range items {
+			if err := addStock(tx, v.ProductID, v.VariantID, v.InventoryID, v.Qty, move); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&entity.Payment{}).Where("order_id = ?", order.ID).
+			Update("status", Canceled).Error; err != nil {
+			return fmt.Errorf("cancel payment: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrOrderStatusChanged) || errors.Is(err, ErrStockNotRestorable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("order repo: cancel order: %w", err)
+	}
+
+	result := entity.Order{}
+	if err := o.Db.WithContext(ctx).
+		Preload("OrderProducts").Preload("OrderProducts.Product").
+		Preload("User").Preload("Address").First(&result, order.ID).Error; err != nil {
+		return nil, fmt.Errorf("order repo: preload order cancel: %w", err)
+	}
+
+	return &result, nil
+}
+
+func (o *orderRepositoryImpl) FindHistory(ctx context.Context, orderId uint) ([]*entity.OrderStatusHistory, error) {
+	var data []*entity.OrderStatusHistory
+
+	if err := o.Db.WithContext(ctx).Preload("User").Where("order_id = ?", orderId).
+		Order("created_at ASC, id ASC").Find(&data).Error; err != nil {
+		return nil, fmt.Errorf("order repo: find history: %w", err)
+	}
+
+	return data, nil
+}
+
+// updateStatus only moves the order when it is still in the status the caller
+// read, so two admins acting on the same order cannot both win.
+func (o *orderRepositoryImpl) updateStatus(tx *gorm.DB, order *entity.Order, statusOrder, statusDelivery string, history *entity.OrderStatusHistory) error {
+	data := map[string]interface{}{
+		"status_order":    statusOrder,
+		"status_delivery": statusDelivery,
+	}
+
+	result := tx.Model(&entity.Order{}).
+		Where("id = ? AND status_order = ? AND status_delivery = ?", order.ID, order.StatusOrder, order.StatusDelivery).
+		Updates(data)
+	if result.Error != nil {
+		return fmt.Errorf("update status: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrOrderStatusChanged
+	}
+
+	history.OrderID = order.ID
+	if err := tx.Create(history).Error; err != nil {
+		return fmt.Errorf("create history: %w", err)
+	}
+
+	return nil
+}
+
+func (o *orderRepositoryImpl) AddOrderItem(ctx context.Context, orderId uint, item *entity.OrderProduct, userId uint) (*entity.Order, error) {
+	err := o.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := o.lockEditableOrder(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		if err := o.priceOrderItem(tx, item); err != nil {
+			return err
+		}
+
+		move := orderMovement(StockSale, orderId, userId)
+
+		//merge with the existing line, order_id, product_id and variant_id are unique
+		var existing entity.OrderProduct
+		err = tx.Unscoped().Where("order_id = ? AND product_id = ? AND variant_id = ?", orderId, item.ProductID, item.VariantID).
+			Take(&existing).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("find order item: %w", err)
+		}
+		found := err == nil
+
+		//a live line keeps its warehouse, a new one is allocated like at checkout
+		if found && !existing.DeletedAt.Valid {
+			inventoryId, err := lineInventory(tx, &existing)
+			if err != nil {
+				return err
+			}
+
+			if err := reduceStock(tx, item.ProductID, item.VariantID, inventoryId, item.Qty, move); err != nil {
+				return err
+			}
+			item.InventoryID = inventoryId
+		} else {
+			used, err := o.orderWarehouses(tx, orderId)
+			if err != nil {
+				return err
+			}
+
+			inventoryId, err := allocateStock(tx, item.ProductID, item.VariantID, item.Qty, order.Address.Addresses, used, move)
+			if err != nil {
+				return err
+			}
+			item.InventoryID = inventoryId
+		}
+
+		if found {
+			qty := item.Qty
+			if !existing.DeletedAt.Valid {
+				qty += existing.Qty
+			}
+
+			data := map[string]interface{}{
+				"qty":          qty,
+				"unit_price":   item.UnitPrice,
+				"sku":          item.Sku,
+				"variant_name": item.VariantName,
+				"inventory_id": item.InventoryID,
+				"deleted_at":   nil,
+			}
+
+			if err := tx.Unscoped().Model(&existing).Updates(data).Error; err != nil {
+				return fmt.Errorf("merge order item: %w", err)
+			}
+		} else {
+			item.OrderID = orderId
+
+			if err := tx.Create(item).Error; err != nil {
+				return fmt.Errorf("create order item: %w", err)
+			}
+		}
+
+		return o.recomputeAmount(tx, orderId)
+	})
+
+	if err != nil {
+		if isOrderItemErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("order repo: add item: %w", err)
+	}
+
+	return o.FindById(ctx, orderId)
+}
+
+func (o *orderRepositoryImpl) RemoveOrderItem(ctx context.Context, orderId, productId, variantId, userId uint) (*entity.Order, error) {
+	err := o.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := o.lockEditableOrder(tx, orderId); err != nil {
+			return err
+		}
+
+		item, err := o.findOrderItem(tx, orderId, productId, variantId)
+		if err != nil {
+			return err
+		}
 
-	if order.StatusOrder != "" {
-		data["status_order"] = order.StatusOrder
+		var totalItems int64
+		if err := tx.Model(&entity.OrderProduct{}).Where("order_id = ?", orderId).Count(&totalItems).Error; err != nil {
+			return fmt.Errorf("count order item: %w", err)
+		}
+
+		if totalItems <= 1 {
+			return ErrEmptyItems
+		}
+
+		//hard delete, a soft deleted row still holds the unique index
+		if err := tx.Unscoped().Delete(item).Error; err != nil {
+			return fmt.Errorf("delete order item: %w", err)
+		}
+
+		if err := addStock(tx, productId, variantId, item.InventoryID, item.Qty, orderMovement(StockCancel, orderId, userId)); err != nil {
+			return err
+		}
+
+		return o.recomputeAmount(tx, orderId)
+	})
+
+	if err != nil {
+		if isOrderItemErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("order repo: remove item: %w", err)
 	}
 
-	if order.StatusDelivery != "" {
-		data["status_delivery"] = order.StatusDelivery
+	return o.FindById(ctx, orderId)
+}
+
+func (o *orderRepositoryImpl) UpdateOrderQty(ctx context.Context, orderId, productId, variantId uint, qty int, userId uint) (*entity.Order, error) {
+	err := o.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := o.lockEditableOrder(tx, orderId); err != nil {
+			return err
+		}
+
+		item, err := o.findOrderItem(tx, orderId, productId, variantId)
+		if err != nil {
+			return err
+		}
+
+		priced := entity.OrderProduct{ProductID: productId, VariantID: variantId}
+		if err := o.priceOrderItem(tx, &priced); err != nil {
+			return err
+		}
+
+		//the line keeps its warehouse, more qty must be there as well
+		delta := qty - item.Qty
+		if delta > 0 {
+			inventoryId, err := lineInventory(tx, item)
+			if err != nil {
+				return err
+			}
+
+			if err := reduceStock(tx, productId, variantId, inventoryId, delta, orderMovement(StockSale, orderId, userId)); err != nil {
+				return err
+			}
+		}
+
+		if delta < 0 {
+			if err := addStock(tx, productId, variantId, item.InventoryID, -delta, orderMovement(StockCancel, orderId, userId)); err != nil {
+				return err
+			}
+		}
+
+		data := map[string]interface{}{
+			"qty":        qty,
+			"unit_price": priced.UnitPrice,
+		}
+
+		if err := tx.Model(item).Updates(data).Error; err != nil {
+			return fmt.Errorf("update order item: %w", err)
+		}
+
+		return o.recomputeAmount(tx, orderId)
+	})
+
+	if err != nil {
+		if isOrderItemErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("order repo: update qty: %w", err)
 	}
 
-	if err := o.Db.WithContext(ctx).Where("id = ? AND (status_order = ? OR status_order = ?)", orderId, Waiting, Confirmed).
-		First(&order).Error; err != nil {
+	return o.FindById(ctx, orderId)
+}
+
+// lockEditableOrder holds the order row until the transaction ends, lines can
+// only change while the order is waiting and no payment has been uploaded.
+func (o *orderRepositoryImpl) lockEditableOrder(tx *gorm.DB, orderId uint) (*entity.Order, error) {
+	var order entity.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Address").First(&order, orderId).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrOrderNotFound
 		}
-		return nil, fmt.Errorf("order repo: confirm order find id: %w", err)
+		return nil, fmt.Errorf("lock order: %w", err)
 	}
 
-	if err := o.Db.WithContext(ctx).Model(&order).Select("status_order", "status_delivery").
-		Updates(data).Error; err != nil {
-		return nil, fmt.Errorf("order repo: confirm order: %w", err)
+	if order.StatusOrder != Waiting {
+		return nil, ErrOrderNotEditable
 	}
 
-	if err := o.Db.WithContext(ctx).
-		Preload("OrderProducts").Preload("OrderProducts.Product").
-		Preload("User").Preload("Address").First(&order, orderId).Error; err != nil {
-		return nil, fmt.Errorf("order repo: preload order confirm: %w", err)
+	var totalPay int64
+	if err := tx.Model(&entity.Payment{}).Where("order_id = ?", orderId).Count(&totalPay).Error; err != nil {
+		return nil, fmt.Errorf("count payment: %w", err)
 	}
 
-	return order, nil
+	if totalPay > 0 {
+		return nil, ErrOrderNotEditable
+	}
+
+	return &order, nil
 }
 
-//func (o *orderRepositoryImpl) AddOrderItem(ctx context.Context, orderId uint, item *entity.OrderProduct) (*entity.Order, error) {
-// 	var order entity.Order
+// orderMovement describes a stock change made by editing an order, userId 0
+// is left empty.
+func orderMovement(reason string, orderId, userId uint) *entity.StockMovement {
+	move := entity.StockMovement{Reason: reason, RefType: RefOrder, RefID: orderId}
+	if userId != 0 {
+		move.UserID = &userId
+	}
 
-// 	err := o.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return &move
+}
 
-// 		if err := tx.First(&order, orderId).Error; err != nil {
-// 			if errors.Is(err, gorm.ErrRecordNotFound) {
-// 				return ErrOrderNotFound
-// 			}
-// 			return fmt.Errorf("add item repo: find order: %w", err)
-// 		}
+// orderWarehouses lists the warehouses the order already ships from.
+func (o *orderRepositoryImpl) orderWarehouses(tx *gorm.DB, orderId uint) (map[uint]bool, error) {
+	var ids []uint
+	if err := tx.Model(&entity.OrderProduct{}).Where("order_id = ? AND inventory_id <> 0", orderId).
+		Distinct().Pluck("inventory_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("find order warehouses: %w", err)
+	}
 
-// 		var p entity.Product
-// 		if err := tx.First(&p, item.ProductID).Error; err != nil {
-// 			if errors.Is(err, gorm.ErrRecordNotFound) {
-// 				return ErrProductNotFound
-// 			}
-// 			return fmt.Errorf("add item repo: find product: %w", err)
-// 		}
+	used := map[uint]bool{}
+	for _, v := range ids {
+		used[v] = true
+	}
 
-// 		item.UnitPrice = p.Price
-// 		item.OrderID = orderId
+	return used, nil
+}
 
-// 		if err := tx.Create(item).Error; err != nil {
-// 			return err
-// 		}
+func (o *orderRepositoryImpl) findOrderItem(tx *gorm.DB, orderId, productId, variantId uint) (*entity.OrderProduct, error) {
+	var item entity.OrderProduct
+	if err := tx.Where("order_id = ? AND product_id = ? AND variant_id = ?", orderId, productId, variantId).
+		Take(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderItemNotFound
+		}
+		return nil, fmt.Errorf("find order item: %w", err)
+	}
 
-// 		newAmount := order.AmountPay + (item.UnitPrice * float64(item.Qty))
-// 		if err := tx.Model(&order).Update("amount_pay", newAmount).Error; err != nil {
-// 			return err
-// 		}
+	return &item, nil
+}
 
-// 		if err := tx.Preload("OrderProduct").First(&order, orderId).Error; err != nil {
-// 			return err
-// 		}
+// priceOrderItem sets the current unit price of the line and keeps the sku
+// and option values of the variant, so the line still reads right after the
+// variant changes.
+func (o *orderRepositoryImpl) priceOrderItem(tx *gorm.DB, item *entity.OrderProduct) error {
+	var p entity.Product
+	if err := tx.Select("id, price").First(&p, item.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		return fmt.Errorf("find product: %w", err)
+	}
+	item.UnitPrice = p.Price
 
-// 		return nil
+	if item.VariantID == 0 {
+		return checkVariant(tx, item.ProductID, 0)
+	}
 
-// 	})
+	var v entity.ProductVariant
+	if err := tx.Where("product_id = ?", item.ProductID).First(&v, item.VariantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrVariantNotFound
+		}
+		return fmt.Errorf("find variant: %w", err)
+	}
 
-// 	if err != nil {
-// 		return nil, err
-// 	}
+	item.UnitPrice = v.EffectivePrice(p.Price)
+	item.Sku = v.SKU
+	item.VariantName = v.Title()
 
-// 	return &order, nil
-// }
+	return nil
+}
 
-// func (o *orderRepositoryImpl) RemoveOrderItem(ctx context.Context, orderId, productId uint) (*entity.Order, error) {
+func (o *orderRepositoryImpl) recomputeAmount(tx *gorm.DB, orderId uint) error {
+	var amountPay float64
+	if err := tx.Model(&entity.OrderProduct{}).Select("COALESCE(SUM(qty * unit_price), 0)").
+		Where("order_id = ?", orderId).Scan(&amountPay).Error; err != nil {
+		return fmt.Errorf("sum amount pay: %w", err)
+	}
 
-// }
+	if err := tx.Model(&entity.Order{}).Where("id = ?", orderId).Update("amount_pay", amountPay).Error; err != nil {
+		return fmt.Errorf("update amount pay: %w", err)
+	}
 
-// func (o *orderRepositoryImpl) UpdateOrderQty(ctx context.Context, orderId, productId uint, qty int) (*entity.Order, error) {
+	return nil
+}
 
-// }
+func isOrderItemErr(err error) bool {
+	return errors.Is(err, ErrOrderNotFound) || errors.Is(err, ErrOrderNotEditable) ||
+		errors.Is(err, ErrOrderItemNotFound) || errors.Is(err, ErrProductNotFound) ||
+		errors.Is(err, ErrNotEnoughStock) || errors.Is(err, ErrEmptyItems) ||
+		errors.Is(err, ErrVariantNotFound) || errors.Is(err, ErrVariantRequired) ||
+		errors.Is(err, ErrStockNotRestorable)
+}
@@ -3,13 +3,15 @@ package repository
 import (
 	"context"
 	"simple-toko/entity"
+	"time"
 )
 
 type PaymentRepository interface {
-	UploadPayment(ctx context.Context, pym *entity.Payment) (*entity.Payment, error)
+	UploadPayment(ctx context.Context, pym *entity.Payment, now, createdBefore time.Time) (*entity.Payment, error)
 	UpdateStatus(ctx context.Context, pym *entity.Payment) (*entity.Payment, error)
 	FindById(ctx context.Context, id uint) (*entity.Payment, error)
 	FindByOrderId(ctx context.Context, orderId uint) (*entity.Payment, error)
+	FindByImage(ctx context.Context, image string) (*entity.Payment, error)
 	FindAll(ctx context.Context, page, pageSize int) ([]*entity.Payment,int64, error)
 	Delete(ctx context.Context, id uint) error
 	//Confirm(ctx context.Context, orderId uint, pym *entity.Payment) (*entity.Payment, error)
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"simple-toko/entity"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type paymentRepositoryImpl struct {
@@ -19,16 +21,46 @@ func NewPaymentRepositoryImpl(db *gorm.DB) *paymentRepositoryImpl {
 	}
 }
 
-func (pay *paymentRepositoryImpl) UploadPayment(ctx context.Context, pym *entity.Payment) (*entity.Payment, error) {
+var (
+	ErrPaymentNotFound       = errors.New("payment not found")
+	ErrPaymentNotConfirmable = errors.New("payment of a canceled order cannot be confirmed")
+	ErrOrderNotPayable       = errors.New("order is no longer waiting for payment")
+)
 
-	if err := pay.Db.WithContext(ctx).First(&entity.Order{}, pym.OrderID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrOrderNotFound
+// UploadPayment saves the proof of an order still waiting for payment, the
+// order is held so the expiry cannot cancel it at the same time. An order
+// without pay_before expires once it was created before createdBefore.
+func (pay *paymentRepositoryImpl) UploadPayment(ctx context.Context, pym *entity.Payment, now, createdBefore time.Time) (*entity.Payment, error) {
+	err := pay.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order entity.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, status_order, pay_before, created_at").
+			First(&order, pym.OrderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderNotFound
+			}
+			return fmt.Errorf("lock order: %w", err)
+		}
+
+		if order.StatusOrder != Waiting {
+			return ErrOrderNotPayable
+		}
+
+		if (order.PayBefore != nil && !order.PayBefore.After(now)) ||
+			(order.PayBefore == nil && !order.CreatedAt.After(createdBefore)) {
+			return ErrOrderNotPayable
+		}
+
+		if err := tx.Model(pym).Create(pym).Error; err != nil {
+			return fmt.Errorf("upload payment: %w", err)
 		}
-		return nil, fmt.Errorf("payment repo: find order, upload payment: %w", err)
-	}
 
-	if err := pay.Db.WithContext(ctx).Model(pym).Create(pym).Error; err != nil {
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrOrderNotFound) || errors.Is(err, ErrOrderNotPayable) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("payment repo: upload payment: %w", err)
 	}
 
@@ -47,8 +79,41 @@ func (pay *paymentRepositoryImpl) UpdateStatus(ctx context.Context, pym *entity.
 		return nil, fmt.Errorf("payment repo: find order, update status: %w", err)
 	}
 
-	if err := pay.Db.WithContext(ctx).Model(pym).Where("order_id = ?", pym.OrderID).Update("status", pym.Status).Error; err != nil {
-		return nil, fmt.Errorf("payment repo: update status: %w", err)
+	err := pay.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		//the order is held so it cannot be canceled while the payment is confirmed
+		var order entity.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, status_order").
+			First(&order, pym.OrderID).Error; err != nil {
+			return fmt.Errorf("lock order: %w", err)
+		}
+
+		if pym.Status == Confirmed {
+			var current []string
+			if err := tx.Model(&entity.Payment{}).Where("order_id = ?", pym.OrderID).
+				Pluck("status", &current).Error; err != nil {
+				return fmt.Errorf("find payment status: %w", err)
+			}
+
+			if order.StatusOrder == Canceled || (len(current) > 0 && current[0] == Canceled) {
+				return ErrPaymentNotConfirmable
+			}
+		}
+
+		if err := tx.Model(pym).Where("order_id = ?", pym.OrderID).Update("status", pym.Status).Error; err != nil {
+			return fmt.Errorf("update status: %w", err)
+		}
+
+		if pym.Status != Confirmed {
+			return nil
+		}
+
+		return assignInvoiceNumber(tx, pym.OrderID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrPaymentNotConfirmable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("payment repo: %w", err)
 	}
 
 	if err := pay.Db.WithContext(ctx).Preload("Order").Where("order_id = ?", pym.OrderID).Take(pym).Error; err != nil {
@@ -84,12 +149,27 @@ func (pay *paymentRepositoryImpl) FindByOrderId(ctx context.Context, orderId uin
 
 	var data entity.Payment
 	if err := pay.Db.WithContext(ctx).Preload("Order").Where("order_id = ?", orderId).Take(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
 		return nil, fmt.Errorf("payment repo: preload payment, find order id: %w", err)
 	}
 
 	return &data, nil
 }
 
+func (pay *paymentRepositoryImpl) FindByImage(ctx context.Context, image string) (*entity.Payment, error) {
+	var data entity.Payment
+	if err := pay.Db.WithContext(ctx).Preload("Order").Where("image = ?", image).Take(&data).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("payment repo: find image: %w", err)
+	}
+
+	return &data, nil
+}
+
 func (pay *paymentRepositoryImpl) FindAll(ctx context.Context, page, pageSize int) ([]*entity.Payment, int64, error) {
 	var dataPay []*entity.Payment
 	var totalItems int64
@@ -120,3 +200,28 @@ func (pay *paymentRepositoryImpl) Delete(ctx context.Context, id uint) error {
 
 	return nil
 }
+
+// assignInvoiceNumber gives the order its invoice number the first time the
+// payment is confirmed, confirming again keeps the number already issued.
+func assignInvoiceNumber(tx *gorm.DB, orderId uint) error {
+	var order entity.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, invoice_number").
+		First(&order, orderId).Error; err != nil {
+		return fmt.Errorf("lock order: %w", err)
+	}
+
+	if order.InvoiceNumber != "" {
+		return nil
+	}
+
+	number, err := nextDocumentNumber(tx, InvoiceNumberPrefix, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&order).UpdateColumn("invoice_number", number).Error; err != nil {
+		return fmt.Errorf("assign invoice number: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"fmt"
+	"simple-toko/entity"
+
+	"gorm.io/gorm"
+)
+
+// Every write of products.price goes through recordPrice and every write of
+// product_variants.price through recordVariantPrice in the same transaction,
+// so product_price_histories is the full timeline of a product.
+
+const (
+	PriceCreated   = "create"
+	PriceManual    = "manual"
+	PriceImport    = "import"
+	PriceScheduled = "schedule"
+)
+
+func recordPrice(tx *gorm.DB, productId uint, oldPrice, newPrice float64, source string, scheduleId *uint) error {
+	return savePrice(tx, &entity.ProductPriceHistory{
+		ProductID:  productId,
+		OldPrice:   oldPrice,
+		NewPrice:   newPrice,
+		Source:     source,
+		ScheduleID: scheduleId,
+	})
+}
+
+// recordVariantPrice records the price a variant sells at, a variant without
+// its own price is recorded at the product price.
+func recordVariantPrice(tx *gorm.DB, productId, variantId uint, oldPrice, newPrice float64, source string) error {
+	return savePrice(tx, &entity.ProductPriceHistory{
+		ProductID: productId,
+		VariantID: variantId,
+		OldPrice:  oldPrice,
+		NewPrice:  newPrice,
+		Source:    source,
+	})
+}
+
+func savePrice(tx *gorm.DB, history *entity.ProductPriceHistory) error {
+	if history.OldPrice == history.NewPrice && history.Source != PriceCreated {
+		return nil
+	}
+
+	if err := tx.Create(history).Error; err != nil {
+		return fmt.Errorf("record price: %w", err)
+	}
+
+	return nil
+}
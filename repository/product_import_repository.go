@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+	"time"
+)
+
+type ProductImportRepository interface {
+	Create(ctx context.Context, job *entity.ProductImport) (*entity.ProductImport, error)
+	FindById(ctx context.Context, id uint) (*entity.ProductImport, error)
+	Claim(ctx context.Context, staleBefore time.Time) (*entity.ProductImport, error)
+	Progress(ctx context.Context, job *entity.ProductImport, errs []entity.ProductImportError) error
+}
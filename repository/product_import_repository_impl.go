@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type productImportRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewProductImportRepositoryImpl(db *gorm.DB) *productImportRepositoryImpl {
+	return &productImportRepositoryImpl{
+		Db: db,
+	}
+}
+
+const (
+	ImportQueued  = "queued"
+	ImportRunning = "running"
+	ImportDone    = "done"
+	ImportFailed  = "failed"
+)
+
+var ErrImportNotFound = errors.New("product import not found")
+
+func (p *productImportRepositoryImpl) Create(ctx context.Context, job *entity.ProductImport) (*entity.ProductImport, error) {
+	job.Status = ImportQueued
+
+	if err := p.Db.WithContext(ctx).Create(job).Error; err != nil {
+		return nil, fmt.Errorf("product import repo: create: %w", err)
+	}
+
+	return job, nil
+}
+
+func (p *productImportRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.ProductImport, error) {
+	job := entity.ProductImport{}
+
+	if err := p.Db.WithContext(ctx).Preload("Errors", func(db *gorm.DB) *gorm.DB {
+		return db.Order("`row`, id")
+	}).First(&job, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrImportNotFound
+		}
+		return nil, fmt.Errorf("product import repo: find by id: %w", err)
+	}
+
+	return &job, nil
+}
+
+// Claim marks the oldest queued import as running and returns it, nil when
+// there is nothing to do. A running import that saved no progress since
+// staleBefore lost its worker and is started again, rows are upserts so
+// running them twice is safe. The status check in the update keeps two
+// workers from taking the same import.
+func (p *productImportRepositoryImpl) Claim(ctx context.Context, staleBefore time.Time) (*entity.ProductImport, error) {
+	for {
+		job := entity.ProductImport{}
+
+		err := p.Db.WithContext(ctx).
+			Where("status = ? OR (status = ? AND updated_at < ?)", ImportQueued, ImportRunning, staleBefore).
+			Order("id").First(&job).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("product import repo: find queued: %w", err)
+		}
+
+		now := time.Now()
+		result := p.Db.WithContext(ctx).Model(&entity.ProductImport{}).
+			Where("id = ? AND status = ? AND updated_at = ?", job.ID, job.Status, job.UpdatedAt).
+			Updates(map[string]interface{}{
+				"status":     ImportRunning,
+				"processed":  0,
+				"created":    0,
+				"updated":    0,
+				"failed":     0,
+				"started_at": now,
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return nil, fmt.Errorf("product import repo: claim: %w", result.Error)
+		}
+
+		//another worker was faster, look for the next one
+		if result.RowsAffected == 0 {
+			continue
+		}
+
+		if err := p.Db.WithContext(ctx).Where("product_import_id = ?", job.ID).Delete(&entity.ProductImportError{}).Error; err != nil {
+			return nil, fmt.Errorf("product import repo: clear errors: %w", err)
+		}
+
+		job.Status = ImportRunning
+		job.Processed, job.Created, job.Updated, job.Failed = 0, 0, 0, 0
+		job.StartedAt = &now
+		return &job, nil
+	}
+}
+
+// Progress saves the counters and status of the import with the row errors
+// found since the last call.
+func (p *productImportRepositoryImpl) Progress(ctx context.Context, job *entity.ProductImport, errs []entity.ProductImportError) error {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(job).Select("status", "total_rows", "processed", "created", "updated", "failed", "error", "finished_at").
+			Updates(job).Error; err != nil {
+			return err
+		}
+
+		if len(errs) == 0 {
+			return nil
+		}
+
+		for i := range errs {
+			errs[i].ProductImportID = job.ID
+		}
+
+		return tx.Create(&errs).Error
+	})
+
+	if err != nil {
+		return fmt.Errorf("product import repo: progress: %w", err)
+	}
+
+	return nil
+}
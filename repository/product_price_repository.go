@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+	"time"
+)
+
+type ProductPriceRepository interface {
+	CreateSchedule(ctx context.Context, schedule *entity.ProductPriceSchedule) (*entity.ProductPriceSchedule, error)
+	FindSchedules(ctx context.Context, productId uint, status string) ([]*entity.ProductPriceSchedule, error)
+	CancelSchedule(ctx context.Context, productId, id uint) (*entity.ProductPriceSchedule, error)
+	FindHistory(ctx context.Context, productId uint) ([]*entity.ProductPriceHistory, error)
+	ApplyDue(ctx context.Context, now time.Time) ([]uint, error)
+}
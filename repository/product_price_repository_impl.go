@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type productPriceRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewProductPriceRepositoryImpl(db *gorm.DB) *productPriceRepositoryImpl {
+	return &productPriceRepositoryImpl{
+		Db: db,
+	}
+}
+
+const (
+	SchedulePending  = "pending"
+	ScheduleApplied  = "applied"
+	ScheduleCanceled = "canceled"
+)
+
+var (
+	ErrScheduleNotFound   = errors.New("price schedule not found")
+	ErrScheduleNotPending = errors.New("price schedule is not pending")
+)
+
+func (p *productPriceRepositoryImpl) CreateSchedule(ctx context.Context, schedule *entity.ProductPriceSchedule) (*entity.ProductPriceSchedule, error) {
+	schedule.Status = SchedulePending
+
+	if err := p.Db.WithContext(ctx).Omit("Product").Create(schedule).Error; err != nil {
+		return nil, fmt.Errorf("product price repo: create schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (p *productPriceRepositoryImpl) FindSchedules(ctx context.Context, productId uint, status string) ([]*entity.ProductPriceSchedule, error) {
+	var schedules []*entity.ProductPriceSchedule
+
+	query := p.Db.WithContext(ctx).Where("product_id = ?", productId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("starts_at, id").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("product price repo: find schedules: %w", err)
+	}
+
+	return schedules, nil
+}
+
+func (p *productPriceRepositoryImpl) CancelSchedule(ctx context.Context, productId, id uint) (*entity.ProductPriceSchedule, error) {
+	schedule := entity.ProductPriceSchedule{}
+
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND product_id = ?", id, productId).First(&schedule).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrScheduleNotFound
+			}
+			return err
+		}
+
+		if schedule.Status != SchedulePending {
+			return ErrScheduleNotPending
+		}
+
+		return tx.Model(&schedule).Update("status", ScheduleCanceled).Error
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrScheduleNotFound) || errors.Is(err, ErrScheduleNotPending) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product price repo: cancel schedule: %w", err)
+	}
+
+	return &schedule, nil
+}
+
+func (p *productPriceRepositoryImpl) FindHistory(ctx context.Context, productId uint) ([]*entity.ProductPriceHistory, error) {
+	var history []*entity.ProductPriceHistory
+
+	if err := p.Db.WithContext(ctx).Where("product_id = ?", productId).
+		Order("created_at, id").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("product price repo: find history: %w", err)
+	}
+
+	return history, nil
+}
+
+// ApplyDue sets the price of every pending schedule that has started, oldest
+// first so the latest one wins, and returns the changed product ids. Each
+// schedule is applied in its own transaction and is locked, so a second
+// instance of the scheduler skips what is already applied.
+func (p *productPriceRepositoryImpl) ApplyDue(ctx context.Context, now time.Time) ([]uint, error) {
+	var due []*entity.ProductPriceSchedule
+
+	if err := p.Db.WithContext(ctx).Where("status = ? AND starts_at <= ?", SchedulePending, now).
+		Order("starts_at, id").Find(&due).Error; err != nil {
+		return nil, fmt.Errorf("product price repo: find due: %w", err)
+	}
+
+	//a schedule that keeps failing stays pending and must not hold back the
+	//ones after it, the failures are returned together
+	var changed []uint
+	var errs []error
+	for _, v := range due {
+		applied, err := p.apply(ctx, v.ID, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("product price repo: apply schedule %d: %w", v.ID, err))
+			continue
+		}
+
+		if applied {
+			changed = append(changed, v.ProductID)
+		}
+	}
+
+	return changed, errors.Join(errs...)
+}
+
+func (p *productPriceRepositoryImpl) apply(ctx context.Context, id uint, now time.Time) (bool, error) {
+	applied := false
+
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		schedule := entity.ProductPriceSchedule{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&schedule, id).Error; err != nil {
+			return fmt.Errorf("lock schedule: %w", err)
+		}
+
+		if schedule.Status != SchedulePending {
+			return nil
+		}
+
+		product := entity.Product{}
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, schedule.ProductID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return tx.Model(&schedule).Updates(map[string]interface{}{
+				"status": ScheduleCanceled,
+				"note":   "product was deleted",
+			}).Error
+		}
+		if err != nil {
+			return fmt.Errorf("lock product: %w", err)
+		}
+
+		if err := recordPrice(tx, product.ID, product.Price, schedule.Price, PriceScheduled, &schedule.ID); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&product).Update("price", schedule.Price).Error; err != nil {
+			return fmt.Errorf("update price: %w", err)
+		}
+
+		applied = true
+		return tx.Model(&schedule).Updates(map[string]interface{}{
+			"status":     ScheduleApplied,
+			"applied_at": now,
+		}).Error
+	})
+
+	return applied, err
+}
@@ -6,12 +6,25 @@ import (
 )
 
 type ProductRepository interface {
-	Create(ctx context.Context, product *entity.Product) (*entity.Product, error)
+	Create(ctx context.Context, product *entity.Product, move *entity.StockMovement) (*entity.Product, error)
 	Update(ctx context.Context, product *entity.Product) (*entity.Product, error)
 	Delete(ctx context.Context, id uint) error
 	FindById(ctx context.Context, id uint) (*entity.Product, error)
-	FindAll(ctx context.Context, page, pageSize int, search string) ([]*entity.Product, int64, error)
-	AddStock(ctx context.Context, id uint, stock int) (*entity.Product, error)
-	ReduceStock(ctx context.Context, id uint, stock int) (*entity.Product, error)
-	UpdateImage(ctx context.Context, id uint, img string) (*entity.Product, error)
+	FindByIds(ctx context.Context, ids []uint) ([]*entity.Product, error)
+	FindAll(ctx context.Context, page, pageSize int, filter *entity.ProductFilter) ([]*entity.Product, int64, error)
+	Facets(ctx context.Context, filter *entity.ProductFilter) (*entity.ProductFacets, error)
+	AddStock(ctx context.Context, id, variantId, inventoryId uint, stock int, move *entity.StockMovement) (*entity.Product, error)
+	ReduceStock(ctx context.Context, id, variantId, inventoryId uint, stock int, move *entity.StockMovement) (*entity.Product, error)
+	AddImage(ctx context.Context, image *entity.ProductImage) (*entity.Product, error)
+	ReorderImages(ctx context.Context, productId uint, imageIds []uint) (*entity.Product, error)
+	SetPrimaryImage(ctx context.Context, productId, imageId uint) (*entity.Product, error)
+	DeleteImage(ctx context.Context, productId, imageId uint) (*entity.ProductImage, error)
+	FindImage(ctx context.Context, productId, imageId uint) (*entity.ProductImage, error)
+	ImageFileNames(ctx context.Context) ([]string, error)
+	SaveOptions(ctx context.Context, productId uint, options []entity.ProductOption) (*entity.Product, error)
+	CreateVariant(ctx context.Context, variant *entity.ProductVariant, move *entity.StockMovement) (*entity.Product, error)
+	UpdateVariant(ctx context.Context, variant *entity.ProductVariant) (*entity.Product, error)
+	DeleteVariant(ctx context.Context, productId, variantId uint, move *entity.StockMovement) (*entity.Product, error)
+	Upsert(ctx context.Context, product *entity.Product, move *entity.StockMovement) (bool, error)
+	Export(ctx context.Context, batchSize int, fn func([]*entity.Product) error) error
 }
@@ -5,8 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"simple-toko/entity"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type productRepositoryImpl struct {
@@ -19,12 +23,42 @@ func NewProductRepositoryImpl(db *gorm.DB) *productRepositoryImpl {
 	}
 }
 
-func (p *productRepositoryImpl) Create(ctx context.Context, product *entity.Product) (*entity.Product, error) {
-	if err := p.Db.WithContext(ctx).Create(product).Error; err != nil {
+var (
+	ErrImageNotFound     = errors.New("product image not found")
+	ErrInvalidImageOrder = errors.New("image order must list every image of the product once")
+	ErrSkuDeleted        = errors.New("sku belongs to a deleted product")
+	ErrVariantStock      = errors.New("product has variants, stock is set per variant")
+	ErrProductStockLeft  = errors.New("product still holds stock of its own, empty it before the first variant")
+)
+
+func (p *productRepositoryImpl) Create(ctx context.Context, product *entity.Product, move *entity.StockMovement) (*entity.Product, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkProductSku(tx, product.SKU, 0); err != nil {
+			return err
+		}
+
+		//only link the categories, never upsert them from the product
+		if err := tx.Omit("Categories.*").Create(product).Error; err != nil {
+			return err
+		}
+
+		//the opening stock sits at the home location
+		move.RefID = product.ID
+		if err := addStock(tx, product.ID, 0, product.InventoryID, product.Stock, move); err != nil {
+			return err
+		}
+
+		return recordPrice(tx, product.ID, 0, product.Price, PriceCreated, nil)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrSkuExists) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("product repo: create: %w", err)
 	}
 
-	if err := p.Db.WithContext(ctx).Preload("Inventory").First(product, product.ID).Error; err != nil {
+	if err := p.Db.WithContext(ctx).Preload("Inventory").Preload("Categories").Preload("Options").Preload("Variants").Preload("Images").Preload("Stocks.Inventory").First(product, product.ID).Error; err != nil {
 		return nil, fmt.Errorf("product repo: preload create: %w", err)
 	}
 
@@ -38,15 +72,45 @@ func (p *productRepositoryImpl) Update(ctx context.Context, product *entity.Prod
 		Price:       product.Price,
 		Description: product.Description,
 	}
+	categories := product.Categories
+	sku := product.SKU
+
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := checkProductSku(tx, sku, product.ID); err != nil {
+			return err
+		}
+
+		if err := tx.First(product, product.ID).Error; err != nil {
+			return err
+		}
 
-	if err := p.Db.WithContext(ctx).First(product, product.ID).Updates(data).Error; err != nil {
+		if err := recordPrice(tx, product.ID, product.Price, data.Price, PriceManual, nil); err != nil {
+			return err
+		}
+
+		if err := tx.Model(product).Updates(data).Error; err != nil {
+			return err
+		}
+
+		//written on its own so a nil sku clears the column
+		if err := tx.Model(product).Update("sku", sku).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(product).Omit("Categories.*").Association("Categories").Replace(categories)
+	})
+
+	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrorIdNotFound
 		}
+		if errors.Is(err, ErrSkuExists) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("product repo: update: %w", err)
 	}
 
-	if err := p.Db.WithContext(ctx).Preload("Inventory").First(product, product.ID).Error; err != nil {
+	if err := p.Db.WithContext(ctx).Preload("Inventory").Preload("Categories").Preload("Options").Preload("Variants").Preload("Images").Preload("Stocks.Inventory").First(product, product.ID).Error; err != nil {
 		return nil, fmt.Errorf("product repo: preload update: %w", err)
 	}
 
@@ -54,15 +118,25 @@ func (p *productRepositoryImpl) Update(ctx context.Context, product *entity.Prod
 }
 
 func (p *productRepositoryImpl) Delete(ctx context.Context, id uint) error {
-	data := entity.Product{}
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&entity.Product{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	result := p.Db.WithContext(ctx).Delete(&data, id)
-	if result.Error != nil {
-		return fmt.Errorf("product repo: delete: %w", result.Error)
-	}
+		if result.RowsAffected == 0 {
+			return ErrorIdNotFound
+		}
+
+		//the files are removed by the caller, the rows would only point at nothing
+		return tx.Where("product_id = ?", id).Delete(&entity.ProductImage{}).Error
+	})
 
-	if result.RowsAffected == 0 {
-		return ErrorIdNotFound
+	if err != nil {
+		if errors.Is(err, ErrorIdNotFound) {
+			return err
+		}
+		return fmt.Errorf("product repo: delete: %w", err)
 	}
 
 	return nil
@@ -71,7 +145,7 @@ func (p *productRepositoryImpl) Delete(ctx context.Context, id uint) error {
 func (p *productRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.Product, error) {
 	product := entity.Product{}
 
-	if err := p.Db.WithContext(ctx).Preload("Inventory").First(&product, id).Error; err != nil {
+	if err := p.Db.WithContext(ctx).Preload("Inventory").Preload("Categories").Preload("Options").Preload("Variants").Preload("Images").Preload("Stocks.Inventory").First(&product, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrorIdNotFound
 		}
@@ -81,23 +155,85 @@ func (p *productRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.
 	return &product, nil
 }
 
-func (p *productRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, search string) ([]*entity.Product, int64, error) {
+func (p *productRepositoryImpl) FindByIds(ctx context.Context, ids []uint) ([]*entity.Product, error) {
 	var product []*entity.Product
-	var totalItems int64
 
-	query := p.Db.WithContext(ctx).Model(&entity.Product{})
+	if len(ids) == 0 {
+		return product, nil
+	}
 
-	if search != ""{
-		query = query.Where("name LIKE ?", "%"+search+"%")
+	if err := p.Db.WithContext(ctx).Preload("Inventory").Preload("Categories").Preload("Options").Preload("Variants").Preload("Images").Preload("Stocks.Inventory").Where("id IN ?", ids).Find(&product).Error; err != nil {
+		return nil, fmt.Errorf("product repo: find by ids: %w", err)
 	}
 
-	if err := query.Count(&totalItems).Error; err != nil {
+	return product, nil
+}
+
+const (
+	SortRelevance   = "relevance"
+	SortNewest      = "newest"
+	SortPriceAsc    = "price_asc"
+	SortPriceDesc   = "price_desc"
+	SortBestSelling = "best_selling"
+)
+
+// PriceBuckets are the upper bounds of the price facet, the last bucket has
+// no upper bound.
+var PriceBuckets = []float64{50000, 100000, 250000, 500000, 1000000}
+
+// minSearchToken is the innodb_ft_min_token_size default, shorter words are
+// not in the fulltext index.
+const minSearchToken = 3
+
+func (p *productRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, filter *entity.ProductFilter) ([]*entity.Product, int64, error) {
+	var product []*entity.Product
+	var totalItems int64
+
+	if err := p.filterProducts(ctx, filter).Count(&totalItems).Error; err != nil {
 		return nil, 0, err
 	}
 
+	query := p.filterProducts(ctx, filter)
+
+	sort := filter.Sort
+	if filter.Search != "" {
+		//relevance is also the tie breaker of the other sorts
+		expr, fulltext := p.fulltextQuery(filter.Search)
+		if fulltext {
+			query = query.Select("products.*, MATCH(products.name, products.description) AGAINST (? IN BOOLEAN MODE) AS relevance", expr)
+		} else {
+			query = query.Select("products.*, CASE WHEN products.name LIKE ? THEN 2 ELSE 1 END AS relevance", "%"+filter.Search+"%")
+		}
+
+		if sort == "" {
+			sort = SortRelevance
+		}
+	} else if sort == SortRelevance {
+		sort = ""
+	}
+
+	switch sort {
+	case SortRelevance:
+		query = query.Order("relevance DESC")
+	case SortNewest:
+		query = query.Order("products.created_at DESC")
+	case SortPriceAsc:
+		query = query.Order("products.price ASC")
+	case SortPriceDesc:
+		query = query.Order("products.price DESC")
+	case SortBestSelling:
+		//sold like the top product report, only confirmed orders count
+		query = query.Order("(SELECT COALESCE(SUM(op.qty), 0) FROM order_products op JOIN orders o ON op.order_id = o.id " +
+			"WHERE op.product_id = products.id AND o.status_order = '" + Confirmed + "') DESC")
+	}
+
+	if filter.Search != "" && sort != SortRelevance {
+		query = query.Order("relevance DESC")
+	}
+
 	offset := (page - 1) * pageSize
 
-	if err := query.Preload("Inventory").Limit(pageSize).
+	if err := query.Order("products.id").Preload("Inventory").Preload("Categories").Preload("Options").Preload("Variants").Preload("Images").Preload("Stocks.Inventory").Limit(pageSize).
 		Offset(offset).Find(&product).Error; err != nil {
 		return nil, 0, err
 	}
@@ -105,87 +241,826 @@ func (p *productRepositoryImpl) FindAll(ctx context.Context, page, pageSize int,
 	return product, totalItems, nil
 }
 
-func (p *productRepositoryImpl) AddStock(ctx context.Context, id uint, stock int) (*entity.Product, error) {
+// Facets counts the filtered products per category, per inventory location
+// and per price bucket, without paging.
+func (p *productRepositoryImpl) Facets(ctx context.Context, filter *entity.ProductFilter) (*entity.ProductFacets, error) {
+	facets := entity.ProductFacets{}
 
-	if stock <= 0 {
-		return nil, ErrorValidation
+	ids := p.filterProducts(ctx, filter).Select("products.id")
+
+	if err := p.Db.WithContext(ctx).Table("product_categories AS pc").
+		Select("c.id AS id, c.name AS name, COUNT(DISTINCT pc.product_id) AS total").
+		Joins("JOIN categories c ON pc.category_id = c.id AND c.deleted_at IS NULL").
+		Where("pc.product_id IN (?)", ids).Group("c.id, c.name").Order("total DESC, c.name").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, fmt.Errorf("product repo: category facets: %w", err)
 	}
 
-	data := map[string]interface{}{
-		"stock": gorm.Expr("stock + ?", stock),
+	if err := p.filterProducts(ctx, filter).
+		Select("inventories.id AS id, inventories.location AS name, COUNT(DISTINCT products.id) AS total").
+		Joins("JOIN product_stocks ON product_stocks.product_id = products.id AND product_stocks.quantity > 0").
+		Joins("JOIN inventories ON product_stocks.inventory_id = inventories.id AND inventories.deleted_at IS NULL").
+		Group("inventories.id, inventories.location").Order("total DESC, inventories.location").
+		Scan(&facets.Inventories).Error; err != nil {
+		return nil, fmt.Errorf("product repo: inventory facets: %w", err)
 	}
 
-	result := p.Db.WithContext(ctx).Model(&entity.Product{}).Where("id = ?", id).Updates(data)
-	if result.Error != nil {
-		return nil, fmt.Errorf("product repo: add stock: %w", result.Error)
+	bucket := "CASE"
+	var vars []interface{}
+	for i, v := range PriceBuckets {
+		bucket += fmt.Sprintf(" WHEN products.price < ? THEN %d", i)
+		vars = append(vars, v)
 	}
+	bucket += fmt.Sprintf(" ELSE %d END", len(PriceBuckets))
+
+	var prices []struct {
+		Bucket int
+		Total  int64
+	}
+
+	if err := p.filterProducts(ctx, filter).Select(bucket+" AS bucket, COUNT(*) AS total", vars...).
+		Group("bucket").Order("bucket").Scan(&prices).Error; err != nil {
+		return nil, fmt.Errorf("product repo: price facets: %w", err)
+	}
+
+	for _, v := range prices {
+		price := entity.PriceFacet{Total: v.Total}
+		if v.Bucket > 0 {
+			price.Min = PriceBuckets[v.Bucket-1]
+		}
+		if v.Bucket < len(PriceBuckets) {
+			max := PriceBuckets[v.Bucket]
+			price.Max = &max
+		}
+
+		facets.Prices = append(facets.Prices, price)
+	}
+
+	return &facets, nil
+}
 
-	if result.RowsAffected == 0 {
-		return nil, ErrorIdNotFound
+// filterProducts applies every filter but no order or paging, so the same
+// conditions serve the page, the count and the facets.
+func (p *productRepositoryImpl) filterProducts(ctx context.Context, filter *entity.ProductFilter) *gorm.DB {
+	query := p.Db.WithContext(ctx).Model(&entity.Product{})
+
+	if filter.Search != "" {
+		if expr, fulltext := p.fulltextQuery(filter.Search); fulltext {
+			query = query.Where("MATCH(products.name, products.description) AGAINST (? IN BOOLEAN MODE)", expr)
+		} else {
+			like := "%" + filter.Search + "%"
+			query = query.Where("(products.name LIKE ? OR products.description LIKE ?)", like, like)
+		}
+	}
+
+	if len(filter.CategoryIDs) > 0 {
+		query = query.Where("products.id IN (?)", p.Db.Table("product_categories").Select("product_id").
+			Where("category_id IN ?", filter.CategoryIDs))
+	}
+
+	if filter.InventoryID != 0 {
+		query = query.Where("EXISTS (SELECT 1 FROM product_stocks ps WHERE ps.product_id = products.id AND ps.inventory_id = ? AND ps.quantity > 0)",
+			filter.InventoryID)
+	}
+
+	if filter.MinPrice != nil {
+		query = query.Where("products.price >= ?", *filter.MinPrice)
+	}
+
+	if filter.MaxPrice != nil {
+		query = query.Where("products.price <= ?", *filter.MaxPrice)
+	}
+
+	if filter.InStock {
+		query = query.Where("products.stock > 0")
+	}
+
+	return query
+}
+
+// fulltextQuery turns the search into a boolean mode query where every word
+// must match as a prefix. Other drivers, or a search made only of words too
+// short for the index, fall back to LIKE.
+func (p *productRepositoryImpl) fulltextQuery(search string) (string, bool) {
+	if p.Db.Dialector.Name() != "mysql" {
+		return "", false
+	}
+
+	words := strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var terms []string
+	for _, v := range words {
+		if utf8.RuneCountInString(v) < minSearchToken {
+			continue
+		}
+		terms = append(terms, "+"+v+"*")
+	}
+
+	if len(terms) == 0 {
+		return "", false
+	}
+
+	return strings.Join(terms, " "), true
+}
+
+func (p *productRepositoryImpl) AddStock(ctx context.Context, id, variantId, inventoryId uint, stock int, move *entity.StockMovement) (*entity.Product, error) {
+
+	if stock <= 0 {
+		return nil, ErrorValidation
+	}
+
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&entity.Product{}, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrorIdNotFound
+			}
+			return fmt.Errorf("find product: %w", err)
+		}
+
+		if err := checkVariant(tx, id, variantId); err != nil {
+			return err
+		}
+
+		inventoryId, err := checkInventory(tx, id, inventoryId)
+		if err != nil {
+			return err
+		}
+
+		return addStock(tx, id, variantId, inventoryId, stock, move)
+	})
+
+	if err != nil {
+		if isStockErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: add stock: %w", err)
 	}
 
 	var newProd entity.Product
-	if err := p.Db.WithContext(ctx).Preload("Inventory").First(&newProd, id).Error; err != nil {
+	if err := p.Db.WithContext(ctx).Preload("Inventory").Preload("Categories").Preload("Options").Preload("Variants").Preload("Images").Preload("Stocks.Inventory").First(&newProd, id).Error; err != nil {
 		return nil, fmt.Errorf("product repo: preload add stock: %w", err)
 	}
 
 	return &newProd, nil
 }
 
-func (p *productRepositoryImpl) ReduceStock(ctx context.Context, id uint, stock int) (*entity.Product, error) {
+func (p *productRepositoryImpl) ReduceStock(ctx context.Context, id, variantId, inventoryId uint, stock int, move *entity.StockMovement) (*entity.Product, error) {
 	if stock <= 0 {
 		return nil, ErrorValidation
 	}
 
-	data := map[string]interface{}{
-		"stock": gorm.Expr("stock - ?", stock),
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&entity.Product{}, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrorIdNotFound
+			}
+			return fmt.Errorf("find product: %w", err)
+		}
+
+		if err := checkVariant(tx, id, variantId); err != nil {
+			return err
+		}
+
+		inventoryId, err := checkInventory(tx, id, inventoryId)
+		if err != nil {
+			return err
+		}
+
+		return reduceStock(tx, id, variantId, inventoryId, stock, move)
+	})
+
+	if err != nil {
+		if isStockErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: reduce stock: %w", err)
 	}
 
-	var currentStock int
-	if err := p.Db.WithContext(ctx).Model(&entity.Product{}).Select("stock").Where("id = ?", id).Scan(&currentStock).Error; err != nil {
-		return nil, fmt.Errorf("product repo: get stock: %w", err)
+	var newProd entity.Product
+	if err := p.Db.WithContext(ctx).Preload("Inventory").Preload("Categories").Preload("Options").Preload("Variants").Preload("Images").Preload("Stocks.Inventory").First(&newProd, id).Error; err != nil {
+		return nil, fmt.Errorf("product repo: preload reduce stock: %w", err)
 	}
 
-	if currentStock < stock {
-		return nil, ErrNotEnoughStock
+	return &newProd, nil
+}
+
+// SaveOptions replaces the option names of the product, the values stay on the
+// variants.
+func (p *productRepositoryImpl) SaveOptions(ctx context.Context, productId uint, options []entity.ProductOption) (*entity.Product, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productId).Delete(&entity.ProductOption{}).Error; err != nil {
+			return fmt.Errorf("delete options: %w", err)
+		}
+
+		if len(options) == 0 {
+			return nil
+		}
+
+		for i := range options {
+			options[i].ProductID = productId
+		}
+
+		if err := tx.Create(&options).Error; err != nil {
+			return fmt.Errorf("create options: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("product repo: save options: %w", err)
 	}
 
-	result := p.Db.WithContext(ctx).Model(&entity.Product{}).Where("id = ? AND stock >= ?", id, stock).Updates(data)
-	if result.Error != nil {
-		return nil, fmt.Errorf("product repo: reduce stock: %w", result.Error)
+	return p.FindById(ctx, productId)
+}
+
+func (p *productRepositoryImpl) CreateVariant(ctx context.Context, variant *entity.ProductVariant, move *entity.StockMovement) (*entity.Product, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := p.checkSku(tx, variant.SKU, 0); err != nil {
+			return err
+		}
+
+		if err := checkProductStockLeft(tx, variant.ProductID); err != nil {
+			return err
+		}
+
+		if err := tx.Create(variant).Error; err != nil {
+			return fmt.Errorf("create variant: %w", err)
+		}
+
+		productPrice, err := findProductPrice(tx, variant.ProductID)
+		if err != nil {
+			return err
+		}
+
+		price := variant.EffectivePrice(productPrice)
+		if err := recordVariantPrice(tx, variant.ProductID, variant.ID, 0, price, PriceCreated); err != nil {
+			return err
+		}
+
+		//once the product has variants its own stock rows no longer count, they
+		//are empty at this point
+		if err := clearStock(tx, variant.ProductID, 0, move); err != nil {
+			return err
+		}
+
+		return addStock(tx, variant.ProductID, variant.ID, 0, variant.Stock, move)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrSkuExists) || errors.Is(err, ErrProductStockLeft) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: create variant: %w", err)
 	}
 
-	if result.RowsAffected == 0 {
-		return nil, ErrorIdNotFound
+	return p.FindById(ctx, variant.ProductID)
+}
+
+// UpdateVariant saves the sku, option values and price, stock only moves
+// through AddStock and ReduceStock.
+func (p *productRepositoryImpl) UpdateVariant(ctx context.Context, variant *entity.ProductVariant) (*entity.Product, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := p.checkSku(tx, variant.SKU, variant.ID); err != nil {
+			return err
+		}
+
+		var current entity.ProductVariant
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, price").
+			Where("id = ? AND product_id = ?", variant.ID, variant.ProductID).Take(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrVariantNotFound
+			}
+			return fmt.Errorf("lock variant: %w", err)
+		}
+
+		productPrice, err := findProductPrice(tx, variant.ProductID)
+		if err != nil {
+			return err
+		}
+
+		if err := recordVariantPrice(tx, variant.ProductID, variant.ID,
+			current.EffectivePrice(productPrice), variant.EffectivePrice(productPrice), PriceManual); err != nil {
+			return err
+		}
+
+		data := map[string]interface{}{
+			"sku":     variant.SKU,
+			"option1": variant.Option1,
+			"option2": variant.Option2,
+			"option3": variant.Option3,
+			"price":   variant.Price,
+		}
+
+		result := tx.Model(&entity.ProductVariant{}).Where("id = ? AND product_id = ?", variant.ID, variant.ProductID).Updates(data)
+		if result.Error != nil {
+			return fmt.Errorf("update variant: %w", result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrVariantNotFound
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrSkuExists) || errors.Is(err, ErrVariantNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: update variant: %w", err)
 	}
 
-	var newProd entity.Product
-	if err := p.Db.WithContext(ctx).Preload("Inventory").First(&newProd, id).Error; err != nil {
-		return nil, fmt.Errorf("product repo: preload reduce stock: %w", err)
+	return p.FindById(ctx, variant.ProductID)
+}
+
+// checkProductStockLeft refuses the first variant of a product while the
+// product itself still has stock or open orders hold its stock, that stock
+// would have no row to go back to once the product has variants.
+func checkProductStockLeft(tx *gorm.DB, productId uint) error {
+	var variants int64
+	if err := tx.Model(&entity.ProductVariant{}).Where("product_id = ?", productId).Count(&variants).Error; err != nil {
+		return fmt.Errorf("count variant: %w", err)
 	}
 
-	return &newProd, nil
+	if variants > 0 {
+		return nil
+	}
+
+	var rows []entity.ProductStock
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND variant_id = 0", productId).Find(&rows).Error; err != nil {
+		return fmt.Errorf("lock stock: %w", err)
+	}
+
+	stock := 0
+	for _, v := range rows {
+		stock += v.Quantity
+	}
+
+	if stock > 0 {
+		return fmt.Errorf("%w: %d in stock", ErrProductStockLeft, stock)
+	}
+
+	var ordered int64
+	if err := tx.Model(&entity.OrderProduct{}).
+		Joins("JOIN orders ON orders.id = order_products.order_id AND orders.deleted_at IS NULL").
+		Where("order_products.product_id = ? AND order_products.variant_id = 0", productId).
+		Where("orders.status_order <> ? AND orders.status_delivery <> ?", Canceled, Delivered).
+		Select("COALESCE(SUM(order_products.qty), 0)").Scan(&ordered).Error; err != nil {
+		return fmt.Errorf("count ordered stock: %w", err)
+	}
+
+	if ordered > 0 {
+		return fmt.Errorf("%w: %d on open orders", ErrProductStockLeft, ordered)
+	}
+
+	return nil
 }
 
-func (p *productRepositoryImpl) UpdateImage(ctx context.Context, id uint, img string) (*entity.Product, error) {
-	product := entity.Product{}
+// findProductPrice is the price of the product a variant without its own
+// price sells at.
+func findProductPrice(tx *gorm.DB, productId uint) (float64, error) {
+	var product entity.Product
+	if err := tx.Select("id, price").First(&product, productId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrorIdNotFound
+		}
+		return 0, fmt.Errorf("find product price: %w", err)
+	}
+
+	return product.Price, nil
+}
+
+func (p *productRepositoryImpl) DeleteVariant(ctx context.Context, productId, variantId uint, move *entity.StockMovement) (*entity.Product, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND product_id = ?", variantId, productId).Delete(&entity.ProductVariant{})
+		if result.Error != nil {
+			return fmt.Errorf("delete variant: %w", result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrVariantNotFound
+		}
+
+		if err := clearStock(tx, productId, variantId, move); err != nil {
+			return err
+		}
+
+		return syncProductStock(tx, productId)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrVariantNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: delete variant: %w", err)
+	}
+
+	return p.FindById(ctx, productId)
+}
+
+func (p *productRepositoryImpl) checkSku(tx *gorm.DB, sku string, exceptId uint) error {
+	var total int64
+	if err := tx.Model(&entity.ProductVariant{}).Where("sku = ? AND id <> ?", sku, exceptId).Count(&total).Error; err != nil {
+		return fmt.Errorf("check sku: %w", err)
+	}
+
+	if total > 0 {
+		return ErrSkuExists
+	}
+
+	if err := tx.Model(&entity.Product{}).Unscoped().Where("sku = ?", sku).Count(&total).Error; err != nil {
+		return fmt.Errorf("check product sku: %w", err)
+	}
+
+	if total > 0 {
+		return ErrSkuExists
+	}
+
+	return nil
+}
+
+// checkProductSku keeps a product sku apart from every other product, deleted
+// ones included since they keep their row, and from every variant sku.
+func checkProductSku(tx *gorm.DB, sku *string, exceptId uint) error {
+	if sku == nil {
+		return nil
+	}
+
+	var total int64
+	if err := tx.Model(&entity.Product{}).Unscoped().Where("sku = ? AND id <> ?", *sku, exceptId).Count(&total).Error; err != nil {
+		return fmt.Errorf("check product sku: %w", err)
+	}
+
+	if total > 0 {
+		return ErrSkuExists
+	}
+
+	if err := tx.Model(&entity.ProductVariant{}).Where("sku = ?", *sku).Count(&total).Error; err != nil {
+		return fmt.Errorf("check sku: %w", err)
+	}
+
+	if total > 0 {
+		return ErrSkuExists
+	}
+
+	return nil
+}
+
+func isStockErr(err error) bool {
+	return errors.Is(err, ErrorIdNotFound) || errors.Is(err, ErrNotEnoughStock) || errors.Is(err, ErrInventoryNotFound) ||
+		errors.Is(err, ErrVariantNotFound) || errors.Is(err, ErrVariantRequired)
+}
+
+// AddImage appends the image to the gallery, the first image of a product is
+// always the primary one. A product that still has only the old single image
+// gets it moved into the gallery first.
+func (p *productRepositoryImpl) AddImage(ctx context.Context, image *entity.ProductImage) (*entity.Product, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product entity.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, image").First(&product, image.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrorIdNotFound
+			}
+			return fmt.Errorf("lock product: %w", err)
+		}
+
+		var images []entity.ProductImage
+		if err := tx.Where("product_id = ?", image.ProductID).Find(&images).Error; err != nil {
+			return fmt.Errorf("find images: %w", err)
+		}
+
+		if len(images) == 0 && product.Image != "" {
+			legacy := entity.ProductImage{
+				ProductID: product.ID,
+				FileName:  product.Image,
+				Position:  1,
+				IsPrimary: true,
+			}
+			if err := tx.Create(&legacy).Error; err != nil {
+				return fmt.Errorf("move old image: %w", err)
+			}
+			images = append(images, legacy)
+		}
+
+		image.Position = 1
+		for _, v := range images {
+			if v.Position >= image.Position {
+				image.Position = v.Position + 1
+			}
+		}
+
+		if len(images) == 0 {
+			image.IsPrimary = true
+		}
+
+		if image.IsPrimary {
+			if err := tx.Model(&entity.ProductImage{}).Where("product_id = ?", image.ProductID).
+				Update("is_primary", false).Error; err != nil {
+				return fmt.Errorf("reset primary: %w", err)
+			}
+		}
+
+		if err := tx.Create(image).Error; err != nil {
+			return fmt.Errorf("create image: %w", err)
+		}
+
+		return p.syncPrimaryImage(tx, image.ProductID)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrorIdNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: add image: %w", err)
+	}
+
+	return p.FindById(ctx, image.ProductID)
+}
+
+// ReorderImages takes every image id of the product in the new order.
+func (p *productRepositoryImpl) ReorderImages(ctx context.Context, productId uint, imageIds []uint) (*entity.Product, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&entity.ProductImage{}).Where("product_id = ?", productId).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("find images: %w", err)
+		}
+
+		if len(ids) != len(imageIds) {
+			return ErrInvalidImageOrder
+		}
+
+		owned := map[uint]bool{}
+		for _, v := range ids {
+			owned[v] = true
+		}
+
+		for i, v := range imageIds {
+			if !owned[v] {
+				return ErrInvalidImageOrder
+			}
+			delete(owned, v)
+
+			if err := tx.Model(&entity.ProductImage{}).Where("id = ?", v).Update("position", i+1).Error; err != nil {
+				return fmt.Errorf("update position: %w", err)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrInvalidImageOrder) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: reorder images: %w", err)
+	}
+
+	return p.FindById(ctx, productId)
+}
 
-	if err := p.Db.WithContext(ctx).First(&product, id).Error; err != nil {
+func (p *productRepositoryImpl) SetPrimaryImage(ctx context.Context, productId, imageId uint) (*entity.Product, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := p.findImage(tx, productId, imageId); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&entity.ProductImage{}).Where("product_id = ?", productId).
+			Update("is_primary", gorm.Expr("id = ?", imageId)).Error; err != nil {
+			return fmt.Errorf("set primary: %w", err)
+		}
+
+		return p.syncPrimaryImage(tx, productId)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: set primary image: %w", err)
+	}
+
+	return p.FindById(ctx, productId)
+}
+
+// DeleteImage returns the removed row so the caller can delete its files, the
+// next image in order becomes primary when the primary one goes.
+func (p *productRepositoryImpl) DeleteImage(ctx context.Context, productId, imageId uint) (*entity.ProductImage, error) {
+	var image *entity.ProductImage
+
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		image, err = p.findImage(tx, productId, imageId)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Delete(image).Error; err != nil {
+			return fmt.Errorf("delete image: %w", err)
+		}
+
+		return p.syncPrimaryImage(tx, productId)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: delete image: %w", err)
+	}
+
+	return image, nil
+}
+
+func (p *productRepositoryImpl) FindImage(ctx context.Context, productId, imageId uint) (*entity.ProductImage, error) {
+	image, err := p.findImage(p.Db.WithContext(ctx), productId, imageId)
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product repo: find image: %w", err)
+	}
+
+	return image, nil
+}
+
+// ImageFileNames lists every file name still used by a gallery image or by the
+// image column of a product that is not deleted.
+func (p *productRepositoryImpl) ImageFileNames(ctx context.Context) ([]string, error) {
+	var names []string
+	if err := p.Db.WithContext(ctx).Model(&entity.ProductImage{}).Distinct().Pluck("file_name", &names).Error; err != nil {
+		return nil, fmt.Errorf("product repo: image file names: %w", err)
+	}
+
+	var legacy []string
+	if err := p.Db.WithContext(ctx).Model(&entity.Product{}).Where("image IS NOT NULL AND image <> ''").
+		Pluck("image", &legacy).Error; err != nil {
+		return nil, fmt.Errorf("product repo: product image names: %w", err)
+	}
+
+	return append(names, legacy...), nil
+}
+
+func (p *productRepositoryImpl) findImage(tx *gorm.DB, productId, imageId uint) (*entity.ProductImage, error) {
+	var image entity.ProductImage
+	if err := tx.Where("product_id = ?", productId).First(&image, imageId).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrorIdNotFound
+			return nil, ErrImageNotFound
 		}
-		return nil, fmt.Errorf("product repo: find id image: %w", err)
+		return nil, err
 	}
 
-	result := p.Db.WithContext(ctx).Model(&product).Update("image", img)
-	if result.Error != nil {
-		return nil, fmt.Errorf("product repo: update image: %w", result.Error)
+	return &image, nil
+}
+
+// syncPrimaryImage leaves exactly one primary image, falling back to the
+// first one in order, and copies its file name into products.image so the
+// single image field keeps working.
+func (p *productRepositoryImpl) syncPrimaryImage(tx *gorm.DB, productId uint) error {
+	var images []entity.ProductImage
+	if err := tx.Where("product_id = ?", productId).Order("position ASC, id ASC").Find(&images).Error; err != nil {
+		return fmt.Errorf("find images: %w", err)
+	}
+
+	var primary *entity.ProductImage
+	for i := range images {
+		if images[i].IsPrimary {
+			primary = &images[i]
+			break
+		}
+	}
+
+	if primary == nil && len(images) > 0 {
+		primary = &images[0]
+	}
+
+	var fileName interface{}
+	if primary != nil {
+		if err := tx.Model(&entity.ProductImage{}).Where("product_id = ?", productId).
+			Update("is_primary", gorm.Expr("id = ?", primary.ID)).Error; err != nil {
+			return fmt.Errorf("update primary: %w", err)
+		}
+		fileName = primary.FileName
+	}
+
+	if err := tx.Unscoped().Model(&entity.Product{}).Where("id = ?", productId).
+		UpdateColumn("image", fileName).Error; err != nil {
+		return fmt.Errorf("update product image: %w", err)
 	}
 
-	var data entity.Product
-	if err := p.Db.WithContext(ctx).Preload("Inventory").First(&data, id).Error; err != nil {
-		return nil, fmt.Errorf("product repo: preload update img: %w", err)
+	return nil
+}
+
+// Upsert saves a product by its sku and reports whether it was created. The
+// categories are only replaced when the slice is not nil, the stock of a
+// product with variants stays the sum of the variants.
+func (p *productRepositoryImpl) Upsert(ctx context.Context, product *entity.Product, move *entity.StockMovement) (bool, error) {
+	if product.SKU == nil {
+		return false, fmt.Errorf("product repo: upsert: sku is required")
 	}
 
-	return &data, nil
+	created := false
+
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current entity.Product
+		err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("sku = ?", *product.SKU).First(&current).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("lock product: %w", err)
+		}
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := checkProductSku(tx, product.SKU, 0); err != nil {
+				return err
+			}
+
+			created = true
+			if err := tx.Omit("Categories.*").Create(product).Error; err != nil {
+				return err
+			}
+
+			if err := addStock(tx, product.ID, 0, product.InventoryID, product.Stock, move); err != nil {
+				return err
+			}
+
+			return recordPrice(tx, product.ID, 0, product.Price, PriceCreated, nil)
+		}
+
+		if current.DeletedAt.Valid {
+			return ErrSkuDeleted
+		}
+
+		data := map[string]interface{}{
+			"inventory_id": product.InventoryID,
+			"name":         product.Name,
+			"price":        product.Price,
+			"description":  product.Description,
+		}
+
+		var variants int64
+		if err := tx.Model(&entity.ProductVariant{}).Where("product_id = ?", current.ID).Count(&variants).Error; err != nil {
+			return fmt.Errorf("count variant: %w", err)
+		}
+
+		//the stock column is the quantity at the location of the row
+		if variants == 0 {
+			if err := setStock(tx, current.ID, product.InventoryID, product.Stock, move); err != nil {
+				return err
+			}
+		} else {
+			var located int64
+			if err := tx.Model(&entity.ProductStock{}).Select("COALESCE(SUM(quantity), 0)").
+				Where("product_id = ? AND inventory_id = ?", current.ID, product.InventoryID).Scan(&located).Error; err != nil {
+				return fmt.Errorf("find location stock: %w", err)
+			}
+
+			if int64(product.Stock) != located {
+				return ErrVariantStock
+			}
+		}
+
+		if err := recordPrice(tx, current.ID, current.Price, product.Price, PriceImport, nil); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&current).Updates(data).Error; err != nil {
+			return fmt.Errorf("update product: %w", err)
+		}
+
+		product.ID = current.ID
+
+		if product.Categories == nil {
+			return nil
+		}
+
+		return tx.Model(&current).Omit("Categories.*").Association("Categories").Replace(product.Categories)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrSkuExists) || errors.Is(err, ErrSkuDeleted) || errors.Is(err, ErrVariantStock) {
+			return false, err
+		}
+		return false, fmt.Errorf("product repo: upsert: %w", err)
+	}
+
+	return created, nil
+}
+
+// Export hands every product to fn in batches ordered by id, so the whole
+// catalog is never held in memory.
+func (p *productRepositoryImpl) Export(ctx context.Context, batchSize int, fn func([]*entity.Product) error) error {
+	var products []*entity.Product
+
+	result := p.Db.WithContext(ctx).Preload("Inventory").Preload("Categories").Preload("Stocks").
+		FindInBatches(&products, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(products)
+		})
+
+	if result.Error != nil {
+		return fmt.Errorf("product repo: export: %w", result.Error)
+	}
+
+	return nil
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type PurchaseOrderRepository interface {
+	Create(ctx context.Context, order *entity.PurchaseOrder) (*entity.PurchaseOrder, error)
+	Update(ctx context.Context, order *entity.PurchaseOrder) (*entity.PurchaseOrder, error)
+	FindById(ctx context.Context, id uint) (*entity.PurchaseOrder, error)
+	FindAll(ctx context.Context, page, pageSize int, filter *entity.PurchaseOrderFilter) ([]*entity.PurchaseOrder, int64, error)
+	Approve(ctx context.Context, id, userId uint) (*entity.PurchaseOrder, error)
+	Receive(ctx context.Context, id uint, receipt *entity.GoodsReceipt, close bool) (*entity.PurchaseOrder, error)
+	Cancel(ctx context.Context, id uint) (*entity.PurchaseOrder, error)
+}
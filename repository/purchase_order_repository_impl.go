@@ -0,0 +1,408 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type purchaseOrderRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewPurchaseOrderRepositoryImpl(db *gorm.DB) *purchaseOrderRepositoryImpl {
+	return &purchaseOrderRepositoryImpl{
+		Db: db,
+	}
+}
+
+const (
+	PurchaseDraft    = "draft"
+	PurchaseApproved = "approved"
+	PurchasePartial  = "partially_received"
+	PurchaseReceived = "received"
+	PurchaseCanceled = "canceled"
+
+	PurchaseOpen = "open" //filter only, approved or partially received
+)
+
+var (
+	ErrPurchaseNotFound      = errors.New("purchase order not found")
+	ErrPurchaseStatusChanged = errors.New("purchase order status has changed")
+	ErrPurchaseLineNotFound  = errors.New("purchase order line not found")
+	ErrPurchaseQtyExceeded   = errors.New("received qty exceeds the qty left on the order line")
+	ErrPurchaseNotReceived   = errors.New("nothing was received on the purchase order, cancel it instead")
+)
+
+func (p *purchaseOrderRepositoryImpl) Create(ctx context.Context, order *entity.PurchaseOrder) (*entity.PurchaseOrder, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := p.checkOrder(tx, order); err != nil {
+			return err
+		}
+
+		code, err := nextDocumentNumber(tx, PurchaseOrderCodePrefix, time.Now())
+		if err != nil {
+			return err
+		}
+
+		order.Code = code
+		order.Status = PurchaseDraft
+		order.Total = purchaseTotal(order.Lines)
+
+		if err := tx.Omit("Supplier", "Inventory", "User", "Receipts", "Lines.Product").Create(order).Error; err != nil {
+			return fmt.Errorf("create purchase order: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isPurchaseErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order repo: create: %w", err)
+	}
+
+	return p.FindById(ctx, order.ID)
+}
+
+// Update replaces the supplier, location, expected date, note and lines of a
+// draft.
+func (p *purchaseOrderRepositoryImpl) Update(ctx context.Context, order *entity.PurchaseOrder) (*entity.PurchaseOrder, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := p.lockOrder(tx, order.ID, PurchaseDraft); err != nil {
+			return err
+		}
+
+		if err := p.checkOrder(tx, order); err != nil {
+			return err
+		}
+
+		data := map[string]interface{}{
+			"supplier_id":  order.SupplierID,
+			"inventory_id": order.InventoryID,
+			"expected_at":  order.ExpectedAt,
+			"note":         order.Note,
+			"total":        purchaseTotal(order.Lines),
+		}
+
+		if err := tx.Model(&entity.PurchaseOrder{}).Where("id = ?", order.ID).Updates(data).Error; err != nil {
+			return fmt.Errorf("update purchase order: %w", err)
+		}
+
+		if err := tx.Where("purchase_order_id = ?", order.ID).Delete(&entity.PurchaseOrderLine{}).Error; err != nil {
+			return fmt.Errorf("delete lines: %w", err)
+		}
+
+		for i := range order.Lines {
+			order.Lines[i].ID = 0
+			order.Lines[i].PurchaseOrderID = order.ID
+		}
+
+		if err := tx.Omit("Product").Create(&order.Lines).Error; err != nil {
+			return fmt.Errorf("create lines: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isPurchaseErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order repo: update: %w", err)
+	}
+
+	return p.FindById(ctx, order.ID)
+}
+
+func (p *purchaseOrderRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.PurchaseOrder, error) {
+	order := entity.PurchaseOrder{}
+
+	err := p.preload(p.Db.WithContext(ctx)).
+		Preload("Receipts", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Receipts.User").
+		Preload("Receipts.Lines").
+		First(&order, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPurchaseNotFound
+		}
+		return nil, fmt.Errorf("purchase order repo: find by id: %w", err)
+	}
+
+	return &order, nil
+}
+
+// FindAll lists the orders, newest first. Open orders are listed by the date
+// they are expected, the ones without a date last.
+func (p *purchaseOrderRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, filter *entity.PurchaseOrderFilter) ([]*entity.PurchaseOrder, int64, error) {
+	query := func() *gorm.DB {
+		query := p.Db.WithContext(ctx).Model(&entity.PurchaseOrder{})
+
+		switch filter.Status {
+		case "":
+		case PurchaseOpen:
+			query = query.Where("status IN ?", []string{PurchaseApproved, PurchasePartial})
+		default:
+			query = query.Where("status = ?", filter.Status)
+		}
+
+		if filter.SupplierID != 0 {
+			query = query.Where("supplier_id = ?", filter.SupplierID)
+		}
+
+		if filter.InventoryID != 0 {
+			query = query.Where("inventory_id = ?", filter.InventoryID)
+		}
+
+		return query
+	}
+
+	var totalItems int64
+	if err := query().Count(&totalItems).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "created_at DESC, id DESC"
+	if filter.Status == PurchaseOpen {
+		order = "expected_at IS NULL, expected_at, id"
+	}
+
+	offset := (page - 1) * pageSize
+
+	var orders []*entity.PurchaseOrder
+	if err := p.preload(query()).Order(order).Limit(pageSize).Offset(offset).Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return orders, totalItems, nil
+}
+
+func (p *purchaseOrderRepositoryImpl) Approve(ctx context.Context, id, userId uint) (*entity.PurchaseOrder, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := p.lockOrder(tx, id, PurchaseDraft)
+		if err != nil {
+			return err
+		}
+
+		data := map[string]interface{}{
+			"status":      PurchaseApproved,
+			"approved_by": userId,
+			"approved_at": time.Now(),
+		}
+
+		if err := tx.Model(order).Updates(data).Error; err != nil {
+			return fmt.Errorf("approve purchase order: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isPurchaseErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order repo: approve: %w", err)
+	}
+
+	return p.FindById(ctx, id)
+}
+
+// Receive books a delivery, the received qty is added to the location of the
+// order at the unit cost of the receipt line. The order is received once
+// every line is complete, or when close is set and the rest is not coming.
+func (p *purchaseOrderRepositoryImpl) Receive(ctx context.Context, id uint, receipt *entity.GoodsReceipt, close bool) (*entity.PurchaseOrder, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := p.lockOrder(tx, id, PurchaseApproved, PurchasePartial)
+		if err != nil {
+			return err
+		}
+
+		lines := map[uint]*entity.PurchaseOrderLine{}
+		for i := range order.Lines {
+			lines[order.Lines[i].ID] = &order.Lines[i]
+		}
+
+		if len(receipt.Lines) > 0 {
+			code, err := nextDocumentNumber(tx, GoodsReceiptCodePrefix, time.Now())
+			if err != nil {
+				return err
+			}
+
+			receipt.Code = code
+			receipt.PurchaseOrderID = order.ID
+		}
+
+		move := &entity.StockMovement{Reason: StockPurchase, RefType: RefPurchaseOrder, RefID: order.ID, UserID: &receipt.UserID, Note: receipt.Code}
+		for i := range receipt.Lines {
+			v := &receipt.Lines[i]
+
+			line, ok := lines[v.PurchaseOrderLineID]
+			if !ok {
+				return ErrPurchaseLineNotFound
+			}
+
+			if line.ReceivedQty+v.Qty > line.Qty {
+				return ErrPurchaseQtyExceeded
+			}
+
+			if err := checkVariant(tx, line.ProductID, line.VariantID); err != nil {
+				return err
+			}
+
+			if err := addStock(tx, line.ProductID, line.VariantID, order.InventoryID, v.Qty, move); err != nil {
+				return err
+			}
+
+			line.ReceivedQty += v.Qty
+			if err := tx.Model(line).Update("received_qty", line.ReceivedQty).Error; err != nil {
+				return fmt.Errorf("update line: %w", err)
+			}
+
+			v.ProductID = line.ProductID
+			v.VariantID = line.VariantID
+		}
+
+		if len(receipt.Lines) > 0 {
+			if err := tx.Omit("User").Create(receipt).Error; err != nil {
+				return fmt.Errorf("create goods receipt: %w", err)
+			}
+		}
+
+		status := PurchaseReceived
+		received := 0
+		for _, v := range order.Lines {
+			if v.ReceivedQty < v.Qty && !close {
+				status = PurchasePartial
+			}
+			received += v.ReceivedQty
+		}
+
+		//closing an order that never got anything is a cancel, not a receipt
+		if received == 0 {
+			return ErrPurchaseNotReceived
+		}
+
+		if err := tx.Model(order).Update("status", status).Error; err != nil {
+			return fmt.Errorf("update status: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isPurchaseErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order repo: receive: %w", err)
+	}
+
+	return p.FindById(ctx, id)
+}
+
+// Cancel drops an order nothing was received on yet, a partly received order
+// is closed through Receive.
+func (p *purchaseOrderRepositoryImpl) Cancel(ctx context.Context, id uint) (*entity.PurchaseOrder, error) {
+	err := p.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		order, err := p.lockOrder(tx, id, PurchaseDraft, PurchaseApproved)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(order).Update("status", PurchaseCanceled).Error
+	})
+
+	if err != nil {
+		if isPurchaseErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order repo: cancel: %w", err)
+	}
+
+	return p.FindById(ctx, id)
+}
+
+// lockOrder holds the order until the transaction ends and makes sure it is
+// still in one of statuses.
+func (p *purchaseOrderRepositoryImpl) lockOrder(tx *gorm.DB, id uint, statuses ...string) (*entity.PurchaseOrder, error) {
+	var order entity.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&order, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPurchaseNotFound
+		}
+		return nil, fmt.Errorf("lock purchase order: %w", err)
+	}
+
+	for _, v := range statuses {
+		if order.Status == v {
+			return &order, nil
+		}
+	}
+
+	return nil, ErrPurchaseStatusChanged
+}
+
+// checkOrder makes sure the supplier and the location exist and every line
+// names a product, or one of its variants, that can hold stock.
+func (p *purchaseOrderRepositoryImpl) checkOrder(tx *gorm.DB, order *entity.PurchaseOrder) error {
+	if err := tx.Select("id").First(&entity.Supplier{}, order.SupplierID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSupplierNotFound
+		}
+		return fmt.Errorf("find supplier: %w", err)
+	}
+
+	if err := tx.Select("id").First(&entity.Inventory{}, order.InventoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInventoryNotFound
+		}
+		return fmt.Errorf("find inventory: %w", err)
+	}
+
+	for _, v := range order.Lines {
+		if err := tx.Select("id").First(&entity.Product{}, v.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return fmt.Errorf("find product: %w", err)
+		}
+
+		if err := checkVariant(tx, v.ProductID, v.VariantID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *purchaseOrderRepositoryImpl) preload(db *gorm.DB) *gorm.DB {
+	return db.Preload("Supplier", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Inventory", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("User").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Lines.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Lines.Product.Variants")
+}
+
+func purchaseTotal(lines []entity.PurchaseOrderLine) float64 {
+	total := 0.0
+	for _, v := range lines {
+		total += float64(v.Qty) * v.UnitCost
+	}
+
+	return total
+}
+
+func isPurchaseErr(err error) bool {
+	return errors.Is(err, ErrPurchaseNotFound) || errors.Is(err, ErrPurchaseStatusChanged) ||
+		errors.Is(err, ErrPurchaseLineNotFound) || errors.Is(err, ErrPurchaseQtyExceeded) ||
+		errors.Is(err, ErrPurchaseNotReceived) || errors.Is(err, ErrSupplierNotFound) || errors.Is(err, ErrInventoryNotFound) ||
+		errors.Is(err, ErrProductNotFound) || errors.Is(err, ErrVariantNotFound) ||
+		errors.Is(err, ErrVariantRequired)
+}
@@ -21,17 +21,28 @@ func (r *reportRepositoryImpl) MonthlySales(ctx context.Context, page, pageSize
 	var data []*entity.SalesReport
 	var totalItems int64
 
-	if err := r.Db.WithContext(ctx).Table("orders AS o").Where("o.status_order = ?", Confirmed).
-		Select("COUNT(DISTINCT DATE_FORMAT(created_at, '%Y-%m'))").Scan(&totalItems).Error; err != nil {
+	//sales are counted in the month of the order, refunds in the month they are paid
+	sales := r.Db.Table("order_products AS op").
+		Select("DATE_FORMAT(o.created_at, '%Y-%m') AS month, op.qty AS total_qty, op.qty * op.unit_price AS total_sales, 0 AS returned_qty, 0 AS total_refund").
+		Joins("JOIN orders o ON op.order_id = o.id").Where("o.status_order = ?", Confirmed)
+
+	refunds := r.Db.Table("refunds AS rf").
+		Select("DATE_FORMAT(rf.created_at, '%Y-%m') AS month, 0 AS total_qty, 0 AS total_sales, rf.qty AS returned_qty, rf.amount AS total_refund")
+
+	rows := r.Db.Raw("? UNION ALL ?", sales, refunds)
+
+	if err := r.Db.WithContext(ctx).Table("(?) AS t", rows).
+		Select("COUNT(DISTINCT t.month)").Scan(&totalItems).Error; err != nil {
 		return nil, 0, err
 	}
 
 	offset := (page - 1) * pageSize
 
-	err := r.Db.WithContext(ctx).Table("order_products AS op").
-		Select("DATE_FORMAT(o.created_at, '%Y-%m') AS month, SUM(op.qty) AS total_qty, SUM(op.qty * op.unit_price) AS total_sales").
-		Joins("JOIN orders o ON op.order_id = o.id").Where("o.status_order = ?", Confirmed).Limit(pageSize).
-		Offset(offset).Group("DATE_FORMAT(o.created_at, '%Y-%m')").Order("month").Scan(&data).Error
+	err := r.Db.WithContext(ctx).Table("(?) AS t", rows).
+		Select("t.month AS month, SUM(t.total_qty) AS total_qty, SUM(t.total_sales) AS total_sales, " +
+			"SUM(t.returned_qty) AS returned_qty, SUM(t.total_refund) AS total_refund, " +
+			"SUM(t.total_sales) - SUM(t.total_refund) AS net_sales").
+		Group("t.month").Order("month").Limit(pageSize).Offset(offset).Scan(&data).Error
 
 	if err != nil {
 		return nil, 0, err
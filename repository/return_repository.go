@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type ReturnRepository interface {
+	Create(ctx context.Context, ret *entity.ReturnRequest) (*entity.ReturnRequest, error)
+	FindById(ctx context.Context, id uint) (*entity.ReturnRequest, error)
+	FindAll(ctx context.Context, page, pageSize int, filter *entity.ReturnFilter) ([]*entity.ReturnRequest, int64, error)
+	FindOrderProduct(ctx context.Context, id uint) (*entity.OrderProduct, error)
+	ChangeStatus(ctx context.Context, ret *entity.ReturnRequest, status, note string) (*entity.ReturnRequest, error)
+	Receive(ctx context.Context, ret *entity.ReturnRequest, restock bool, userId uint) (*entity.ReturnRequest, error)
+	Refund(ctx context.Context, ret *entity.ReturnRequest, refund *entity.Refund) (*entity.ReturnRequest, error)
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type returnRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewReturnRepositoryImpl(db *gorm.DB) *returnRepositoryImpl {
+	return &returnRepositoryImpl{
+		Db: db,
+	}
+}
+
+const (
+	ReturnRequested string = "requested"
+	ReturnApproved  string = "approved"
+	ReturnRejected  string = "rejected"
+	ReturnReceived  string = "received"
+	ReturnRefunded  string = "refunded"
+)
+
+var (
+	ErrReturnNotFound      = errors.New("return request not found")
+	ErrReturnQtyExceeded   = errors.New("return qty exceeds the qty left on the order line")
+	ErrReturnStatusChanged = errors.New("return status has changed")
+)
+
+func (r *returnRepositoryImpl) Create(ctx context.Context, ret *entity.ReturnRequest) (*entity.ReturnRequest, error) {
+	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		//lock the order line so two requests cannot return the same qty
+		var item entity.OrderProduct
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, ret.OrderProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderItemNotFound
+			}
+			return fmt.Errorf("lock order item: %w", err)
+		}
+
+		var returned int64
+		if err := tx.Model(&entity.ReturnRequest{}).Select("COALESCE(SUM(qty), 0)").
+			Where("order_product_id = ? AND status <> ?", item.ID, ReturnRejected).
+			Scan(&returned).Error; err != nil {
+			return fmt.Errorf("sum returned qty: %w", err)
+		}
+
+		if int64(ret.Qty)+returned > int64(item.Qty) {
+			return ErrReturnQtyExceeded
+		}
+
+		ret.OrderID = item.OrderID
+		ret.Status = ReturnRequested
+
+		if err := tx.Create(ret).Error; err != nil {
+			return fmt.Errorf("create return: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrOrderItemNotFound) || errors.Is(err, ErrReturnQtyExceeded) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("return repo: create: %w", err)
+	}
+
+	return r.FindById(ctx, ret.ID)
+}
+
+func (r *returnRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.ReturnRequest, error) {
+	ret := entity.ReturnRequest{}
+
+	if err := r.Db.WithContext(ctx).Preload("Order").Preload("OrderProduct").
+		Preload("OrderProduct.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("User").Preload("Refund").First(&ret, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReturnNotFound
+		}
+		return nil, fmt.Errorf("return repo: find by id: %w", err)
+	}
+
+	return &ret, nil
+}
+
+func (r *returnRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, filter *entity.ReturnFilter) ([]*entity.ReturnRequest, int64, error) {
+	var ret []*entity.ReturnRequest
+	var totalItems int64
+
+	query := r.Db.WithContext(ctx).Model(&entity.ReturnRequest{})
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+
+	if err := query.Order("created_at DESC, id DESC").Limit(pageSize).Offset(offset).
+		Preload("Order").Preload("OrderProduct").
+		Preload("OrderProduct.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("User").Preload("Refund").Find(&ret).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return ret, totalItems, nil
+}
+
+func (r *returnRepositoryImpl) FindOrderProduct(ctx context.Context, id uint) (*entity.OrderProduct, error) {
+	item := entity.OrderProduct{}
+
+	if err := r.Db.WithContext(ctx).Preload("Order").First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderItemNotFound
+		}
+		return nil, fmt.Errorf("return repo: find order item: %w", err)
+	}
+
+	return &item, nil
+}
+
+func (r *returnRepositoryImpl) ChangeStatus(ctx context.Context, ret *entity.ReturnRequest, status, note string) (*entity.ReturnRequest, error) {
+	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return r.updateStatus(tx, ret, status, map[string]interface{}{"admin_note": note})
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrReturnStatusChanged) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("return repo: change status: %w", err)
+	}
+
+	return r.FindById(ctx, ret.ID)
+}
+
+func (r *returnRepositoryImpl) Receive(ctx context.Context, ret *entity.ReturnRequest, restock bool, userId uint) (*entity.ReturnRequest, error) {
+	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.updateStatus(tx, ret, ReturnReceived, map[string]interface{}{"restock": restock}); err != nil {
+			return err
+		}
+
+		if !restock {
+			return nil
+		}
+
+		move := entity.StockMovement{Reason: StockReturn, RefType: RefReturn, RefID: ret.ID, UserID: &userId}
+		if err := addStock(tx, ret.OrderProduct.ProductID, ret.OrderProduct.VariantID, ret.OrderProduct.InventoryID, ret.Qty, &move); err != nil {
+			return fmt.Errorf("restock: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrReturnStatusChanged) || errors.Is(err, ErrStockNotRestorable) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("return repo: receive: %w", err)
+	}
+
+	return r.FindById(ctx, ret.ID)
+}
+
+func (r *returnRepositoryImpl) Refund(ctx context.Context, ret *entity.ReturnRequest, refund *entity.Refund) (*entity.ReturnRequest, error) {
+	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.updateStatus(tx, ret, ReturnRefunded, nil); err != nil {
+			return err
+		}
+
+		refund.ReturnRequestID = ret.ID
+		refund.OrderID = ret.OrderID
+
+		if err := tx.Create(refund).Error; err != nil {
+			return fmt.Errorf("create refund: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrReturnStatusChanged) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("return repo: refund: %w", err)
+	}
+
+	return r.FindById(ctx, ret.ID)
+}
+
+// updateStatus only moves the return when it is still in the status the
+// caller read, so two admins acting at once cannot both apply a transition.
+func (r *returnRepositoryImpl) updateStatus(tx *gorm.DB, ret *entity.ReturnRequest, status string, columns map[string]interface{}) error {
+	updates := map[string]interface{}{"status": status}
+	for k, v := range columns {
+		updates[k] = v
+	}
+
+	result := tx.Model(&entity.ReturnRequest{}).Where("id = ? AND status = ?", ret.ID, ret.Status).Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("update status: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrReturnStatusChanged
+	}
+
+	return nil
+}
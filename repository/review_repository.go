@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type ReviewRepository interface {
+	Create(ctx context.Context, review *entity.Review) (*entity.Review, error)
+	FindById(ctx context.Context, id uint) (*entity.Review, error)
+	FindAll(ctx context.Context, page, pageSize int, filter *entity.ReviewFilter) ([]*entity.Review, int64, error)
+	FindOrderProduct(ctx context.Context, id uint) (*entity.OrderProduct, error)
+	SetHidden(ctx context.Context, id uint, hidden bool, reason string) (*entity.Review, error)
+}
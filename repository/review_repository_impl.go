@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type reviewRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewReviewRepositoryImpl(db *gorm.DB) *reviewRepositoryImpl {
+	return &reviewRepositoryImpl{
+		Db: db,
+	}
+}
+
+var (
+	ErrReviewNotFound = errors.New("review not found")
+	ErrReviewExists   = errors.New("order line already has a review")
+)
+
+func (r *reviewRepositoryImpl) Create(ctx context.Context, review *entity.Review) (*entity.Review, error) {
+	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		//lock the order line so a double submit cannot add two reviews
+		var item entity.OrderProduct
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, review.OrderProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrOrderItemNotFound
+			}
+			return fmt.Errorf("lock order item: %w", err)
+		}
+
+		var total int64
+		if err := tx.Model(&entity.Review{}).Where("order_product_id = ?", item.ID).Count(&total).Error; err != nil {
+			return fmt.Errorf("count review: %w", err)
+		}
+
+		if total > 0 {
+			return ErrReviewExists
+		}
+
+		review.ProductID = item.ProductID
+
+		if err := tx.Create(review).Error; err != nil {
+			return fmt.Errorf("create review: %w", err)
+		}
+
+		return syncProductRating(tx, item.ProductID)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrOrderItemNotFound) || errors.Is(err, ErrReviewExists) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("review repo: create: %w", err)
+	}
+
+	return r.FindById(ctx, review.ID)
+}
+
+func (r *reviewRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.Review, error) {
+	review := entity.Review{}
+
+	if err := r.preload(r.Db.WithContext(ctx)).First(&review, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("review repo: find by id: %w", err)
+	}
+
+	return &review, nil
+}
+
+func (r *reviewRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, filter *entity.ReviewFilter) ([]*entity.Review, int64, error) {
+	var review []*entity.Review
+	var totalItems int64
+
+	query := r.Db.WithContext(ctx).Model(&entity.Review{})
+
+	if filter.ProductID != 0 {
+		query = query.Where("product_id = ?", filter.ProductID)
+	}
+
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	if filter.Hidden != nil {
+		query = query.Where("hidden = ?", *filter.Hidden)
+	}
+
+	if err := query.Count(&totalItems).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+
+	if err := r.preload(query).Order("created_at DESC, id DESC").Limit(pageSize).Offset(offset).
+		Find(&review).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return review, totalItems, nil
+}
+
+func (r *reviewRepositoryImpl) FindOrderProduct(ctx context.Context, id uint) (*entity.OrderProduct, error) {
+	item := entity.OrderProduct{}
+
+	if err := r.Db.WithContext(ctx).Preload("Order").First(&item, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderItemNotFound
+		}
+		return nil, fmt.Errorf("review repo: find order item: %w", err)
+	}
+
+	return &item, nil
+}
+
+// SetHidden is the moderation of a review, the product rating is counted
+// again in the same transaction.
+func (r *reviewRepositoryImpl) SetHidden(ctx context.Context, id uint, hidden bool, reason string) (*entity.Review, error) {
+	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var review entity.Review
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&review, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReviewNotFound
+			}
+			return fmt.Errorf("lock review: %w", err)
+		}
+
+		if !hidden {
+			reason = ""
+		}
+
+		if err := tx.Model(&review).Updates(map[string]interface{}{"hidden": hidden, "hidden_reason": reason}).Error; err != nil {
+			return fmt.Errorf("update hidden: %w", err)
+		}
+
+		return syncProductRating(tx, review.ProductID)
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrReviewNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("review repo: set hidden: %w", err)
+	}
+
+	return r.FindById(ctx, id)
+}
+
+func (r *reviewRepositoryImpl) preload(db *gorm.DB) *gorm.DB {
+	return db.Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("OrderProduct").Preload("User").Preload("Images")
+}
+
+// syncProductRating keeps products.rating_avg and review_count equal to the
+// visible reviews, like products.stock follows the variants.
+func syncProductRating(tx *gorm.DB, productId uint) error {
+	if err := tx.Exec("UPDATE products SET "+
+		"rating_avg = (SELECT COALESCE(ROUND(AVG(rating), 2), 0) FROM reviews WHERE product_id = ? AND hidden = false), "+
+		"review_count = (SELECT COUNT(*) FROM reviews WHERE product_id = ? AND hidden = false) WHERE id = ?",
+		productId, productId, productId).Error; err != nil {
+		return fmt.Errorf("sync product rating: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stock is held per location in product_stocks, one row per product (variant
+// 0) or per variant and inventory. A product with variants keeps its stock on
+// the variants, product_variants.stock and products.stock are kept as sums so
+// listings and reports still read a single column.
+//
+// Every change of a location row goes through the helpers below in the same
+// transaction and writes a stock_movements row, so the ledger summed per row
+// is always the current quantity.
+
+const (
+	StockPurchase   = "purchase"
+	StockSale       = "sale"
+	StockAdjustment = "adjustment"
+	StockReturn     = "return"
+	StockCancel     = "cancel"
+	StockTransfer   = "transfer"
+)
+
+const (
+	RefOrder         = "order"
+	RefReturn        = "return"
+	RefImport        = "import"
+	RefProduct       = "product"
+	RefTransfer      = "transfer"
+	RefStocktake     = "stocktake"
+	RefPurchaseOrder = "purchase_order"
+)
+
+var (
+	ErrVariantNotFound   = errors.New("product variant not found")
+	ErrVariantRequired   = errors.New("product has variants, variant is required")
+	ErrSkuExists         = errors.New("sku already exists")
+	ErrInventoryNotFound = errors.New("inventory not found")
+
+	ErrStockNotRestorable = errors.New("stock cannot be put back, the variant was removed or the product has variants now")
+)
+
+// checkVariant makes sure the variant belongs to the product, a product with
+// variants cannot move stock without naming one.
+func checkVariant(tx *gorm.DB, productId, variantId uint) error {
+	var total int64
+
+	if variantId != 0 {
+		if err := tx.Model(&entity.ProductVariant{}).Where("id = ? AND product_id = ?", variantId, productId).
+			Count(&total).Error; err != nil {
+			return fmt.Errorf("find variant: %w", err)
+		}
+
+		if total == 0 {
+			return ErrVariantNotFound
+		}
+
+		return nil
+	}
+
+	if err := tx.Model(&entity.ProductVariant{}).Where("product_id = ?", productId).Count(&total).Error; err != nil {
+		return fmt.Errorf("count variant: %w", err)
+	}
+
+	if total > 0 {
+		return ErrVariantRequired
+	}
+
+	return nil
+}
+
+// checkInventory makes sure the location exists, 0 names the home location of
+// the product and is returned resolved.
+func checkInventory(tx *gorm.DB, productId, inventoryId uint) (uint, error) {
+	if inventoryId == 0 {
+		return homeInventory(tx, productId)
+	}
+
+	var total int64
+	if err := tx.Model(&entity.Inventory{}).Where("id = ?", inventoryId).Count(&total).Error; err != nil {
+		return 0, fmt.Errorf("find inventory: %w", err)
+	}
+
+	if total == 0 {
+		return 0, ErrInventoryNotFound
+	}
+
+	return inventoryId, nil
+}
+
+func homeInventory(tx *gorm.DB, productId uint) (uint, error) {
+	var product entity.Product
+	if err := tx.Unscoped().Select("id", "inventory_id").First(&product, productId).Error; err != nil {
+		return 0, fmt.Errorf("find home inventory: %w", err)
+	}
+
+	return product.InventoryID, nil
+}
+
+// lineInventory is the warehouse of an order line, the home location of the
+// product for lines older than the allocation.
+func lineInventory(tx *gorm.DB, item *entity.OrderProduct) (uint, error) {
+	if item.InventoryID != 0 {
+		return item.InventoryID, nil
+	}
+
+	return homeInventory(tx, item.ProductID)
+}
+
+// locationStock is the quantity of one location row, held until the
+// transaction ends. A missing row holds 0.
+func locationStock(tx *gorm.DB, productId, variantId, inventoryId uint) (int, error) {
+	var quantity []int
+	if err := tx.Model(&entity.ProductStock{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND variant_id = ? AND inventory_id = ?", productId, variantId, inventoryId).
+		Pluck("quantity", &quantity).Error; err != nil {
+		return 0, fmt.Errorf("find location stock: %w", err)
+	}
+
+	if len(quantity) == 0 {
+		return 0, nil
+	}
+
+	return quantity[0], nil
+}
+
+func reduceStock(tx *gorm.DB, productId, variantId, inventoryId uint, qty int, move *entity.StockMovement) error {
+	stock := tx.Model(&entity.ProductStock{}).
+		Where("product_id = ? AND variant_id = ? AND inventory_id = ? AND quantity >= ?", productId, variantId, inventoryId, qty).
+		UpdateColumn("quantity", gorm.Expr("quantity - ?", qty))
+	if stock.Error != nil {
+		return fmt.Errorf("reduce stock: %w", stock.Error)
+	}
+
+	if stock.RowsAffected == 0 {
+		return ErrNotEnoughStock
+	}
+
+	if err := recordMovement(tx, productId, variantId, inventoryId, -qty, move); err != nil {
+		return err
+	}
+
+	return syncStock(tx, productId, variantId)
+}
+
+// addStock also restores stock of soft deleted products. A variant that was
+// removed in the meantime, or stock of the product itself once it has
+// variants, has no row to go to and fails with ErrStockNotRestorable.
+// Inventory 0 is the home location of the product, used for order lines
+// older than the allocation.
+func addStock(tx *gorm.DB, productId, variantId, inventoryId uint, qty int, move *entity.StockMovement) error {
+	if err := checkVariant(tx, productId, variantId); err != nil {
+		if errors.Is(err, ErrVariantNotFound) || errors.Is(err, ErrVariantRequired) {
+			return fmt.Errorf("%w: %v", ErrStockNotRestorable, err)
+		}
+		return err
+	}
+
+	if inventoryId == 0 {
+		home, err := homeInventory(tx, productId)
+		if err != nil {
+			return err
+		}
+		inventoryId = home
+	}
+
+	row := entity.ProductStock{
+		ProductID:   productId,
+		VariantID:   variantId,
+		InventoryID: inventoryId,
+		Quantity:    qty,
+	}
+
+	if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity":   gorm.Expr("quantity + ?", qty),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&row).Error; err != nil {
+		return fmt.Errorf("add stock: %w", err)
+	}
+
+	if err := recordMovement(tx, productId, variantId, inventoryId, qty, move); err != nil {
+		return err
+	}
+
+	return syncStock(tx, productId, variantId)
+}
+
+// setStock overwrites the quantity of a product without variants at one
+// location, used by the import where the file holds the counted stock.
+func setStock(tx *gorm.DB, productId, inventoryId uint, qty int, move *entity.StockMovement) error {
+	var current []int
+	if err := tx.Model(&entity.ProductStock{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND variant_id = 0 AND inventory_id = ?", productId, inventoryId).
+		Pluck("quantity", &current).Error; err != nil {
+		return fmt.Errorf("lock stock: %w", err)
+	}
+
+	delta := qty
+	if len(current) > 0 {
+		delta -= current[0]
+	}
+
+	row := entity.ProductStock{
+		ProductID:   productId,
+		InventoryID: inventoryId,
+		Quantity:    qty,
+	}
+
+	if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity":   qty,
+			"updated_at": time.Now(),
+		}),
+	}).Create(&row).Error; err != nil {
+		return fmt.Errorf("set stock: %w", err)
+	}
+
+	if err := recordMovement(tx, productId, 0, inventoryId, delta, move); err != nil {
+		return err
+	}
+
+	return syncProductStock(tx, productId)
+}
+
+// allocateStock picks the warehouse a line is sent from and takes the stock
+// there. Addresses are free text, so a location named in the address counts
+// as nearest, then a warehouse the order already ships from, then the one
+// with the most stock. A line is never split between warehouses.
+func allocateStock(tx *gorm.DB, productId, variantId uint, qty int, address string, used map[uint]bool, move *entity.StockMovement) (uint, error) {
+	var candidates []struct {
+		InventoryID uint
+		Location    string
+		Quantity    int
+	}
+
+	if err := tx.Table("product_stocks").
+		Select("product_stocks.inventory_id, inventories.location, product_stocks.quantity").
+		Joins("JOIN inventories ON inventories.id = product_stocks.inventory_id AND inventories.deleted_at IS NULL").
+		Where("product_stocks.product_id = ? AND product_stocks.variant_id = ? AND product_stocks.quantity >= ?", productId, variantId, qty).
+		Scan(&candidates).Error; err != nil {
+		return 0, fmt.Errorf("find stock location: %w", err)
+	}
+
+	if len(candidates) == 0 {
+		return 0, ErrNotEnoughStock
+	}
+
+	address = strings.ToLower(address)
+	rank := func(location string, inventoryId uint) int {
+		switch {
+		case location != "" && strings.Contains(address, strings.ToLower(location)):
+			return 0
+		case used[inventoryId]:
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if ra, rb := rank(a.Location, a.InventoryID), rank(b.Location, b.InventoryID); ra != rb {
+			return ra < rb
+		}
+		if a.Quantity != b.Quantity {
+			return a.Quantity > b.Quantity
+		}
+		return a.InventoryID < b.InventoryID
+	})
+
+	//the read is not locked, a location emptied meanwhile fails its guarded
+	//update and the next one is tried
+	for _, v := range candidates {
+		err := reduceStock(tx, productId, variantId, v.InventoryID, qty, move)
+		if errors.Is(err, ErrNotEnoughStock) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		used[v.InventoryID] = true
+		return v.InventoryID, nil
+	}
+
+	return 0, ErrNotEnoughStock
+}
+
+// clearStock empties and removes the location rows of a product (variant 0)
+// or of one variant, the ledger gets the quantity taken out of every row.
+func clearStock(tx *gorm.DB, productId, variantId uint, move *entity.StockMovement) error {
+	var rows []entity.ProductStock
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND variant_id = ?", productId, variantId).Find(&rows).Error; err != nil {
+		return fmt.Errorf("lock stock: %w", err)
+	}
+
+	for _, v := range rows {
+		if v.Quantity == 0 {
+			continue
+		}
+
+		if err := tx.Model(&v).UpdateColumn("quantity", 0).Error; err != nil {
+			return fmt.Errorf("clear stock: %w", err)
+		}
+
+		if err := recordMovement(tx, productId, variantId, v.InventoryID, -v.Quantity, move); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("product_id = ? AND variant_id = ?", productId, variantId).Delete(&entity.ProductStock{}).Error; err != nil {
+		return fmt.Errorf("delete stock: %w", err)
+	}
+
+	return nil
+}
+
+// recordMovement writes one ledger row for a change that was just applied,
+// move only carries the reason, reference and actor.
+func recordMovement(tx *gorm.DB, productId, variantId, inventoryId uint, delta int, move *entity.StockMovement) error {
+	if delta == 0 {
+		return nil
+	}
+
+	var balance int
+	if err := tx.Model(&entity.ProductStock{}).Select("quantity").
+		Where("product_id = ? AND variant_id = ? AND inventory_id = ?", productId, variantId, inventoryId).
+		Scan(&balance).Error; err != nil {
+		return fmt.Errorf("find stock balance: %w", err)
+	}
+
+	movement := entity.StockMovement{
+		ProductID:   productId,
+		VariantID:   variantId,
+		InventoryID: inventoryId,
+		Delta:       delta,
+		Balance:     balance,
+		Reason:      move.Reason,
+		RefType:     move.RefType,
+		RefID:       move.RefID,
+		UserID:      move.UserID,
+		Note:        move.Note,
+	}
+
+	if err := tx.Omit(clause.Associations).Create(&movement).Error; err != nil {
+		return fmt.Errorf("create stock movement: %w", err)
+	}
+
+	return nil
+}
+
+// syncStock recounts the variant and the product from their locations, the
+// product only counts its variants once it has any.
+func syncStock(tx *gorm.DB, productId, variantId uint) error {
+	if variantId != 0 {
+		if err := tx.Exec("UPDATE product_variants SET stock = (SELECT COALESCE(SUM(quantity), 0) FROM product_stocks WHERE variant_id = ?) WHERE id = ?",
+			variantId, variantId).Error; err != nil {
+			return fmt.Errorf("sync variant stock: %w", err)
+		}
+	}
+
+	return syncProductStock(tx, productId)
+}
+
+func syncProductStock(tx *gorm.DB, productId uint) error {
+	if err := tx.Exec("UPDATE products SET stock = (SELECT COALESCE(SUM(quantity), 0) FROM product_stocks WHERE product_id = ? "+
+		"AND (variant_id <> 0 OR NOT EXISTS (SELECT 1 FROM product_variants WHERE product_id = ?))) WHERE id = ?",
+		productId, productId, productId).Error; err != nil {
+		return fmt.Errorf("sync product stock: %w", err)
+	}
+
+	return nil
+}
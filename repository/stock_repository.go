@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type StockRepository interface {
+	FindByProduct(ctx context.Context, productId uint) ([]*entity.ProductStock, error)
+	FindByInventory(ctx context.Context, inventoryId uint, page, pageSize int) ([]*entity.ProductStock, int64, error)
+	FindMovements(ctx context.Context, page, pageSize int, filter *entity.StockMovementFilter) ([]*entity.StockMovement, int64, error)
+	Reconcile(ctx context.Context) ([]entity.StockMismatch, error)
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+
+	"gorm.io/gorm"
+)
+
+type stockRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewStockRepositoryImpl(db *gorm.DB) *stockRepositoryImpl {
+	return &stockRepositoryImpl{
+		Db: db,
+	}
+}
+
+// FindByProduct lists every location row of the product, variants included.
+func (s *stockRepositoryImpl) FindByProduct(ctx context.Context, productId uint) ([]*entity.ProductStock, error) {
+	if err := s.Db.WithContext(ctx).Select("id").First(&entity.Product{}, productId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrorIdNotFound
+		}
+		return nil, fmt.Errorf("stock repo: find product: %w", err)
+	}
+
+	var stocks []*entity.ProductStock
+	if err := s.Db.WithContext(ctx).Preload("Inventory").Preload("Product.Variants").
+		Where("product_id = ?", productId).Order("inventory_id, variant_id").Find(&stocks).Error; err != nil {
+		return nil, fmt.Errorf("stock repo: find by product: %w", err)
+	}
+
+	return stocks, nil
+}
+
+// FindByInventory pages the stock held at one location, empty rows and
+// deleted products are left out.
+func (s *stockRepositoryImpl) FindByInventory(ctx context.Context, inventoryId uint, page, pageSize int) ([]*entity.ProductStock, int64, error) {
+	if err := s.Db.WithContext(ctx).First(&entity.Inventory{}, inventoryId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrorIdNotFound
+		}
+		return nil, 0, fmt.Errorf("stock repo: find inventory: %w", err)
+	}
+
+	query := func() *gorm.DB {
+		return s.Db.WithContext(ctx).Model(&entity.ProductStock{}).
+			Joins("JOIN products ON products.id = product_stocks.product_id AND products.deleted_at IS NULL").
+			Where("product_stocks.inventory_id = ? AND product_stocks.quantity > 0", inventoryId)
+	}
+
+	var totalItems int64
+	if err := query().Count(&totalItems).Error; err != nil {
+		return nil, 0, fmt.Errorf("stock repo: count by inventory: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+
+	var stocks []*entity.ProductStock
+	if err := query().Preload("Inventory").Preload("Product.Variants").
+		Order("product_stocks.product_id, product_stocks.variant_id").Limit(pageSize).Offset(offset).
+		Find(&stocks).Error; err != nil {
+		return nil, 0, fmt.Errorf("stock repo: find by inventory: %w", err)
+	}
+
+	return stocks, totalItems, nil
+}
+
+// FindMovements pages the ledger of a product, newest first.
+func (s *stockRepositoryImpl) FindMovements(ctx context.Context, page, pageSize int, filter *entity.StockMovementFilter) ([]*entity.StockMovement, int64, error) {
+	if err := s.Db.WithContext(ctx).Unscoped().Select("id").First(&entity.Product{}, filter.ProductID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, ErrorIdNotFound
+		}
+		return nil, 0, fmt.Errorf("stock repo: find product: %w", err)
+	}
+
+	query := func() *gorm.DB {
+		query := s.Db.WithContext(ctx).Model(&entity.StockMovement{}).Where("product_id = ?", filter.ProductID)
+
+		if filter.VariantID != nil {
+			query = query.Where("variant_id = ?", *filter.VariantID)
+		}
+
+		if filter.InventoryID != 0 {
+			query = query.Where("inventory_id = ?", filter.InventoryID)
+		}
+
+		if filter.Reason != "" {
+			query = query.Where("reason = ?", filter.Reason)
+		}
+
+		if filter.CreatedFrom != nil {
+			query = query.Where("created_at >= ?", *filter.CreatedFrom)
+		}
+
+		if filter.CreatedTo != nil {
+			query = query.Where("created_at < ?", *filter.CreatedTo)
+		}
+
+		return query
+	}
+
+	var totalItems int64
+	if err := query().Count(&totalItems).Error; err != nil {
+		return nil, 0, fmt.Errorf("stock repo: count movements: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+
+	var movements []*entity.StockMovement
+	if err := query().Preload("Inventory").Preload("User").Order("created_at DESC, id DESC").
+		Limit(pageSize).Offset(offset).Find(&movements).Error; err != nil {
+		return nil, 0, fmt.Errorf("stock repo: find movements: %w", err)
+	}
+
+	return movements, totalItems, nil
+}
+
+// Reconcile lists every location row whose quantity is not the sum of its
+// ledger, and every product whose stock is not the sum of its whole ledger.
+// Rows that were removed must have a ledger summing to zero.
+func (s *stockRepositoryImpl) Reconcile(ctx context.Context) ([]entity.StockMismatch, error) {
+	var locations []entity.StockMismatch
+	if err := s.Db.WithContext(ctx).Raw("SELECT k.product_id, k.variant_id, k.inventory_id, " +
+		"COALESCE(s.quantity, 0) AS quantity, COALESCE(m.ledger, 0) AS ledger " +
+		"FROM (SELECT product_id, variant_id, inventory_id FROM product_stocks " +
+		"UNION SELECT product_id, variant_id, inventory_id FROM stock_movements) k " +
+		"LEFT JOIN product_stocks s ON s.product_id = k.product_id AND s.variant_id = k.variant_id AND s.inventory_id = k.inventory_id " +
+		"LEFT JOIN (SELECT product_id, variant_id, inventory_id, SUM(delta) AS ledger FROM stock_movements " +
+		"GROUP BY product_id, variant_id, inventory_id) m " +
+		"ON m.product_id = k.product_id AND m.variant_id = k.variant_id AND m.inventory_id = k.inventory_id " +
+		"WHERE COALESCE(s.quantity, 0) <> COALESCE(m.ledger, 0) " +
+		"ORDER BY k.product_id, k.variant_id, k.inventory_id").Scan(&locations).Error; err != nil {
+		return nil, fmt.Errorf("stock repo: reconcile locations: %w", err)
+	}
+
+	var products []entity.StockMismatch
+	if err := s.Db.WithContext(ctx).Raw("SELECT p.id AS product_id, p.stock AS quantity, COALESCE(SUM(m.delta), 0) AS ledger " +
+		"FROM products p LEFT JOIN stock_movements m ON m.product_id = p.id " +
+		"GROUP BY p.id, p.stock HAVING p.stock <> COALESCE(SUM(m.delta), 0) " +
+		"ORDER BY p.id").Scan(&products).Error; err != nil {
+		return nil, fmt.Errorf("stock repo: reconcile products: %w", err)
+	}
+
+	return append(locations, products...), nil
+}
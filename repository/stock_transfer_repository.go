@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type StockTransferRepository interface {
+	Create(ctx context.Context, transfer *entity.StockTransfer) (*entity.StockTransfer, error)
+	Update(ctx context.Context, transfer *entity.StockTransfer) (*entity.StockTransfer, error)
+	FindById(ctx context.Context, id uint) (*entity.StockTransfer, error)
+	FindAll(ctx context.Context, page, pageSize int, filter *entity.StockTransferFilter) ([]*entity.StockTransfer, int64, error)
+	Ship(ctx context.Context, id, userId uint) (*entity.StockTransfer, error)
+	Receive(ctx context.Context, id, userId uint, receipts []entity.StockTransferReceipt, close bool) (*entity.StockTransfer, error)
+	Cancel(ctx context.Context, id uint) (*entity.StockTransfer, error)
+}
@@ -0,0 +1,381 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type stockTransferRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewStockTransferRepositoryImpl(db *gorm.DB) *stockTransferRepositoryImpl {
+	return &stockTransferRepositoryImpl{
+		Db: db,
+	}
+}
+
+const (
+	TransferDraft     = "draft"
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+	TransferCanceled  = "canceled"
+)
+
+var (
+	ErrTransferNotFound      = errors.New("stock transfer not found")
+	ErrTransferStatusChanged = errors.New("stock transfer status has changed")
+	ErrTransferLineNotFound  = errors.New("stock transfer line not found")
+	ErrTransferQtyExceeded   = errors.New("received qty exceeds the qty shipped on the line")
+)
+
+func (s *stockTransferRepositoryImpl) Create(ctx context.Context, transfer *entity.StockTransfer) (*entity.StockTransfer, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.checkTransfer(tx, transfer); err != nil {
+			return err
+		}
+
+		code, err := nextDocumentNumber(tx, TransferCodePrefix, time.Now())
+		if err != nil {
+			return err
+		}
+
+		transfer.Code = code
+		transfer.Status = TransferDraft
+
+		if err := tx.Omit("Lines.Product").Create(transfer).Error; err != nil {
+			return fmt.Errorf("create transfer: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isTransferErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer repo: create: %w", err)
+	}
+
+	return s.FindById(ctx, transfer.ID)
+}
+
+// Update replaces the locations, note and lines of a draft.
+func (s *stockTransferRepositoryImpl) Update(ctx context.Context, transfer *entity.StockTransfer) (*entity.StockTransfer, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.lockTransfer(tx, transfer.ID, TransferDraft); err != nil {
+			return err
+		}
+
+		if err := s.checkTransfer(tx, transfer); err != nil {
+			return err
+		}
+
+		data := map[string]interface{}{
+			"source_id":      transfer.SourceID,
+			"destination_id": transfer.DestinationID,
+			"note":           transfer.Note,
+		}
+
+		if err := tx.Model(&entity.StockTransfer{}).Where("id = ?", transfer.ID).Updates(data).Error; err != nil {
+			return fmt.Errorf("update transfer: %w", err)
+		}
+
+		if err := tx.Where("stock_transfer_id = ?", transfer.ID).Delete(&entity.StockTransferLine{}).Error; err != nil {
+			return fmt.Errorf("delete lines: %w", err)
+		}
+
+		for i := range transfer.Lines {
+			transfer.Lines[i].ID = 0
+			transfer.Lines[i].StockTransferID = transfer.ID
+		}
+
+		if err := tx.Omit("Product").Create(&transfer.Lines).Error; err != nil {
+			return fmt.Errorf("create lines: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isTransferErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer repo: update: %w", err)
+	}
+
+	return s.FindById(ctx, transfer.ID)
+}
+
+func (s *stockTransferRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.StockTransfer, error) {
+	transfer := entity.StockTransfer{}
+
+	if err := s.preload(s.Db.WithContext(ctx)).First(&transfer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("stock transfer repo: find by id: %w", err)
+	}
+
+	return &transfer, nil
+}
+
+func (s *stockTransferRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, filter *entity.StockTransferFilter) ([]*entity.StockTransfer, int64, error) {
+	query := func() *gorm.DB {
+		query := s.Db.WithContext(ctx).Model(&entity.StockTransfer{})
+
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+
+		if filter.InventoryID != 0 {
+			query = query.Where("(source_id = ? OR destination_id = ?)", filter.InventoryID, filter.InventoryID)
+		}
+
+		return query
+	}
+
+	var totalItems int64
+	if err := query().Count(&totalItems).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+
+	var transfers []*entity.StockTransfer
+	if err := s.preload(query()).Order("created_at DESC, id DESC").Limit(pageSize).Offset(offset).
+		Find(&transfers).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return transfers, totalItems, nil
+}
+
+// Ship takes the qty of every line from the source, the whole transfer fails
+// when one line does not have enough stock there.
+func (s *stockTransferRepositoryImpl) Ship(ctx context.Context, id, userId uint) (*entity.StockTransfer, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		transfer, err := s.lockTransfer(tx, id, TransferDraft)
+		if err != nil {
+			return err
+		}
+
+		move := &entity.StockMovement{Reason: StockTransfer, RefType: RefTransfer, RefID: transfer.ID, UserID: &userId, Note: transfer.Code}
+		for _, v := range transfer.Lines {
+			if err := checkVariant(tx, v.ProductID, v.VariantID); err != nil {
+				return err
+			}
+
+			if err := reduceStock(tx, v.ProductID, v.VariantID, transfer.SourceID, v.Qty, move); err != nil {
+				return err
+			}
+		}
+
+		data := map[string]interface{}{
+			"status":     TransferInTransit,
+			"shipped_by": userId,
+			"shipped_at": time.Now(),
+		}
+
+		if err := tx.Model(transfer).Updates(data).Error; err != nil {
+			return fmt.Errorf("ship transfer: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isTransferErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer repo: ship: %w", err)
+	}
+
+	return s.FindById(ctx, id)
+}
+
+// Receive adds what arrived to the destination and may be called for every
+// delivery. The transfer is received once every line is complete, or when
+// close is set, then the qty still missing is kept as the discrepancy of its
+// line. The source gave that stock already, so it is not put back anywhere.
+func (s *stockTransferRepositoryImpl) Receive(ctx context.Context, id, userId uint, receipts []entity.StockTransferReceipt, close bool) (*entity.StockTransfer, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		transfer, err := s.lockTransfer(tx, id, TransferInTransit)
+		if err != nil {
+			return err
+		}
+
+		lines := map[uint]*entity.StockTransferLine{}
+		for i := range transfer.Lines {
+			lines[transfer.Lines[i].ID] = &transfer.Lines[i]
+		}
+
+		move := &entity.StockMovement{Reason: StockTransfer, RefType: RefTransfer, RefID: transfer.ID, UserID: &userId, Note: transfer.Code}
+		for _, v := range receipts {
+			line, ok := lines[v.LineID]
+			if !ok {
+				return ErrTransferLineNotFound
+			}
+
+			if line.ReceivedQty+v.Qty > line.Qty {
+				return ErrTransferQtyExceeded
+			}
+
+			//addStock skips a deleted variant, the receipt must not be counted
+			//as received when nothing was booked, close it as a discrepancy
+			if err := checkVariant(tx, line.ProductID, line.VariantID); err != nil {
+				return err
+			}
+
+			if err := addStock(tx, line.ProductID, line.VariantID, transfer.DestinationID, v.Qty, move); err != nil {
+				return err
+			}
+
+			line.ReceivedQty += v.Qty
+			if v.Note != "" {
+				line.Note = v.Note
+			}
+
+			data := map[string]interface{}{
+				"received_qty": line.ReceivedQty,
+				"note":         line.Note,
+			}
+
+			if err := tx.Model(line).Updates(data).Error; err != nil {
+				return fmt.Errorf("update line: %w", err)
+			}
+		}
+
+		complete := true
+		for _, v := range transfer.Lines {
+			if v.ReceivedQty < v.Qty {
+				complete = false
+			}
+		}
+
+		if !complete && !close {
+			return nil
+		}
+
+		for _, v := range transfer.Lines {
+			if v.ReceivedQty == v.Qty {
+				continue
+			}
+
+			if err := tx.Model(&v).Update("discrepancy", v.Qty-v.ReceivedQty).Error; err != nil {
+				return fmt.Errorf("update discrepancy: %w", err)
+			}
+		}
+
+		data := map[string]interface{}{
+			"status":      TransferReceived,
+			"received_by": userId,
+			"received_at": time.Now(),
+		}
+
+		if err := tx.Model(transfer).Updates(data).Error; err != nil {
+			return fmt.Errorf("receive transfer: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isTransferErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer repo: receive: %w", err)
+	}
+
+	return s.FindById(ctx, id)
+}
+
+// Cancel drops a draft, a shipped transfer is closed through Receive.
+func (s *stockTransferRepositoryImpl) Cancel(ctx context.Context, id uint) (*entity.StockTransfer, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		transfer, err := s.lockTransfer(tx, id, TransferDraft)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(transfer).Update("status", TransferCanceled).Error
+	})
+
+	if err != nil {
+		if isTransferErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer repo: cancel: %w", err)
+	}
+
+	return s.FindById(ctx, id)
+}
+
+// lockTransfer holds the transfer until the transaction ends and makes sure
+// it is still in status.
+func (s *stockTransferRepositoryImpl) lockTransfer(tx *gorm.DB, id uint, status string) (*entity.StockTransfer, error) {
+	var transfer entity.StockTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&transfer, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("lock transfer: %w", err)
+	}
+
+	if transfer.Status != status {
+		return nil, ErrTransferStatusChanged
+	}
+
+	return &transfer, nil
+}
+
+// checkTransfer makes sure both locations exist and every line names a
+// product, or one of its variants, that can hold stock.
+func (s *stockTransferRepositoryImpl) checkTransfer(tx *gorm.DB, transfer *entity.StockTransfer) error {
+	for _, v := range []uint{transfer.SourceID, transfer.DestinationID} {
+		var total int64
+		if err := tx.Model(&entity.Inventory{}).Where("id = ?", v).Count(&total).Error; err != nil {
+			return fmt.Errorf("find inventory: %w", err)
+		}
+
+		if total == 0 {
+			return ErrInventoryNotFound
+		}
+	}
+
+	for _, v := range transfer.Lines {
+		if err := tx.Select("id").First(&entity.Product{}, v.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrProductNotFound
+			}
+			return fmt.Errorf("find product: %w", err)
+		}
+
+		if err := checkVariant(tx, v.ProductID, v.VariantID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *stockTransferRepositoryImpl) preload(db *gorm.DB) *gorm.DB {
+	return db.Preload("Source").Preload("Destination").Preload("User").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Lines.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Lines.Product.Variants")
+}
+
+func isTransferErr(err error) bool {
+	return errors.Is(err, ErrTransferNotFound) || errors.Is(err, ErrTransferStatusChanged) ||
+		errors.Is(err, ErrTransferLineNotFound) || errors.Is(err, ErrTransferQtyExceeded) ||
+		errors.Is(err, ErrInventoryNotFound) || errors.Is(err, ErrProductNotFound) ||
+		errors.Is(err, ErrVariantNotFound) || errors.Is(err, ErrVariantRequired) ||
+		errors.Is(err, ErrNotEnoughStock)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type StocktakeRepository interface {
+	Create(ctx context.Context, stocktake *entity.Stocktake) (*entity.Stocktake, error)
+	FindById(ctx context.Context, id uint) (*entity.Stocktake, error)
+	FindAll(ctx context.Context, page, pageSize int, filter *entity.StocktakeFilter) ([]*entity.Stocktake, int64, error)
+	Count(ctx context.Context, id, userId uint, counts []entity.StocktakeCount) (*entity.Stocktake, error)
+	Approve(ctx context.Context, id, userId uint) (*entity.Stocktake, error)
+	Cancel(ctx context.Context, id uint) (*entity.Stocktake, error)
+}
@@ -0,0 +1,370 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type stocktakeRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewStocktakeRepositoryImpl(db *gorm.DB) *stocktakeRepositoryImpl {
+	return &stocktakeRepositoryImpl{
+		Db: db,
+	}
+}
+
+const (
+	StocktakeOpen     = "open"
+	StocktakeApproved = "approved"
+	StocktakeCanceled = "canceled"
+)
+
+var (
+	ErrStocktakeNotFound      = errors.New("stocktake not found")
+	ErrStocktakeStatusChanged = errors.New("stocktake status has changed")
+	ErrStocktakeOpen          = errors.New("inventory already has an open stocktake")
+	ErrStocktakeLineNotFound  = errors.New("stocktake line not found")
+	ErrStocktakeSkuNotFound   = errors.New("sku not found")
+)
+
+// Create opens a stocktake with a line for every product and variant that has
+// a stock row at the location, one location is counted by one open session.
+func (s *stocktakeRepositoryImpl) Create(ctx context.Context, stocktake *entity.Stocktake) (*entity.Stocktake, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var inventory entity.Inventory
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&inventory, stocktake.InventoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInventoryNotFound
+			}
+			return fmt.Errorf("lock inventory: %w", err)
+		}
+
+		var open int64
+		if err := tx.Model(&entity.Stocktake{}).Where("inventory_id = ? AND status = ?", stocktake.InventoryID, StocktakeOpen).
+			Count(&open).Error; err != nil {
+			return fmt.Errorf("count open stocktake: %w", err)
+		}
+
+		if open > 0 {
+			return ErrStocktakeOpen
+		}
+
+		code, err := nextDocumentNumber(tx, StocktakeCodePrefix, time.Now())
+		if err != nil {
+			return err
+		}
+
+		var lines []entity.StocktakeLine
+		if err := tx.Table("product_stocks").
+			Select("product_stocks.product_id, product_stocks.variant_id, product_stocks.quantity AS expected, COALESCE(product_variants.sku, products.sku, '') AS sku").
+			Joins("JOIN products ON products.id = product_stocks.product_id AND products.deleted_at IS NULL").
+			Joins("LEFT JOIN product_variants ON product_variants.id = product_stocks.variant_id").
+			Where("product_stocks.inventory_id = ?", stocktake.InventoryID).
+			Order("product_stocks.product_id, product_stocks.variant_id").
+			Scan(&lines).Error; err != nil {
+			return fmt.Errorf("snapshot stock: %w", err)
+		}
+
+		stocktake.Code = code
+		stocktake.Status = StocktakeOpen
+
+		if err := tx.Omit(clause.Associations).Create(stocktake).Error; err != nil {
+			return fmt.Errorf("create stocktake: %w", err)
+		}
+
+		if len(lines) == 0 {
+			return nil
+		}
+
+		for i := range lines {
+			lines[i].StocktakeID = stocktake.ID
+		}
+
+		if err := tx.Omit(clause.Associations).CreateInBatches(&lines, 500).Error; err != nil {
+			return fmt.Errorf("create lines: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isStocktakeErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stocktake repo: create: %w", err)
+	}
+
+	return s.FindById(ctx, stocktake.ID)
+}
+
+func (s *stocktakeRepositoryImpl) FindById(ctx context.Context, id uint) (*entity.Stocktake, error) {
+	stocktake := entity.Stocktake{}
+
+	err := s.Db.WithContext(ctx).Preload("Inventory").Preload("User").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("product_id, variant_id") }).
+		Preload("Lines.Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Lines.Product.Variants").
+		First(&stocktake, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStocktakeNotFound
+		}
+		return nil, fmt.Errorf("stocktake repo: find by id: %w", err)
+	}
+
+	return &stocktake, nil
+}
+
+// FindAll lists the sessions without their lines.
+func (s *stocktakeRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, filter *entity.StocktakeFilter) ([]*entity.Stocktake, int64, error) {
+	query := func() *gorm.DB {
+		query := s.Db.WithContext(ctx).Model(&entity.Stocktake{})
+
+		if filter.Status != "" {
+			query = query.Where("status = ?", filter.Status)
+		}
+
+		if filter.InventoryID != 0 {
+			query = query.Where("inventory_id = ?", filter.InventoryID)
+		}
+
+		return query
+	}
+
+	var totalItems int64
+	if err := query().Count(&totalItems).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+
+	var stocktakes []*entity.Stocktake
+	if err := query().Preload("Inventory").Preload("User").Order("created_at DESC, id DESC").
+		Limit(pageSize).Offset(offset).Find(&stocktakes).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return stocktakes, totalItems, nil
+}
+
+// Count saves counted qtys on an open session, a later count of the same line
+// replaces the earlier one. The line expects the stock of the location at the
+// time it is counted, so sales booked between the snapshot and the count are
+// not posted again on approval. A sku without a line was not at the location
+// when the session opened and gets a new line.
+func (s *stocktakeRepositoryImpl) Count(ctx context.Context, id, userId uint, counts []entity.StocktakeCount) (*entity.Stocktake, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stocktake, err := s.lockStocktake(tx, id)
+		if err != nil {
+			return err
+		}
+
+		byId := map[uint]*entity.StocktakeLine{}
+		byItem := map[[2]uint]*entity.StocktakeLine{}
+		for i := range stocktake.Lines {
+			line := &stocktake.Lines[i]
+			byId[line.ID] = line
+			byItem[[2]uint{line.ProductID, line.VariantID}] = line
+		}
+
+		now := time.Now()
+		for _, v := range counts {
+			line, ok := byId[v.LineID]
+			if !ok && v.LineID != 0 {
+				return ErrStocktakeLineNotFound
+			}
+
+			if !ok {
+				productId, variantId, err := findSku(tx, v.SKU)
+				if err != nil {
+					return err
+				}
+
+				line, ok = byItem[[2]uint{productId, variantId}]
+				if !ok {
+					line = &entity.StocktakeLine{
+						StocktakeID: stocktake.ID,
+						ProductID:   productId,
+						VariantID:   variantId,
+						SKU:         v.SKU,
+					}
+
+					if err := tx.Omit(clause.Associations).Create(line).Error; err != nil {
+						return fmt.Errorf("create line: %w", err)
+					}
+					byItem[[2]uint{productId, variantId}] = line
+				}
+			}
+
+			expected, err := locationStock(tx, line.ProductID, line.VariantID, stocktake.InventoryID)
+			if err != nil {
+				return err
+			}
+
+			data := map[string]interface{}{
+				"expected":   expected,
+				"counted":    v.Counted,
+				"counted_by": userId,
+				"counted_at": now,
+			}
+
+			if err := tx.Model(line).Updates(data).Error; err != nil {
+				return fmt.Errorf("update count: %w", err)
+			}
+		}
+
+		return tx.Model(stocktake).Update("updated_at", now).Error
+	})
+
+	if err != nil {
+		if isStocktakeErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stocktake repo: count: %w", err)
+	}
+
+	return s.FindById(ctx, id)
+}
+
+// Approve posts the variance of every counted line as an adjustment at the
+// location. The variance is taken against the stock when the line was counted,
+// so sales and receipts booked after the count are kept. Lines never counted
+// are left as they are.
+func (s *stocktakeRepositoryImpl) Approve(ctx context.Context, id, userId uint) (*entity.Stocktake, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stocktake, err := s.lockStocktake(tx, id)
+		if err != nil {
+			return err
+		}
+
+		move := &entity.StockMovement{Reason: StockAdjustment, RefType: RefStocktake, RefID: stocktake.ID, UserID: &userId, Note: stocktake.Code}
+		for _, v := range stocktake.Lines {
+			if v.Counted == nil || *v.Counted == v.Expected {
+				continue
+			}
+
+			if err := checkVariant(tx, v.ProductID, v.VariantID); err != nil {
+				return err
+			}
+
+			delta := *v.Counted - v.Expected
+			if delta > 0 {
+				err = addStock(tx, v.ProductID, v.VariantID, stocktake.InventoryID, delta, move)
+			} else {
+				err = reduceStock(tx, v.ProductID, v.VariantID, stocktake.InventoryID, -delta, move)
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&v).Update("adjusted", delta).Error; err != nil {
+				return fmt.Errorf("update line: %w", err)
+			}
+		}
+
+		data := map[string]interface{}{
+			"status":      StocktakeApproved,
+			"approved_by": userId,
+			"approved_at": time.Now(),
+		}
+
+		if err := tx.Model(stocktake).Updates(data).Error; err != nil {
+			return fmt.Errorf("approve stocktake: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if isStocktakeErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stocktake repo: approve: %w", err)
+	}
+
+	return s.FindById(ctx, id)
+}
+
+func (s *stocktakeRepositoryImpl) Cancel(ctx context.Context, id uint) (*entity.Stocktake, error) {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stocktake, err := s.lockStocktake(tx, id)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(stocktake).Update("status", StocktakeCanceled).Error
+	})
+
+	if err != nil {
+		if isStocktakeErr(err) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stocktake repo: cancel: %w", err)
+	}
+
+	return s.FindById(ctx, id)
+}
+
+// lockStocktake holds an open session until the transaction ends.
+func (s *stocktakeRepositoryImpl) lockStocktake(tx *gorm.DB, id uint) (*entity.Stocktake, error) {
+	var stocktake entity.Stocktake
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lines").First(&stocktake, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStocktakeNotFound
+		}
+		return nil, fmt.Errorf("lock stocktake: %w", err)
+	}
+
+	if stocktake.Status != StocktakeOpen {
+		return nil, ErrStocktakeStatusChanged
+	}
+
+	return &stocktake, nil
+}
+
+// findSku is the product, or the variant, sku belongs to.
+func findSku(tx *gorm.DB, sku string) (uint, uint, error) {
+	if sku == "" {
+		return 0, 0, fmt.Errorf("%w: line_id or sku is required", ErrStocktakeLineNotFound)
+	}
+
+	var variant entity.ProductVariant
+	err := tx.Select("id", "product_id").Where("sku = ?", sku).First(&variant).Error
+	if err == nil {
+		return variant.ProductID, variant.ID, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, 0, fmt.Errorf("find variant: %w", err)
+	}
+
+	var product entity.Product
+	if err := tx.Select("id").Where("sku = ?", sku).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, 0, fmt.Errorf("%w: %s", ErrStocktakeSkuNotFound, sku)
+		}
+		return 0, 0, fmt.Errorf("find product: %w", err)
+	}
+
+	if err := checkVariant(tx, product.ID, 0); err != nil {
+		return 0, 0, err
+	}
+
+	return product.ID, 0, nil
+}
+
+func isStocktakeErr(err error) bool {
+	return errors.Is(err, ErrStocktakeNotFound) || errors.Is(err, ErrStocktakeStatusChanged) ||
+		errors.Is(err, ErrStocktakeOpen) || errors.Is(err, ErrStocktakeLineNotFound) ||
+		errors.Is(err, ErrStocktakeSkuNotFound) || errors.Is(err, ErrInventoryNotFound) ||
+		errors.Is(err, ErrVariantNotFound) || errors.Is(err, ErrVariantRequired) ||
+		errors.Is(err, ErrNotEnoughStock)
+}
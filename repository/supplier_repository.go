@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+	"simple-toko/entity"
+)
+
+type SupplierRepository interface {
+	Create(ctx context.Context, supplier *entity.Supplier) (*entity.Supplier, error)
+	Update(ctx context.Context, supplierId uint, supplier *entity.Supplier) (*entity.Supplier, error)
+	Delete(ctx context.Context, supplierId uint) error
+	FindById(ctx context.Context, supplierId uint) (*entity.Supplier, error)
+	FindAll(ctx context.Context, page, pageSize int, search string) ([]*entity.Supplier, int64, error)
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+
+	"gorm.io/gorm"
+)
+
+type supplierRepositoryImpl struct {
+	Db *gorm.DB
+}
+
+func NewSupplierRepositoryImpl(db *gorm.DB) *supplierRepositoryImpl {
+	return &supplierRepositoryImpl{
+		Db: db,
+	}
+}
+
+var (
+	ErrSupplierNotFound = errors.New("supplier not found")
+	ErrSupplierInUse    = errors.New("supplier still has open purchase orders")
+)
+
+func (s *supplierRepositoryImpl) Create(ctx context.Context, supplier *entity.Supplier) (*entity.Supplier, error) {
+	if err := s.Db.WithContext(ctx).Create(supplier).Error; err != nil {
+		return nil, fmt.Errorf("supplier repo: create: %w", err)
+	}
+
+	return supplier, nil
+}
+
+func (s *supplierRepositoryImpl) Update(ctx context.Context, supplierId uint, supplier *entity.Supplier) (*entity.Supplier, error) {
+	var data entity.Supplier
+	if err := s.Db.WithContext(ctx).First(&data, supplierId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSupplierNotFound
+		}
+		return nil, fmt.Errorf("supplier repo: update: %w", err)
+	}
+
+	update := map[string]interface{}{
+		"name":    supplier.Name,
+		"email":   supplier.Email,
+		"phone":   supplier.Phone,
+		"address": supplier.Address,
+	}
+
+	if err := s.Db.WithContext(ctx).Model(&data).Updates(update).Error; err != nil {
+		return nil, fmt.Errorf("supplier repo: update: %w", err)
+	}
+
+	return &data, nil
+}
+
+// Delete keeps the supplier of received and canceled orders readable, only a
+// supplier with orders still running is refused.
+func (s *supplierRepositoryImpl) Delete(ctx context.Context, supplierId uint) error {
+	err := s.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var total int64
+		if err := tx.Model(&entity.PurchaseOrder{}).
+			Where("supplier_id = ? AND status IN ?", supplierId, []string{PurchaseDraft, PurchaseApproved, PurchasePartial}).
+			Count(&total).Error; err != nil {
+			return fmt.Errorf("count purchase order: %w", err)
+		}
+
+		if total > 0 {
+			return ErrSupplierInUse
+		}
+
+		result := tx.Delete(&entity.Supplier{}, supplierId)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrSupplierNotFound
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, ErrSupplierNotFound) || errors.Is(err, ErrSupplierInUse) {
+			return err
+		}
+		return fmt.Errorf("supplier repo: delete: %w", err)
+	}
+
+	return nil
+}
+
+func (s *supplierRepositoryImpl) FindById(ctx context.Context, supplierId uint) (*entity.Supplier, error) {
+	supplier := entity.Supplier{}
+
+	if err := s.Db.WithContext(ctx).First(&supplier, supplierId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSupplierNotFound
+		}
+		return nil, fmt.Errorf("supplier repo: find id: %w", err)
+	}
+
+	return &supplier, nil
+}
+
+func (s *supplierRepositoryImpl) FindAll(ctx context.Context, page, pageSize int, search string) ([]*entity.Supplier, int64, error) {
+	query := func() *gorm.DB {
+		query := s.Db.WithContext(ctx).Model(&entity.Supplier{})
+
+		if search != "" {
+			query = query.Where("name LIKE ?", "%"+search+"%")
+		}
+
+		return query
+	}
+
+	var totalItems int64
+	if err := query().Count(&totalItems).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+
+	var suppliers []*entity.Supplier
+	if err := query().Order("name, id").Limit(pageSize).Offset(offset).Find(&suppliers).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return suppliers, totalItems, nil
+}
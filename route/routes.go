@@ -5,6 +5,7 @@ import (
 	"simple-toko/middleware"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 )
 
 func NewRouter(
@@ -15,14 +16,30 @@ func NewRouter(
 	OrderHandler handler.OrderHandler,
 	PaymentHandler handler.PaymentHandler,
 	ReportHandler handler.ReportHandler,
+	CartHandler handler.CartHandler,
+	ReturnHandler handler.ReturnHandler,
+	CategoryHandler handler.CategoryHandler,
+	ReviewHandler handler.ReviewHandler,
+	ProductImportHandler handler.ProductImportHandler,
+	ProductPriceHandler handler.ProductPriceHandler,
+	StockHandler handler.StockHandler,
+	TransferHandler handler.StockTransferHandler,
+	StocktakeHandler handler.StocktakeHandler,
+	SupplierHandler handler.SupplierHandler,
+	PurchaseHandler handler.PurchaseOrderHandler,
+	Redis *redis.Client,
 ) *gin.Engine {
 	router := gin.Default()
+	idempotent := middleware.Idempotency(Redis)
 
 	regist := router.Group("/api/v1/")
 	{
 		regist.POST("register", UserHandler.Create)
 		regist.POST("login", UserHandler.Login)
 		regist.POST("refresh-token", UserHandler.RefreshToken)
+
+		//signed links, the signature replaces the token
+		regist.GET("files/payment/:name", PaymentHandler.LinkImage)
 	}
 
 	api := router.Group("/api/v1")
@@ -47,6 +64,41 @@ func NewRouter(
 			admin.DELETE("inventory/:invId", InventHandler.Delete)
 			admin.GET("inventory/:invId", InventHandler.FindById)
 			admin.GET("inventory", InventHandler.FindAll)
+			admin.GET("inventory/:invId/stocks", StockHandler.FindByInventory)
+
+			//stock transfer between inventories
+			admin.POST("inventory/transfers", TransferHandler.Create)
+			admin.GET("inventory/transfers", TransferHandler.FindAll)
+			admin.GET("inventory/transfers/:transferId", TransferHandler.FindById)
+			admin.PUT("inventory/transfers/:transferId", TransferHandler.Update)
+			admin.PUT("inventory/transfers/:transferId/ship", TransferHandler.Ship)
+			admin.PUT("inventory/transfers/:transferId/receive", TransferHandler.Receive)
+			admin.PUT("inventory/transfers/:transferId/cancel", TransferHandler.Cancel)
+
+			//stocktake, physical count of one inventory
+			admin.POST("inventory/stocktakes", StocktakeHandler.Create)
+			admin.GET("inventory/stocktakes", StocktakeHandler.FindAll)
+			admin.GET("inventory/stocktakes/:stocktakeId", StocktakeHandler.FindById)
+			admin.PUT("inventory/stocktakes/:stocktakeId/counts", StocktakeHandler.Count)
+			admin.POST("inventory/stocktakes/:stocktakeId/counts/upload", StocktakeHandler.UploadCounts)
+			admin.PUT("inventory/stocktakes/:stocktakeId/approve", StocktakeHandler.Approve)
+			admin.PUT("inventory/stocktakes/:stocktakeId/cancel", StocktakeHandler.Cancel)
+
+			//supplier
+			admin.POST("suppliers", SupplierHandler.Create)
+			admin.GET("suppliers", SupplierHandler.FindAll)
+			admin.GET("suppliers/:supplierId", SupplierHandler.FindById)
+			admin.PUT("suppliers/:supplierId", SupplierHandler.Update)
+			admin.DELETE("suppliers/:supplierId", SupplierHandler.Delete)
+
+			//purchase order & goods receipt
+			admin.POST("purchase-orders", PurchaseHandler.Create)
+			admin.GET("purchase-orders", PurchaseHandler.FindAll)
+			admin.GET("purchase-orders/:poId", PurchaseHandler.FindById)
+			admin.PUT("purchase-orders/:poId", PurchaseHandler.Update)
+			admin.PUT("purchase-orders/:poId/approve", PurchaseHandler.Approve)
+			admin.PUT("purchase-orders/:poId/cancel", PurchaseHandler.Cancel)
+			admin.POST("purchase-orders/:poId/receipts", PurchaseHandler.Receive)
 
 			//product
 			admin.POST("product", ProductHandler.Create)
@@ -55,13 +107,37 @@ func NewRouter(
 			admin.GET("product/:productId", ProductHandler.FindById)
 			admin.PUT("product/:productId/add", ProductHandler.AddStock)
 			admin.PUT("product/:productId/reduce", ProductHandler.ReduceStock)
+			admin.GET("product/:productId/stocks", StockHandler.FindByProduct)
+			admin.GET("product/:productId/stock-movements", StockHandler.FindMovements)
 			admin.PUT("product/image/:productId", ProductHandler.UpdateImage)
 			admin.GET("product/image/:productId", ProductHandler.PreviewImage)
+			admin.PUT("product/:productId/options", ProductHandler.SaveOptions)
+			admin.POST("product/:productId/variants", ProductHandler.CreateVariant)
+			admin.PUT("product/:productId/variants/:variantId", ProductHandler.UpdateVariant)
+			admin.DELETE("product/:productId/variants/:variantId", ProductHandler.DeleteVariant)
+			admin.POST("product/:productId/images", ProductHandler.UploadImage)
+			admin.PUT("product/:productId/images/order", ProductHandler.ReorderImages)
+			admin.PUT("product/:productId/images/:imageId/primary", ProductHandler.SetPrimaryImage)
+			admin.DELETE("product/:productId/images/:imageId", ProductHandler.DeleteImage)
+			admin.POST("product/import", ProductImportHandler.Import)
+			admin.GET("product/import/:id", ProductImportHandler.FindById)
+			admin.GET("product/export", ProductImportHandler.Export)
+			admin.GET("product/:productId/prices", ProductPriceHandler.Timeline)
+			admin.POST("product/:productId/price-schedules", ProductPriceHandler.CreateSchedule)
+			admin.GET("product/:productId/price-schedules", ProductPriceHandler.FindSchedules)
+			admin.DELETE("product/:productId/price-schedules/:scheduleId", ProductPriceHandler.CancelSchedule)
+
+			//category
+			admin.POST("category", CategoryHandler.Create)
+			admin.PUT("category/:id", CategoryHandler.Update)
+			admin.DELETE("category/:id", CategoryHandler.Delete)
 
 			//orders
 			admin.GET("order", OrderHandler.FindAll)
 			admin.PUT("order/confirm/:id", OrderHandler.ConfirmOrder)
+			admin.PUT("order/cancel/:id", OrderHandler.CancelOrderByAdmin)
 			admin.DELETE("order/:id", OrderHandler.Delete)
+			admin.GET("order/:id/packing-slip.pdf", OrderHandler.PackingSlip)
 
 			//payments
 			admin.GET("payment", PaymentHandler.FindAll)
@@ -70,6 +146,18 @@ func NewRouter(
 			admin.GET("payment/:id", PaymentHandler.FindById)
 			admin.PUT("payment/status/:orderId", PaymentHandler.UpdateStatus)
 
+			//returns
+			admin.GET("return", ReturnHandler.FindAll)
+			admin.PUT("return/:id/approve", ReturnHandler.Approve)
+			admin.PUT("return/:id/reject", ReturnHandler.Reject)
+			admin.PUT("return/:id/receive", ReturnHandler.Receive)
+			admin.POST("return/:id/refund", ReturnHandler.Refund)
+
+			//reviews
+			admin.GET("review", ReviewHandler.FindAll)
+			admin.PUT("review/:id/hide", ReviewHandler.Hide)
+			admin.PUT("review/:id/unhide", ReviewHandler.Unhide)
+
 			//reports
 			admin.GET("monthly-sales", ReportHandler.MonthlySales)
 			admin.GET("top-product", ReportHandler.TopProductSales)
@@ -80,6 +168,9 @@ func NewRouter(
 		cust.Use(middleware.RoleAccessMiddleware("customer", "admin"))
 		{
 			cust.GET("product", ProductHandler.FindAll)
+			cust.GET("product/:productId/images/:imageId", ProductHandler.PreviewGalleryImage)
+			cust.GET("category", CategoryHandler.FindAll)
+			cust.GET("category/:id", CategoryHandler.FindById)
 
 			cust.PUT("users/:userId", UserHandler.Update)
 			cust.GET("users", UserHandler.FindAll)
@@ -89,12 +180,37 @@ func NewRouter(
 			cust.DELETE("address/:id", AddressHandler.Delete)
 			cust.GET("address/user/:userId", AddressHandler.FindByUserId)
 
-			cust.POST("order", OrderHandler.CreateOrder)
+			cust.POST("order", idempotent, OrderHandler.CreateOrder)
 			cust.PUT("order/:id", OrderHandler.UpdateAddress)
 			cust.GET("order/:id", OrderHandler.FindById)
-
-			cust.POST("payment", PaymentHandler.UploadPayment)
+			cust.PUT("order/:id/cancel", OrderHandler.CancelOrder)
+			cust.GET("order/:id/history", OrderHandler.FindHistory)
+			cust.GET("order/:id/invoice.pdf", OrderHandler.Invoice)
+			cust.GET("orders/me", OrderHandler.FindByUser)
+			cust.POST("order/:id/items", OrderHandler.AddItem)
+			cust.PUT("order/:id/items/:productId", OrderHandler.UpdateItemQty)
+			cust.DELETE("order/:id/items/:productId", OrderHandler.RemoveItem)
+
+			cust.GET("cart", CartHandler.FindByUser)
+			cust.POST("cart/items", CartHandler.AddItem)
+			cust.PUT("cart/items/:productId", CartHandler.UpdateItem)
+			cust.DELETE("cart/items/:productId", CartHandler.RemoveItem)
+			cust.DELETE("cart", CartHandler.Clear)
+			cust.POST("cart/checkout", idempotent, CartHandler.Checkout)
+
+			cust.POST("payment", idempotent, PaymentHandler.UploadPayment)
 			cust.GET("payment/order/:orderId", PaymentHandler.FindByOrderId)
+			cust.GET("payment/order/:orderId/link", PaymentHandler.CreateLink)
+
+			cust.POST("return", ReturnHandler.Create)
+			cust.GET("return/:id", ReturnHandler.FindById)
+			cust.GET("return/:id/image", ReturnHandler.PreviewImage)
+			cust.GET("returns/me", ReturnHandler.FindByUser)
+
+			cust.POST("review", ReviewHandler.Create)
+			cust.GET("product/:productId/reviews", ReviewHandler.FindByProduct)
+			cust.GET("review/:id/images/:imageId", ReviewHandler.PreviewImage)
+			cust.GET("reviews/me", ReviewHandler.FindByUser)
 		}
 
 	}
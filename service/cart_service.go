@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	web "simple-toko/web/cart"
+	order "simple-toko/web/order"
+)
+
+type CartService interface {
+	FindByUserId(ctx context.Context, userId uint) (*web.CartResponse, error)
+	AddItem(ctx context.Context, req *web.CartItemRequest) (*web.CartResponse, error)
+	UpdateItem(ctx context.Context, req *web.CartItemRequest) (*web.CartResponse, error)
+	RemoveItem(ctx context.Context, userId, productId, variantId uint) (*web.CartResponse, error)
+	Clear(ctx context.Context, userId uint) error
+	Checkout(ctx context.Context, req *web.CartCheckoutRequest) (*order.OrderResponse, error)
+}
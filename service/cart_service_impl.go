@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	web "simple-toko/web/cart"
+	order "simple-toko/web/order"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type cartServiceImpl struct {
+	CartRepo     repository.CartRepository
+	ProductRepo  repository.ProductRepository
+	OrderService OrderService
+	Validate     *validator.Validate
+	Redis        *redis.Client
+}
+
+func NewCartServiceImpl(cartRepo repository.CartRepository, productRepo repository.ProductRepository, orderService OrderService, validate *validator.Validate, redis *redis.Client) *cartServiceImpl {
+	return &cartServiceImpl{
+		CartRepo:     cartRepo,
+		ProductRepo:  productRepo,
+		OrderService: orderService,
+		Validate:     validate,
+		Redis:        redis,
+	}
+}
+
+var (
+	ErrCartEmpty        = errors.New("cart is empty")
+	ErrCartItemNotFound = errors.New("cart item not found")
+)
+
+func (c *cartServiceImpl) FindByUserId(ctx context.Context, userId uint) (*web.CartResponse, error) {
+	items, err := c.cartItems(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.toResponse(ctx, items)
+}
+
+func (c *cartServiceImpl) AddItem(ctx context.Context, req *web.CartItemRequest) (*web.CartResponse, error) {
+	if err := c.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	product, err := c.ProductRepo.FindById(ctx, req.ProductID)
+	if err != nil {
+		if errors.Is(err, repository.ErrorIdNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, fmt.Errorf("cart service: find product: %w", err)
+	}
+
+	if err := checkCartVariant(product, req.VariantID); err != nil {
+		return nil, err
+	}
+
+	item := entity.CartItem{
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Qty:       req.Qty,
+	}
+
+	result, err := c.CartRepo.AddItem(ctx, req.UserID, &item)
+	if err != nil {
+		return nil, fmt.Errorf("cart service: add item: %w", err)
+	}
+
+	c.setCached(ctx, req.UserID, result.CartItems)
+
+	return c.toResponse(ctx, result.CartItems)
+}
+
+func (c *cartServiceImpl) UpdateItem(ctx context.Context, req *web.CartItemRequest) (*web.CartResponse, error) {
+	if err := c.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	item := entity.CartItem{
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Qty:       req.Qty,
+	}
+
+	result, err := c.CartRepo.UpdateItem(ctx, req.UserID, &item)
+	if err != nil {
+		if errors.Is(err, repository.ErrCartItemNotFound) {
+			return nil, ErrCartItemNotFound
+		}
+		return nil, fmt.Errorf("cart service: update item: %w", err)
+	}
+
+	c.setCached(ctx, req.UserID, result.CartItems)
+
+	return c.toResponse(ctx, result.CartItems)
+}
+
+func (c *cartServiceImpl) RemoveItem(ctx context.Context, userId, productId, variantId uint) (*web.CartResponse, error) {
+	result, err := c.CartRepo.RemoveItem(ctx, userId, productId, variantId)
+	if err != nil {
+		if errors.Is(err, repository.ErrCartItemNotFound) {
+			return nil, ErrCartItemNotFound
+		}
+		return nil, fmt.Errorf("cart service: remove item: %w", err)
+	}
+
+	c.setCached(ctx, userId, result.CartItems)
+
+	return c.toResponse(ctx, result.CartItems)
+}
+
+func (c *cartServiceImpl) Clear(ctx context.Context, userId uint) error {
+	if err := c.CartRepo.Clear(ctx, userId); err != nil {
+		return fmt.Errorf("cart service: clear: %w", err)
+	}
+
+	c.setCached(ctx, userId, []entity.CartItem{})
+
+	return nil
+}
+
+func (c *cartServiceImpl) Checkout(ctx context.Context, req *web.CartCheckoutRequest) (*order.OrderResponse, error) {
+	if err := c.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	cart, err := c.CartRepo.FindByUserId(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("cart service: find cart checkout: %w", err)
+	}
+
+	if len(cart.CartItems) == 0 {
+		return nil, ErrCartEmpty
+	}
+
+	orderReq := order.OrderCreateRequest{
+		UserID:        req.UserID,
+		AddressID:     req.AddressID,
+		OrderProducts: make([]order.ProductItem, 0, len(cart.CartItems)),
+	}
+
+	for _, v := range cart.CartItems {
+		orderReq.OrderProducts = append(orderReq.OrderProducts, order.ProductItem{
+			ProductID: v.ProductID,
+			VariantID: v.VariantID,
+			Qty:       v.Qty,
+		})
+	}
+
+	result, err := c.OrderService.CreateOrder(ctx, &orderReq)
+	if err != nil {
+		return nil, err
+	}
+
+	//only what went into the order leaves the cart, items added meanwhile stay
+	remaining, err := c.CartRepo.RemoveOrdered(ctx, req.UserID, cart.CartItems)
+	if err != nil {
+		fmt.Printf("failed clear cart user %d after checkout: %v\n", req.UserID, err)
+	} else {
+		c.setCached(ctx, req.UserID, remaining.CartItems)
+	}
+
+	return result, nil
+}
+
+// cartItems reads the cart lines from redis and falls back to the database,
+// product price and stock are always loaded fresh.
+func (c *cartServiceImpl) cartItems(ctx context.Context, userId uint) ([]entity.CartItem, error) {
+	cacheKey := fmt.Sprintf("carts:%d", userId)
+
+	cached, err := c.Redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var items []entity.CartItem
+		if err := json.Unmarshal([]byte(cached), &items); err == nil {
+			return items, nil
+		}
+	} else if err != redis.Nil {
+		fmt.Printf("Redis error: %v\n", err)
+	}
+
+	cart, err := c.CartRepo.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("cart service: find cart: %w", err)
+	}
+
+	c.setCached(ctx, userId, cart.CartItems)
+
+	return cart.CartItems, nil
+}
+
+// checkCartVariant needs a variant of the product when it has variants.
+func checkCartVariant(product *entity.Product, variantId uint) error {
+	if variantId == 0 {
+		if len(product.Variants) > 0 {
+			return ErrVariantRequired
+		}
+		return nil
+	}
+
+	for _, v := range product.Variants {
+		if v.ID == variantId {
+			return nil
+		}
+	}
+
+	return ErrVariantNotFound
+}
+
+func (c *cartServiceImpl) setCached(ctx context.Context, userId uint, items []entity.CartItem) {
+	cacheKey := fmt.Sprintf("carts:%d", userId)
+
+	jsonData, _ := json.Marshal(items)
+	if err := c.Redis.Set(ctx, cacheKey, jsonData, 24*time.Hour).Err(); err != nil {
+		fmt.Printf("Redis set error: %v\n", err)
+	}
+}
+
+func (c *cartServiceImpl) toResponse(ctx context.Context, items []entity.CartItem) (*web.CartResponse, error) {
+	ids := make([]uint, 0, len(items))
+	for _, v := range items {
+		ids = append(ids, v.ProductID)
+	}
+
+	result, err := c.ProductRepo.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("cart service: find product: %w", err)
+	}
+
+	products := make(map[uint]*entity.Product, len(result))
+	for _, v := range result {
+		products[v.ID] = v
+	}
+
+	response := helper.ToCartResponse(items, products)
+	return response, nil
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	web "simple-toko/web/category"
+)
+
+type CategoryService interface {
+	Create(ctx context.Context, req *web.CategoryCreateRequest) (*web.CategoryResponse, error)
+	Update(ctx context.Context, req *web.CategoryUpdateRequest) (*web.CategoryResponse, error)
+	Delete(ctx context.Context, id uint) error
+	FindById(ctx context.Context, id uint) (*web.CategoryResponse, error)
+	FindAll(ctx context.Context) ([]*web.CategoryResponse, error)
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	"simple-toko/utils"
+	web "simple-toko/web/category"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type categoryServiceImpl struct {
+	CategoryRepo repository.CategoryRepository
+	Validate     *validator.Validate
+	Redis        *redis.Client
+}
+
+func NewCategoryServiceImpl(categoryRepo repository.CategoryRepository, validate *validator.Validate, redis *redis.Client) *categoryServiceImpl {
+	return &categoryServiceImpl{
+		CategoryRepo: categoryRepo,
+		Validate:     validate,
+		Redis:        redis,
+	}
+}
+
+var (
+	ErrCategoryNotFound    = errors.New("category not found")
+	ErrCategoryHasChildren = errors.New("category still has sub categories")
+	ErrInvalidParent       = errors.New("parent category not found or inside the category itself")
+)
+
+func (c *categoryServiceImpl) Create(ctx context.Context, req *web.CategoryCreateRequest) (*web.CategoryResponse, error) {
+	if err := c.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if req.ParentID != nil {
+		if _, err := c.CategoryRepo.FindById(ctx, *req.ParentID); err != nil {
+			if errors.Is(err, repository.ErrorIdNotFound) {
+				return nil, ErrInvalidParent
+			}
+			return nil, fmt.Errorf("category service: find parent: %w", err)
+		}
+	}
+
+	category := entity.Category{
+		ParentID:    req.ParentID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+
+	result, err := c.CategoryRepo.Create(ctx, &category)
+	if err != nil {
+		return nil, fmt.Errorf("category service: create: %w", err)
+	}
+
+	utils.InvalidateCategoryCached(ctx, c.Redis)
+
+	response := helper.ToCategoryResponse(result)
+	return response, nil
+}
+
+func (c *categoryServiceImpl) Update(ctx context.Context, req *web.CategoryUpdateRequest) (*web.CategoryResponse, error) {
+	if err := c.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	all, err := c.CategoryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("category service: find all update: %w", err)
+	}
+
+	category := findCategory(all, req.ID)
+	if category == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			category.ParentID = nil
+		} else {
+			// the new parent may not be the category or one of its descendants
+			if findCategory(all, *req.ParentID) == nil {
+				return nil, ErrInvalidParent
+			}
+			for _, v := range categoryDescendants(all, req.ID) {
+				if v == *req.ParentID {
+					return nil, ErrInvalidParent
+				}
+			}
+			category.ParentID = req.ParentID
+		}
+	}
+
+	if req.Name != nil {
+		category.Name = *req.Name
+	}
+
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+
+	result, err := c.CategoryRepo.Update(ctx, category)
+	if err != nil {
+		return nil, fmt.Errorf("category service: update: %w", err)
+	}
+
+	utils.InvalidateCategoryCached(ctx, c.Redis)
+
+	response := helper.ToCategoryResponse(result)
+	return response, nil
+}
+
+func (c *categoryServiceImpl) Delete(ctx context.Context, id uint) error {
+	if err := c.CategoryRepo.Delete(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrorIdNotFound):
+			return ErrCategoryNotFound
+		case errors.Is(err, repository.ErrCategoryHasChildren):
+			return ErrCategoryHasChildren
+		default:
+			return fmt.Errorf("category service: delete: %w", err)
+		}
+	}
+
+	utils.InvalidateCategoryCached(ctx, c.Redis)
+
+	return nil
+}
+
+func (c *categoryServiceImpl) FindById(ctx context.Context, id uint) (*web.CategoryResponse, error) {
+	cacheKey := fmt.Sprintf("categories:%d", id)
+
+	cached, err := c.Redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var response web.CategoryResponse
+		if err := json.Unmarshal([]byte(cached), &response); err == nil {
+			return &response, nil
+		}
+	} else if err != redis.Nil {
+		fmt.Printf("Redis error: %v\n", err)
+	}
+
+	all, err := c.CategoryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("category service: find id: %w", err)
+	}
+
+	response := findCategoryNode(helper.ToCategoryTree(all), id)
+	if response == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	jsonData, _ := json.Marshal(response)
+	if err := c.Redis.Set(ctx, cacheKey, jsonData, 5*time.Minute).Err(); err != nil {
+		fmt.Printf("Redis set error: %v\n", err)
+	}
+
+	return response, nil
+}
+
+func (c *categoryServiceImpl) FindAll(ctx context.Context) ([]*web.CategoryResponse, error) {
+	cacheKey := "categories:tree"
+
+	cached, err := c.Redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var responses []*web.CategoryResponse
+		if err := json.Unmarshal([]byte(cached), &responses); err == nil {
+			return responses, nil
+		}
+	} else if err != redis.Nil {
+		fmt.Printf("Redis error: %v\n", err)
+	}
+
+	result, err := c.CategoryRepo.FindAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("category service: find all: %w", err)
+	}
+
+	responses := helper.ToCategoryTree(result)
+
+	jsonData, _ := json.Marshal(responses)
+	if err := c.Redis.Set(ctx, cacheKey, jsonData, 5*time.Minute).Err(); err != nil {
+		fmt.Printf("Redis set error: %v\n", err)
+	}
+
+	return responses, nil
+}
+
+func findCategory(all []*entity.Category, id uint) *entity.Category {
+	for _, v := range all {
+		if v.ID == id {
+			return v
+		}
+	}
+
+	return nil
+}
+
+func findCategoryNode(nodes []*web.CategoryResponse, id uint) *web.CategoryResponse {
+	for _, v := range nodes {
+		if v.ID == id {
+			return v
+		}
+		if found := findCategoryNode(v.Children, id); found != nil {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// categoryDescendants returns id and the ids of every category below it.
+func categoryDescendants(all []*entity.Category, id uint) []uint {
+	children := map[uint][]uint{}
+	for _, v := range all {
+		if v.ParentID != nil {
+			children[*v.ParentID] = append(children[*v.ParentID], v.ID)
+		}
+	}
+
+	ids := []uint{id}
+	seen := map[uint]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+
+	return ids
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"simple-toko/storage"
+)
+
+// readCsv reads an uploaded csv file from store, rows may have any number of
+// fields so the caller reports a short row itself.
+func readCsv(ctx context.Context, store storage.Storage, path string) ([][]string, error) {
+	file, err := store.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Body.Close()
+
+	data, err := io.ReadAll(file.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	//spreadsheet apps often save csv with a byte order mark
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	return records, nil
+}
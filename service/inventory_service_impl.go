@@ -26,7 +26,10 @@ func NewInventoryServiceImpl(inventoryRepo repository.InventoryRepository, valid
 	}
 }
 
-var ErrNotEnoughStock = errors.New("not enough stock")
+var (
+	ErrNotEnoughStock = errors.New("not enough stock")
+	ErrInventoryInUse = errors.New("inventory still holds stock or is the home of a product")
+)
 
 func (i *inventoryServiceImpl) Create(ctx context.Context, req *web.InventoryCreateRequest) (*web.InventoryResponse, error) {
 	if err := i.Validate.Struct(req); err != nil {
@@ -76,6 +79,9 @@ func (i *inventoryServiceImpl) Delete(ctx context.Context, invId uint) error {
 		if errors.Is(err, repository.ErrorIdNotFound) {
 			return ErrorIdNotFound
 		}
+		if errors.Is(err, repository.ErrInventoryInUse) {
+			return ErrInventoryInUse
+		}
 		return fmt.Errorf("user service: delete: %w", err)
 	}
 
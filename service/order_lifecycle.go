@@ -0,0 +1,83 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"simple-toko/repository"
+)
+
+type OrderState string
+
+const (
+	StateWaiting   OrderState = "waiting"
+	StateConfirmed OrderState = "confirmed"
+	StateOnProcess OrderState = "on_process"
+	StateDelivered OrderState = "delivered"
+	StateCanceled  OrderState = "canceled"
+)
+
+var orderTransitions = map[OrderState][]OrderState{
+	StateWaiting:   {StateConfirmed, StateCanceled},
+	StateConfirmed: {StateOnProcess, StateCanceled},
+	StateOnProcess: {StateDelivered, StateCanceled},
+	StateDelivered: {},
+	StateCanceled:  {},
+}
+
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+type TransitionError struct {
+	From OrderState
+	To   OrderState
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
+
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// orderStateOf folds the status_order and status_delivery columns into the
+// single lifecycle state the transitions are defined on.
+func orderStateOf(order *entity.Order) OrderState {
+	switch {
+	case order.StatusOrder == repository.Canceled:
+		return StateCanceled
+	case order.StatusOrder == repository.Waiting:
+		return StateWaiting
+	case order.StatusDelivery == string(StateOnProcess):
+		return StateOnProcess
+	case order.StatusDelivery == repository.Delivered:
+		return StateDelivered
+	default:
+		return StateConfirmed
+	}
+}
+
+func (s OrderState) columns() (statusOrder, statusDelivery string) {
+	switch s {
+	case StateWaiting:
+		return repository.Waiting, repository.Waiting
+	case StateConfirmed:
+		return repository.Confirmed, repository.Waiting
+	case StateOnProcess:
+		return repository.Confirmed, string(StateOnProcess)
+	case StateDelivered:
+		return repository.Confirmed, repository.Delivered
+	default:
+		return repository.Canceled, repository.Canceled
+	}
+}
+
+func checkTransition(from, to OrderState) error {
+	for _, v := range orderTransitions[from] {
+		if v == to {
+			return nil
+		}
+	}
+
+	return &TransitionError{From: from, To: to}
+}
@@ -10,8 +10,18 @@ type OrderService interface {
 	CreateOrder(ctx context.Context, req *web.OrderCreateRequest) (*web.OrderResponse, error)
 	UpdateAddress(ctx context.Context, req *web.OrderUpdateRequest) (*web.OrderResponse, error)
 	Delete(ctx context.Context, id uint) error
-	FindById(ctx context.Context, id uint) (*web.OrderResponse, error)
-	FindAll(ctx context.Context, page, pageSize int) (*pg.PaginatedResponse, error)
+	FindById(ctx context.Context, id, userId uint) (*web.OrderResponse, error)
+	FindAll(ctx context.Context, page, pageSize int, search string) (*pg.PaginatedResponse, error)
+	FindByUser(ctx context.Context, page, pageSize int, req *web.OrderFilterRequest) (*pg.PaginatedResponse, error)
 	// FindByOrderId(ctx context.Context, id uint) ([]*web.OrderResponse, error)
 	ConfirmOrder(ctx context.Context, req *web.OrderUpdateStatusRequest) (*web.OrderResponse, error)
+	CancelOrder(ctx context.Context, req *web.OrderCancelRequest) (*web.OrderResponse, error)
+	CancelOrderByAdmin(ctx context.Context, req *web.OrderCancelRequest) (*web.OrderResponse, error)
+	FindHistory(ctx context.Context, id, userId uint) ([]*web.OrderHistoryResponse, error)
+	AddItem(ctx context.Context, req *web.OrderItemRequest) (*web.OrderResponse, error)
+	UpdateItemQty(ctx context.Context, req *web.OrderItemRequest) (*web.OrderResponse, error)
+	RemoveItem(ctx context.Context, id, productId, variantId, userId, actorId uint) (*web.OrderResponse, error)
+	ExpireOrders(ctx context.Context) (int, error)
+	Invoice(ctx context.Context, id, userId uint) (*web.OrderDocumentResponse, error)
+	PackingSlip(ctx context.Context, id uint) (*web.OrderDocumentResponse, error)
 }
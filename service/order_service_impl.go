@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"os"
 	"simple-toko/entity"
 	"simple-toko/helper"
 	"simple-toko/repository"
@@ -13,6 +14,7 @@ import (
 	pg "simple-toko/web"
 	adrs "simple-toko/web/address"
 	web "simple-toko/web/order"
+	"strconv"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -20,18 +22,20 @@ import (
 )
 
 type orderServiceImpl struct {
-	OrderRepository  repository.OrderRepository
-	AddressRepostory repository.AddressRepository
-	Validate         *validator.Validate
-	Redis            *redis.Client
+	OrderRepository   repository.OrderRepository
+	AddressRepostory  repository.AddressRepository
+	PaymentRepository repository.PaymentRepository
+	Validate          *validator.Validate
+	Redis             *redis.Client
 }
 
-func NewOrderServiceImpl(orderRepository repository.OrderRepository, addressRepostory repository.AddressRepository, validate *validator.Validate, redis *redis.Client) *orderServiceImpl {
+func NewOrderServiceImpl(orderRepository repository.OrderRepository, addressRepostory repository.AddressRepository, paymentRepository repository.PaymentRepository, validate *validator.Validate, redis *redis.Client) *orderServiceImpl {
 	return &orderServiceImpl{
-		OrderRepository:  orderRepository,
-		AddressRepostory: addressRepostory,
-		Validate:         validate,
-		Redis:            redis,
+		OrderRepository:   orderRepository,
+		AddressRepostory:  addressRepostory,
+		PaymentRepository: paymentRepository,
+		Validate:          validate,
+		Redis:             redis,
 	}
 }
 
@@ -41,6 +45,12 @@ var (
 	ErrOrderNotFound   = errors.New("order not found")
 	ErrAddressNotFound = errors.New("address not found")
 	ErrInvalidAddress  = errors.New("invalid input address")
+	ErrOrderNotCancel  = errors.New("order cannot be canceled")
+
+	ErrOrderStatusChanged  = errors.New("order status has changed, please retry")
+	ErrPaymentNotConfirmed = errors.New("payment has not been confirmed")
+	ErrOrderNotEditable    = errors.New("order can no longer be edited")
+	ErrOrderItemNotFound   = errors.New("order item not found")
 )
 
 func (o *orderServiceImpl) CreateOrder(ctx context.Context, req *web.OrderCreateRequest) (*web.OrderResponse, error) {
@@ -53,17 +63,31 @@ func (o *orderServiceImpl) CreateOrder(ctx context.Context, req *web.OrderCreate
 		return nil, ErrAddressNotFound
 	}
 
+	payBefore := time.Now().Add(orderPayDeadline())
+
 	order := entity.Order{
 		UserID:        req.UserID,
 		AddressID:     req.AddressID,
-		OrderProducts: make([]entity.OrderProduct, len(req.OrderProducts)),
+		PayBefore:     &payBefore,
+		OrderProducts: make([]entity.OrderProduct, 0, len(req.OrderProducts)),
 	}
 
-	for i, v := range req.OrderProducts {
-		order.OrderProducts[i] = entity.OrderProduct{
+	//merge duplicate lines, order_id, product_id and variant_id are unique
+	type lineKey struct{ productId, variantId uint }
+	lines := map[lineKey]int{}
+	for _, v := range req.OrderProducts {
+		key := lineKey{v.ProductID, v.VariantID}
+		if i, ok := lines[key]; ok {
+			order.OrderProducts[i].Qty += v.Qty
+			continue
+		}
+
+		lines[key] = len(order.OrderProducts)
+		order.OrderProducts = append(order.OrderProducts, entity.OrderProduct{
 			ProductID: v.ProductID,
+			VariantID: v.VariantID,
 			Qty:       v.Qty,
-		}
+		})
 	}
 
 	result, err := o.OrderRepository.CreateOrder(ctx, &order)
@@ -77,10 +101,17 @@ func (o *orderServiceImpl) CreateOrder(ctx context.Context, req *web.OrderCreate
 			return nil, ErrNotEnoughStock
 		}
 
+		if err := variantErr(err); err != nil {
+			return nil, err
+		}
+
 		return nil, fmt.Errorf("order service: create order: %w", err)
 	}
 
-	utils.InvalidateCached(ctx, o.Redis, result.ID)
+	for _, v := range result.OrderProducts {
+		utils.InvalidateCached(ctx, o.Redis, v.ProductID)
+	}
+	utils.InvalidateOrderCached(ctx, o.Redis, result.ID)
 
 	response := helper.ToOrderResponse(result)
 
@@ -100,6 +131,10 @@ func (o *orderServiceImpl) UpdateAddress(ctx context.Context, req *web.OrderUpda
 		return nil, fmt.Errorf("order service: find order update address: %w", err)
 	}
 
+	if req.UserID != 0 && order.UserID != req.UserID {
+		return nil, ErrOrderNotFound
+	}
+
 	if _, err := o.AddressRepostory.FindByIdAndUserId(ctx, req.AddressID, order.UserID); err != nil {
 		return nil, ErrAddressNotFound
 	}
@@ -114,7 +149,7 @@ func (o *orderServiceImpl) UpdateAddress(ctx context.Context, req *web.OrderUpda
 		return nil, fmt.Errorf("order service: update address: %w", err)
 	}
 
-	utils.InvalidateCached(ctx, o.Redis, result.ID)
+	utils.InvalidateOrderCached(ctx, o.Redis, result.ID)
 
 	response := helper.ToOrderResponse(result)
 	return response, nil
@@ -128,17 +163,20 @@ func (o *orderServiceImpl) Delete(ctx context.Context, id uint) error {
 		return fmt.Errorf("order service: delete order: %w", err)
 	}
 
-	utils.InvalidateCached(ctx, o.Redis, id)
+	utils.InvalidateOrderCached(ctx, o.Redis, id)
 	return nil
 }
 
-func (o *orderServiceImpl) FindById(ctx context.Context, id uint) (*web.OrderResponse, error) {
+func (o *orderServiceImpl) FindById(ctx context.Context, id, userId uint) (*web.OrderResponse, error) {
 	cacheKey := fmt.Sprintf("orders:%d", id)
 
 	cached, err := o.Redis.Get(ctx, cacheKey).Result()
 	if err == nil {
 		var response web.OrderResponse
 		if err := json.Unmarshal([]byte(cached), &response); err == nil {
+			if userId != 0 && response.UserID != userId {
+				return nil, ErrOrderNotFound
+			}
 			return &response, nil
 		}
 
@@ -160,11 +198,15 @@ func (o *orderServiceImpl) FindById(ctx context.Context, id uint) (*web.OrderRes
 	if err := o.Redis.Set(ctx, cacheKey, jsonData, 5*time.Minute).Err(); err != nil {
 		fmt.Printf("Redis set error: %v\n", err)
 	}
+
+	if userId != 0 && response.UserID != userId {
+		return nil, ErrOrderNotFound
+	}
 	return response, nil
 }
 
-func (o *orderServiceImpl) FindAll(ctx context.Context, page, pageSize int) (*pg.PaginatedResponse, error) {
-	cacheKey := fmt.Sprintf("orders:page=%d:size=%d", page, pageSize)
+func (o *orderServiceImpl) FindAll(ctx context.Context, page, pageSize int, search string) (*pg.PaginatedResponse, error) {
+	cacheKey := fmt.Sprintf("orders:page=%d:size=%d:search=%s", page, pageSize, search)
 	cached, err := o.Redis.Get(ctx, cacheKey).Result()
 	if err == nil {
 		var paginateResp pg.PaginatedResponse
@@ -175,7 +217,7 @@ func (o *orderServiceImpl) FindAll(ctx context.Context, page, pageSize int) (*pg
 		fmt.Printf("Redis error: %v\n", err)
 	}
 
-	result, totalItems, err := o.OrderRepository.FindAll(ctx, page, pageSize)
+	result, totalItems, err := o.OrderRepository.FindAll(ctx, page, pageSize, &entity.OrderFilter{Search: search})
 	if err != nil {
 		return nil, fmt.Errorf("order service: find all order: %w", err)
 	}
@@ -196,8 +238,11 @@ func (o *orderServiceImpl) FindAll(ctx context.Context, page, pageSize int) (*pg
 			})
 		}
 		response := web.OrderResponse{
-			ID:        v.ID,
-			AmountPay: v.AmountPay,
+			ID:            v.ID,
+			Code:          v.Code,
+			InvoiceNumber: v.InvoiceNumber,
+			AmountPay:     v.AmountPay,
+			UserID:        v.UserID,
 			User: adrs.UserInfo{
 				Name:  v.User.Name,
 				Email: v.User.Email,
@@ -209,6 +254,7 @@ func (o *orderServiceImpl) FindAll(ctx context.Context, page, pageSize int) (*pg
 			OrderProducts:  orderProducts,
 			StatusOrder:    v.StatusOrder,
 			StatusDelivery: v.StatusDelivery,
+			PayBefore:      v.PayBefore,
 			CreatedAt:      v.CreatedAt,
 			UpdatedAt:      v.UpdatedAt,
 		}
@@ -228,6 +274,63 @@ func (o *orderServiceImpl) FindAll(ctx context.Context, page, pageSize int) (*pg
 	return paginateResp, nil
 }
 
+func (o *orderServiceImpl) FindByUser(ctx context.Context, page, pageSize int, req *web.OrderFilterRequest) (*pg.PaginatedResponse, error) {
+	if err := o.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	filter := entity.OrderFilter{
+		UserID:         req.UserID,
+		StatusOrder:    req.StatusOrder,
+		StatusDelivery: req.StatusDelivery,
+	}
+
+	if req.DateFrom != "" {
+		from, _ := time.ParseInLocation("2006-01-02", req.DateFrom, time.Local)
+		filter.CreatedFrom = &from
+	}
+
+	if req.DateTo != "" {
+		to, _ := time.ParseInLocation("2006-01-02", req.DateTo, time.Local)
+		to = to.AddDate(0, 0, 1)
+		filter.CreatedTo = &to
+	}
+
+	cacheKey := fmt.Sprintf("orders:page=%d:size=%d:user=%d:status=%s:delivery=%s:from=%s:to=%s",
+		page, pageSize, req.UserID, req.StatusOrder, req.StatusDelivery, req.DateFrom, req.DateTo)
+
+	cached, err := o.Redis.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var paginateResp pg.PaginatedResponse
+		if err := json.Unmarshal([]byte(cached), &paginateResp); err == nil {
+			return &paginateResp, nil
+		}
+	} else if err != redis.Nil {
+		fmt.Printf("Redis error: %v\n", err)
+	}
+
+	result, totalItems, err := o.OrderRepository.FindAll(ctx, page, pageSize, &filter)
+	if err != nil {
+		return nil, fmt.Errorf("order service: find by user: %w", err)
+	}
+
+	responses := make([]*web.OrderResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToOrderResponse(v))
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	paginateResp := helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses)
+
+	jsonData, _ := json.Marshal(paginateResp)
+	if err := o.Redis.Set(ctx, cacheKey, jsonData, 5*time.Minute).Err(); err != nil {
+		fmt.Printf("Redis set error: %v\n", err)
+	}
+
+	return paginateResp, nil
+}
+
 // func (o orderServiceImpl) FindByOrderId(ctx context.Context, id uint) ([]*web.OrderResponse, error) {
 
 // }
@@ -237,26 +340,341 @@ func (o *orderServiceImpl) ConfirmOrder(ctx context.Context, req *web.OrderUpdat
 		return nil, ErrorValidation
 	}
 
-	order := entity.Order{}
+	order, err := o.OrderRepository.FindById(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("order service: find id order confirm: %w", err)
+	}
+
+	from := orderStateOf(order)
+	to := OrderState(req.Status)
+
+	if err := checkTransition(from, to); err != nil {
+		return nil, err
+	}
+
+	if to == StateConfirmed {
+		pay, err := o.PaymentRepository.FindByOrderId(ctx, order.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrPaymentNotFound) {
+				return nil, ErrPaymentNotConfirmed
+			}
+			return nil, fmt.Errorf("order service: find payment order confirm: %w", err)
+		}
+
+		if pay.Status != repository.Confirmed {
+			return nil, ErrPaymentNotConfirmed
+		}
+	}
+
+	return o.changeStatus(ctx, order, to, req.UserID, req.Reason)
+}
+
+func (o *orderServiceImpl) CancelOrder(ctx context.Context, req *web.OrderCancelRequest) (*web.OrderResponse, error) {
+	if err := o.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	order, err := o.OrderRepository.FindById(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("order service: find order cancel: %w", err)
+	}
 
-	if req.StatusOrder != nil {
-		order.StatusOrder = *req.StatusOrder
+	if order.UserID != req.UserID {
+		return nil, ErrOrderNotFound
 	}
 
-	if req.StatusDelivery != nil {
-		order.StatusDelivery = *req.StatusDelivery
+	if orderStateOf(order) != StateWaiting {
+		return nil, ErrOrderNotCancel
 	}
 
-	result, err := o.OrderRepository.ConfirmOrder(ctx, req.ID, &order)
+	return o.changeStatus(ctx, order, StateCanceled, req.UserID, req.Reason)
+}
+
+func (o *orderServiceImpl) CancelOrderByAdmin(ctx context.Context, req *web.OrderCancelRequest) (*web.OrderResponse, error) {
+	if err := o.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	order, err := o.OrderRepository.FindById(ctx, req.ID)
 	if err != nil {
 		if errors.Is(err, repository.ErrOrderNotFound) {
 			return nil, ErrOrderNotFound
 		}
-		return nil, fmt.Errorf("order service: find id order confirm: %w", err)
+		return nil, fmt.Errorf("order service: find order cancel: %w", err)
+	}
+
+	if err := checkTransition(orderStateOf(order), StateCanceled); err != nil {
+		return nil, err
+	}
+
+	return o.changeStatus(ctx, order, StateCanceled, req.UserID, req.Reason)
+}
+
+func (o *orderServiceImpl) FindHistory(ctx context.Context, id, userId uint) ([]*web.OrderHistoryResponse, error) {
+	order, err := o.OrderRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("order service: find order history: %w", err)
+	}
+
+	// userId 0 is an admin, who may read any order
+	if userId != 0 && order.UserID != userId {
+		return nil, ErrOrderNotFound
+	}
+
+	result, err := o.OrderRepository.FindHistory(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("order service: find history: %w", err)
+	}
+
+	responses := make([]*web.OrderHistoryResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToOrderHistoryResponse(v))
+	}
+
+	return responses, nil
+}
+
+func (o *orderServiceImpl) Invoice(ctx context.Context, id, userId uint) (*web.OrderDocumentResponse, error) {
+	order, err := o.OrderRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("order service: find order invoice: %w", err)
+	}
+
+	if userId != 0 && order.UserID != userId {
+		return nil, ErrOrderNotFound
+	}
+
+	paymentStatus := "unpaid"
+	pay, err := o.PaymentRepository.FindByOrderId(ctx, order.ID)
+	if err != nil && !errors.Is(err, repository.ErrPaymentNotFound) {
+		return nil, fmt.Errorf("order service: find payment invoice: %w", err)
+	}
+	if pay != nil {
+		paymentStatus = pay.Status
+	}
+
+	// customers only get the final invoice, admins may print a proforma
+	if userId != 0 && paymentStatus != repository.Confirmed {
+		return nil, ErrPaymentNotConfirmed
+	}
+
+	name := order.InvoiceNumber
+	if name == "" {
+		name = order.Code
+	}
+
+	return &web.OrderDocumentResponse{
+		FileName: fmt.Sprintf("invoice-%s.pdf", name),
+		Content:  helper.ToInvoicePdf(order, paymentStatus),
+	}, nil
+}
+
+func (o *orderServiceImpl) PackingSlip(ctx context.Context, id uint) (*web.OrderDocumentResponse, error) {
+	order, err := o.OrderRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("order service: find order packing slip: %w", err)
+	}
+
+	return &web.OrderDocumentResponse{
+		FileName: fmt.Sprintf("packing-slip-%s.pdf", order.Code),
+		Content:  helper.ToPackingSlipPdf(order),
+	}, nil
+}
+
+func (o *orderServiceImpl) changeStatus(ctx context.Context, order *entity.Order, to OrderState, userId uint, reason string) (*web.OrderResponse, error) {
+	history := entity.OrderStatusHistory{
+		FromStatus: string(orderStateOf(order)),
+		ToStatus:   string(to),
+		Reason:     reason,
+	}
+
+	if userId != 0 {
+		history.UserID = &userId
+	}
+
+	var result *entity.Order
+	var err error
+
+	if to == StateCanceled {
+		result, err = o.OrderRepository.CancelOrder(ctx, order, &history)
+	} else {
+		statusOrder, statusDelivery := to.columns()
+		result, err = o.OrderRepository.ChangeStatus(ctx, order, statusOrder, statusDelivery, &history)
+	}
+
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderStatusChanged) {
+			return nil, ErrOrderStatusChanged
+		}
+		if errors.Is(err, repository.ErrStockNotRestorable) {
+			return nil, fmt.Errorf("%w: %v", ErrStockNotRestorable, err)
+		}
+		return nil, fmt.Errorf("order service: change status: %w", err)
+	}
+
+	if to == StateCanceled {
+		for _, v := range result.OrderProducts {
+			utils.InvalidateCached(ctx, o.Redis, v.ProductID)
+		}
+	}
+	utils.InvalidateOrderCached(ctx, o.Redis, result.ID)
+
+	response := helper.ToOrderResponse(result)
+	return response, nil
+}
+
+func (o *orderServiceImpl) AddItem(ctx context.Context, req *web.OrderItemRequest) (*web.OrderResponse, error) {
+	if err := o.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if err := o.checkOwner(ctx, req.ID, req.UserID); err != nil {
+		return nil, err
+	}
+
+	item := entity.OrderProduct{
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Qty:       req.Qty,
 	}
 
-	utils.InvalidateCached(ctx, o.Redis, result.ID)
+	result, err := o.OrderRepository.AddOrderItem(ctx, req.ID, &item, req.ActorID)
+	if err != nil {
+		return nil, o.orderItemErr(err, "add item")
+	}
+
+	utils.InvalidateCached(ctx, o.Redis, req.ProductID)
+	utils.InvalidateOrderCached(ctx, o.Redis, result.ID)
+
+	response := helper.ToOrderResponse(result)
+	return response, nil
+}
+
+func (o *orderServiceImpl) UpdateItemQty(ctx context.Context, req *web.OrderItemRequest) (*web.OrderResponse, error) {
+	if err := o.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if err := o.checkOwner(ctx, req.ID, req.UserID); err != nil {
+		return nil, err
+	}
+
+	result, err := o.OrderRepository.UpdateOrderQty(ctx, req.ID, req.ProductID, req.VariantID, req.Qty, req.ActorID)
+	if err != nil {
+		return nil, o.orderItemErr(err, "update item qty")
+	}
+
+	utils.InvalidateCached(ctx, o.Redis, req.ProductID)
+	utils.InvalidateOrderCached(ctx, o.Redis, result.ID)
+
+	response := helper.ToOrderResponse(result)
+	return response, nil
+}
+
+func (o *orderServiceImpl) RemoveItem(ctx context.Context, id, productId, variantId, userId, actorId uint) (*web.OrderResponse, error) {
+	if err := o.checkOwner(ctx, id, userId); err != nil {
+		return nil, err
+	}
+
+	result, err := o.OrderRepository.RemoveOrderItem(ctx, id, productId, variantId, actorId)
+	if err != nil {
+		return nil, o.orderItemErr(err, "remove item")
+	}
+
+	utils.InvalidateCached(ctx, o.Redis, productId)
+	utils.InvalidateOrderCached(ctx, o.Redis, result.ID)
 
 	response := helper.ToOrderResponse(result)
 	return response, nil
 }
+
+func (o *orderServiceImpl) ExpireOrders(ctx context.Context) (int, error) {
+	now := time.Now()
+
+	orders, err := o.OrderRepository.FindExpired(ctx, now, now.Add(-orderPayDeadline()), 100)
+	if err != nil {
+		return 0, fmt.Errorf("order service: find expired: %w", err)
+	}
+
+	//one order that keeps failing must not hold back the ones after it, the
+	//failures are returned together and logged by the worker
+	expired := 0
+	var errs []error
+	for _, v := range orders {
+		if _, err := o.changeStatus(ctx, v, StateCanceled, 0, "payment deadline passed"); err != nil {
+			if errors.Is(err, ErrOrderStatusChanged) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("order service: expire order %d: %w", v.ID, err))
+			continue
+		}
+		expired++
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+func orderPayDeadline() time.Duration {
+	deadline, err := strconv.Atoi(os.Getenv("ORDER_PAY_DEADLINE"))
+	if err != nil || deadline < 1 {
+		deadline = 24
+	}
+
+	return time.Duration(deadline) * time.Hour
+}
+
+func (o *orderServiceImpl) checkOwner(ctx context.Context, id, userId uint) error {
+	order, err := o.OrderRepository.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return ErrOrderNotFound
+		}
+		return fmt.Errorf("order service: find order: %w", err)
+	}
+
+	if userId != 0 && order.UserID != userId {
+		return ErrOrderNotFound
+	}
+
+	return nil
+}
+
+func (o *orderServiceImpl) orderItemErr(err error, action string) error {
+	switch {
+	case errors.Is(err, repository.ErrOrderNotFound):
+		return ErrOrderNotFound
+	case errors.Is(err, repository.ErrOrderNotEditable):
+		return ErrOrderNotEditable
+	case errors.Is(err, repository.ErrOrderItemNotFound):
+		return ErrOrderItemNotFound
+	case errors.Is(err, repository.ErrProductNotFound):
+		return ErrProductNotFound
+	case errors.Is(err, repository.ErrNotEnoughStock):
+		return ErrNotEnoughStock
+	case errors.Is(err, repository.ErrEmptyItems):
+		return ErrEmptyItems
+	case errors.Is(err, repository.ErrVariantNotFound):
+		return ErrVariantNotFound
+	case errors.Is(err, repository.ErrVariantRequired):
+		return ErrVariantRequired
+	case errors.Is(err, repository.ErrStockNotRestorable):
+		return fmt.Errorf("%w: %v", ErrStockNotRestorable, err)
+	default:
+		return fmt.Errorf("order service: %s: %w", action, err)
+	}
+}
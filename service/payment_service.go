@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"net/url"
 	web "simple-toko/web/payment"
 	pg "simple-toko/web"
 )
@@ -10,8 +11,10 @@ type PaymentService interface {
 	UploadPayment(ctx context.Context, req *web.PaymentCreateRequest) (*web.PaymentResponse, error)
 	UpdateStatus(ctx context.Context, req *web.PaymentUpdateRequest) (*web.PaymentResponse, error)
 	FindById(ctx context.Context, id uint) (*web.PaymentResponse, error)
-	FindByOrderId(ctx context.Context, orerId uint) (*web.PaymentResponse, error)
+	FindByOrderId(ctx context.Context, orerId, userId uint) (*web.PaymentResponse, error)
 	FindAll(ctx context.Context, page, pageSize int) (*pg.PaginatedResponse, error)
 	Delete(ctx context.Context, id uint) error
+	CreateLink(ctx context.Context, req *web.PaymentLinkRequest) (*web.PaymentLinkResponse, error)
+	CheckLink(ctx context.Context, image string, query url.Values) error
 	//UpdatePayment(ctx context.Context, req *web.PaymentUpdateRequest) (*web.PaymentResponse, error)
 }
@@ -5,29 +5,49 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/url"
+	"os"
 	"simple-toko/entity"
 	"simple-toko/helper"
 	"simple-toko/repository"
+	"simple-toko/utils"
 	pg "simple-toko/web"
 	web "simple-toko/web/payment"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
 )
 
 type paymentServiceImpl struct {
 	PaymentRepo repository.PaymentRepository
 	OrderRepo repository.OrderRepository
 	Validate    *validator.Validate
+	Redis       *redis.Client
 }
 
-func NewPaymentServiceImpl(paymentRepo repository.PaymentRepository, orderRepo repository.OrderRepository, validate *validator.Validate) *paymentServiceImpl {
+func NewPaymentServiceImpl(paymentRepo repository.PaymentRepository, orderRepo repository.OrderRepository, validate *validator.Validate, redis *redis.Client) *paymentServiceImpl {
 	return &paymentServiceImpl{
 		PaymentRepo: paymentRepo,
 		OrderRepo: orderRepo,
 		Validate:    validate,
+		Redis:       redis,
 	}
 }
 
+var (
+	ErrPaymentNotFound       = errors.New("payment not found")
+	ErrPaymentNotConfirmable = errors.New("payment of a canceled order cannot be confirmed")
+	ErrLinkInvalid           = errors.New("link signature is invalid")
+	ErrLinkExpired           = errors.New("link expired")
+	ErrOrderNotPayable       = errors.New("order is no longer waiting for payment")
+)
+
+// paymentLinkScope is the folder of the proofs, handler.Path, so a link for a
+// payment file cannot be reused for another folder.
+const paymentLinkScope = "payment/"
+
 func (pay *paymentServiceImpl) UploadPayment(ctx context.Context, req *web.PaymentCreateRequest) (*web.PaymentResponse, error) {
 	if err := pay.Validate.Struct(req); err != nil {
 		return nil, ErrorValidation
@@ -35,7 +55,7 @@ func (pay *paymentServiceImpl) UploadPayment(ctx context.Context, req *web.Payme
 
 	orderId := req.OrderID
 
-	_, err := pay.OrderRepo.FindById(ctx, orderId)
+	order, err := pay.OrderRepo.FindById(ctx, orderId)
 	if err != nil {
 		if errors.Is(err, repository.ErrOrderNotFound) {
 			return nil, ErrOrderNotFound
@@ -43,14 +63,26 @@ func (pay *paymentServiceImpl) UploadPayment(ctx context.Context, req *web.Payme
 		return nil, fmt.Errorf("payment service: find order, upload pay: %w", err)
 	}
 
+	if req.UserID != 0 && order.UserID != req.UserID {
+		return nil, ErrOrderNotFound
+	}
+
 	data := entity.Payment{
 		OrderID: req.OrderID,
 		Image:   req.Image,
 		Status: repository.Waiting,
 	}
 
-	result, err := pay.PaymentRepo.UploadPayment(ctx, &data)
+	now := time.Now()
+
+	result, err := pay.PaymentRepo.UploadPayment(ctx, &data, now, now.Add(-orderPayDeadline()))
 	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			return nil, ErrOrderNotFound
+		case errors.Is(err, repository.ErrOrderNotPayable):
+			return nil, ErrOrderNotPayable
+		}
 		return nil, fmt.Errorf("payment service: upload payment: %w", err)
 	}
 
@@ -70,6 +102,9 @@ func (pay *paymentServiceImpl) UpdateStatus(ctx context.Context, req *web.Paymen
 		if errors.Is(err, repository.ErrOrderNotFound) {
 			return nil, ErrOrderNotFound
 		}
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, ErrPaymentNotFound
+		}
 		return nil, fmt.Errorf("payment service: find order, update status: %w", err)
 	}
 
@@ -79,9 +114,14 @@ func (pay *paymentServiceImpl) UpdateStatus(ctx context.Context, req *web.Paymen
 
 	result, err := pay.PaymentRepo.UpdateStatus(ctx, order)
 	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotConfirmable) {
+			return nil, ErrPaymentNotConfirmable
+		}
 		return nil, fmt.Errorf("payment service: update status: %w", err)
 	}
 
+	utils.InvalidateOrderCached(ctx, pay.Redis, result.OrderID)
+
 	response := helper.ToPaymentResponse(result)
 	return response, nil
 }
@@ -99,15 +139,22 @@ func (pay *paymentServiceImpl) FindById(ctx context.Context, id uint) (*web.Paym
 	return response, nil
 }
 
-func (pay *paymentServiceImpl) FindByOrderId(ctx context.Context, orderId uint) (*web.PaymentResponse, error) {
+func (pay *paymentServiceImpl) FindByOrderId(ctx context.Context, orderId, userId uint) (*web.PaymentResponse, error) {
 	result, err := pay.PaymentRepo.FindByOrderId(ctx, orderId)
 	if err != nil {
 		if errors.Is(err, repository.ErrOrderNotFound) {
 			return nil, ErrOrderNotFound
 		}
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, ErrPaymentNotFound
+		}
 		return nil, fmt.Errorf("payment service: find order: %w", err)
 	}
 
+	if userId != 0 && result.Order.UserID != userId {
+		return nil, ErrOrderNotFound
+	}
+
 	response := helper.ToPaymentResponse(result)
 	return response, nil
 }
@@ -154,3 +201,90 @@ func (pay *paymentServiceImpl) Delete(ctx context.Context, id uint) error {
 
 	return nil
 }
+
+// CreateLink signs a download link of the payment proof. A customer always
+// gets a link scoped to themself, an admin may scope it to a customer or leave
+// it open for anyone holding it, for example a finance tool.
+func (pay *paymentServiceImpl) CreateLink(ctx context.Context, req *web.PaymentLinkRequest) (*web.PaymentLinkResponse, error) {
+	if err := pay.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	result, err := pay.PaymentRepo.FindByOrderId(ctx, req.OrderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, fmt.Errorf("payment service: find order, create link: %w", err)
+	}
+
+	scope := req.ScopeUserID
+	if req.UserID != 0 {
+		if result.Order.UserID != req.UserID {
+			return nil, ErrOrderNotFound
+		}
+		scope = req.UserID
+	}
+
+	if result.Image == "" {
+		return nil, ErrPaymentNotFound
+	}
+
+	expiresIn := req.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = paymentLinkTTL()
+	}
+
+	expiresAt := time.Now().Add(time.Duration(expiresIn) * time.Minute).Truncate(time.Second)
+
+	query, err := utils.SignFileLink(paymentLinkScope+result.Image, scope, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("payment service: %w", err)
+	}
+
+	return &web.PaymentLinkResponse{
+		OrderID:   result.OrderID,
+		Image:     result.Image,
+		UserID:    scope,
+		ExpiresAt: expiresAt,
+		Query:     query.Encode(),
+	}, nil
+}
+
+// CheckLink allows a download without a token. A link scoped to a customer
+// stops working once the proof is no longer on one of their orders.
+func (pay *paymentServiceImpl) CheckLink(ctx context.Context, image string, query url.Values) error {
+	userId, err := utils.VerifyFileLink(paymentLinkScope+image, query, time.Now())
+	if err != nil {
+		if errors.Is(err, utils.ErrLinkExpired) {
+			return ErrLinkExpired
+		}
+		return ErrLinkInvalid
+	}
+
+	result, err := pay.PaymentRepo.FindByImage(ctx, image)
+	if err != nil {
+		if errors.Is(err, repository.ErrPaymentNotFound) {
+			return ErrPaymentNotFound
+		}
+		return fmt.Errorf("payment service: find image, check link: %w", err)
+	}
+
+	if userId != 0 && result.Order.UserID != userId {
+		return ErrLinkInvalid
+	}
+
+	return nil
+}
+
+func paymentLinkTTL() int {
+	ttl, err := strconv.Atoi(os.Getenv("PAYMENT_LINK_TTL"))
+	if err != nil || ttl < 1 {
+		ttl = 60
+	}
+
+	return ttl
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	"io"
+	web "simple-toko/web/product"
+)
+
+type ProductImportService interface {
+	Create(ctx context.Context, userId uint, fileName string) (*web.ProductImportResponse, error)
+	FindById(ctx context.Context, id uint) (*web.ProductImportResponse, error)
+	RunPending(ctx context.Context) (int, error)
+	Export(ctx context.Context, w io.Writer) error
+}
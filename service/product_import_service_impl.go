@@ -0,0 +1,516 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	"simple-toko/storage"
+	"simple-toko/utils"
+	web "simple-toko/web/product"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type productImportServiceImpl struct {
+	ImportRepo    repository.ProductImportRepository
+	ProductRepo   repository.ProductRepository
+	InventoryRepo repository.InventoryRepository
+	CategoryRepo  repository.CategoryRepository
+	Validate      *validator.Validate
+	Redis         *redis.Client
+	Storage       storage.Storage
+}
+
+func NewProductImportServiceImpl(importRepo repository.ProductImportRepository, productRepo repository.ProductRepository, inventoryRepo repository.InventoryRepository, categoryRepo repository.CategoryRepository, validate *validator.Validate, redis *redis.Client, store storage.Storage) *productImportServiceImpl {
+	return &productImportServiceImpl{
+		ImportRepo:    importRepo,
+		ProductRepo:   productRepo,
+		InventoryRepo: inventoryRepo,
+		CategoryRepo:  categoryRepo,
+		Validate:      validate,
+		Redis:         redis,
+		Storage:       store,
+	}
+}
+
+const (
+	ProductImportPath = "import/"
+
+	importBatch     = 50  //rows between two progress saves
+	importMaxErrors = 500 //rows past this still count as failed but keep no message
+	importStale     = 10 * time.Minute
+	exportBatch     = 500
+)
+
+// The import reads the same columns the export writes, id is ignored and a
+// product is matched by sku. inventory_id wins over inventory_location, the
+// inventory column takes either an id or a location. categories are ids
+// separated by "|", without the column the categories are left as they are.
+var (
+	importColumns = []string{"sku", "name", "price", "stock", "description"}
+	exportColumns = []string{"id", "sku", "name", "price", "stock", "description", "inventory_id", "inventory_location", "categories"}
+
+	importFields = map[string]string{
+		"InventoryID": "inventory",
+		"SKU":         "sku",
+		"Name":        "name",
+		"Price":       "price",
+		"Stock":       "stock",
+		"Description": "description",
+		"CategoryIDs": "categories",
+	}
+)
+
+var (
+	ErrImportNotFound = errors.New("product import not found")
+	ErrImportFile     = errors.New("import file cannot be read")
+)
+
+func (p *productImportServiceImpl) Create(ctx context.Context, userId uint, fileName string) (*web.ProductImportResponse, error) {
+	job := entity.ProductImport{
+		UserID:   userId,
+		FileName: fileName,
+	}
+
+	result, err := p.ImportRepo.Create(ctx, &job)
+	if err != nil {
+		return nil, fmt.Errorf("product import service: create: %w", err)
+	}
+
+	response := helper.ToProductImportResponse(result)
+	return response, nil
+}
+
+func (p *productImportServiceImpl) FindById(ctx context.Context, id uint) (*web.ProductImportResponse, error) {
+	result, err := p.ImportRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrImportNotFound) {
+			return nil, ErrImportNotFound
+		}
+		return nil, fmt.Errorf("product import service: find by id: %w", err)
+	}
+
+	response := helper.ToProductImportResponse(result)
+	return response, nil
+}
+
+// RunPending works through the queued imports one after another and returns
+// how many were finished.
+func (p *productImportServiceImpl) RunPending(ctx context.Context) (int, error) {
+	total := 0
+
+	for {
+		job, err := p.ImportRepo.Claim(ctx, time.Now().Add(-importStale))
+		if err != nil {
+			return total, fmt.Errorf("product import service: claim: %w", err)
+		}
+
+		if job == nil {
+			return total, nil
+		}
+
+		if err := p.run(ctx, job); err != nil {
+			return total, err
+		}
+
+		total++
+	}
+}
+
+func (p *productImportServiceImpl) run(ctx context.Context, job *entity.ProductImport) error {
+	records, err := readCsv(ctx, p.Storage, ProductImportPath+job.FileName)
+	if err != nil {
+		return p.finish(ctx, job, importFileError(err), nil)
+	}
+
+	header := map[string]int{}
+	for i, v := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(v))] = i
+	}
+
+	for _, v := range importColumns {
+		if _, ok := header[v]; !ok {
+			return p.finish(ctx, job, fmt.Sprintf("column %s is missing", v), nil)
+		}
+	}
+
+	_, hasId := header["inventory_id"]
+	_, hasLocation := header["inventory_location"]
+	_, hasInventory := header["inventory"]
+	if !hasId && !hasLocation && !hasInventory {
+		return p.finish(ctx, job, "column inventory, inventory_id or inventory_location is missing", nil)
+	}
+
+	rows := records[1:]
+	job.TotalRows = len(rows)
+
+	state := importState{
+		job:         job,
+		header:      header,
+		inventories: map[string]uint{},
+		seen:        map[string]int{},
+	}
+
+	var errs []entity.ProductImportError
+	changed := false
+
+	for i, v := range rows {
+		row := i + 2 //the header is the first line of the file
+		sku := state.value(v, "sku")
+
+		created, err := p.importRow(ctx, &state, v)
+		switch {
+		case err != nil:
+			job.Failed++
+			if job.Failed <= importMaxErrors {
+				errs = append(errs, entity.ProductImportError{Row: row, SKU: truncate(sku, 64), Message: truncate(err.Error(), 500)})
+			}
+		case created:
+			job.Created++
+			changed = true
+		default:
+			job.Updated++
+			changed = true
+		}
+
+		//a failed row saved nothing, a later row may still use its sku
+		if err == nil {
+			state.seen[sku] = row
+		}
+		job.Processed++
+
+		if job.Processed%importBatch == 0 {
+			if err := p.ImportRepo.Progress(ctx, job, errs); err != nil {
+				return fmt.Errorf("product import service: progress: %w", err)
+			}
+			errs = nil
+
+			if changed {
+				utils.InvalidateCategoryCached(ctx, p.Redis)
+				changed = false
+			}
+		}
+	}
+
+	if changed {
+		utils.InvalidateCategoryCached(ctx, p.Redis)
+	}
+
+	return p.finish(ctx, job, "", errs)
+}
+
+type importState struct {
+	job         *entity.ProductImport
+	header      map[string]int
+	inventories map[string]uint
+	seen        map[string]int
+}
+
+func (s *importState) value(record []string, column string) string {
+	i, ok := s.header[column]
+	if !ok || i >= len(record) {
+		return ""
+	}
+
+	return strings.TrimSpace(record[i])
+}
+
+// importRow checks one record with the rules of ProductCreateRequest and
+// saves it, the returned error is the message for the report.
+func (p *productImportServiceImpl) importRow(ctx context.Context, state *importState, record []string) (bool, error) {
+	sku := state.value(record, "sku")
+	if sku == "" {
+		return false, errors.New("sku is required")
+	}
+
+	if row, ok := state.seen[sku]; ok {
+		return false, fmt.Errorf("sku is already used on row %d", row)
+	}
+
+	req := web.ProductCreateRequest{
+		SKU:         sku,
+		Name:        state.value(record, "name"),
+		Description: state.value(record, "description"),
+	}
+
+	var problems []string
+	reported := map[string]bool{} //a column that failed to parse is not validated again
+
+	if v := state.value(record, "price"); v != "" {
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			problems = append(problems, "price is not a number")
+			reported["price"] = true
+		}
+		req.Price = price
+	}
+
+	if v := state.value(record, "stock"); v != "" {
+		stock, err := strconv.Atoi(v)
+		if err != nil {
+			problems = append(problems, "stock is not a whole number")
+			reported["stock"] = true
+		}
+		req.Stock = stock
+	}
+
+	inventoryId, err := p.findInventory(ctx, state, record)
+	if err != nil {
+		problems = append(problems, err.Error())
+		reported["inventory"] = true
+	}
+	req.InventoryID = inventoryId
+
+	_, hasCategories := state.header["categories"]
+	if v := state.value(record, "categories"); v != "" {
+		for _, c := range strings.Split(v, "|") {
+			id, err := strconv.ParseUint(strings.TrimSpace(c), 10, 64)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("category %q is not an id", c))
+				reported["categories"] = true
+				continue
+			}
+			req.CategoryIDs = append(req.CategoryIDs, uint(id))
+		}
+	}
+
+	if err := p.Validate.Struct(&req); err != nil {
+		var verrs validator.ValidationErrors
+		if !errors.As(err, &verrs) {
+			return false, err
+		}
+
+		for _, v := range verrs {
+			if !reported[importFields[v.Field()]] {
+				problems = append(problems, validationMessage(v))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return false, errors.New(strings.Join(problems, ", "))
+	}
+
+	product := entity.Product{
+		InventoryID: req.InventoryID,
+		SKU:         productSku(req.SKU),
+		Name:        req.Name,
+		Price:       req.Price,
+		Stock:       req.Stock,
+		Description: req.Description,
+	}
+
+	if hasCategories {
+		product.Categories = []entity.Category{}
+
+		if len(req.CategoryIDs) > 0 {
+			categories, err := p.CategoryRepo.FindByIds(ctx, req.CategoryIDs)
+			if err != nil {
+				return false, fmt.Errorf("failed find categories: %w", err)
+			}
+
+			found := map[uint]bool{}
+			for _, v := range categories {
+				found[v.ID] = true
+				product.Categories = append(product.Categories, *v)
+			}
+
+			for _, v := range req.CategoryIDs {
+				if !found[v] {
+					return false, fmt.Errorf("category %d not found", v)
+				}
+			}
+		}
+	}
+
+	move := entity.StockMovement{
+		Reason:  repository.StockAdjustment,
+		RefType: repository.RefImport,
+		RefID:   state.job.ID,
+		UserID:  stockActor(state.job.UserID),
+	}
+
+	created, err := p.ProductRepo.Upsert(ctx, &product, &move)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSkuExists):
+			return false, errors.New("sku is used by a product variant")
+		case errors.Is(err, repository.ErrSkuDeleted), errors.Is(err, repository.ErrVariantStock):
+			return false, err
+		default:
+			return false, fmt.Errorf("failed save product: %w", err)
+		}
+	}
+
+	return created, nil
+}
+
+// findInventory resolves the inventory columns to an id, lookups are kept for
+// the rest of the file.
+func (p *productImportServiceImpl) findInventory(ctx context.Context, state *importState, record []string) (uint, error) {
+	key := ""
+	byId := false
+
+	switch {
+	case state.value(record, "inventory_id") != "":
+		key, byId = state.value(record, "inventory_id"), true
+	case state.value(record, "inventory_location") != "":
+		key = state.value(record, "inventory_location")
+	case state.value(record, "inventory") != "":
+		key = state.value(record, "inventory")
+		_, err := strconv.ParseUint(key, 10, 64)
+		byId = err == nil
+	default:
+		return 0, errors.New("inventory is required")
+	}
+
+	cacheKey := fmt.Sprintf("%t:%s", byId, key)
+	if id, ok := state.inventories[cacheKey]; ok {
+		if id == 0 {
+			return 0, fmt.Errorf("inventory %s not found", key)
+		}
+		return id, nil
+	}
+
+	var inv *entity.Inventory
+	var err error
+
+	if byId {
+		id, perr := strconv.ParseUint(key, 10, 64)
+		if perr != nil {
+			return 0, errors.New("inventory_id is not a number")
+		}
+		inv, err = p.InventoryRepo.FindById(ctx, uint(id))
+	} else {
+		inv, err = p.InventoryRepo.FindByLocation(ctx, key)
+	}
+
+	if err != nil {
+		if errors.Is(err, repository.ErrorIdNotFound) {
+			state.inventories[cacheKey] = 0
+			return 0, fmt.Errorf("inventory %s not found", key)
+		}
+		return 0, fmt.Errorf("failed find inventory: %w", err)
+	}
+
+	state.inventories[cacheKey] = inv.ID
+	return inv.ID, nil
+}
+
+// finish saves the final state of the import, a non empty message fails the
+// whole file. The uploaded file is not needed anymore.
+func (p *productImportServiceImpl) finish(ctx context.Context, job *entity.ProductImport, message string, errs []entity.ProductImportError) error {
+	now := time.Now()
+
+	job.Status = repository.ImportDone
+	if message != "" {
+		job.Status = repository.ImportFailed
+		job.Error = truncate(message, 500)
+	}
+	job.FinishedAt = &now
+
+	if err := p.ImportRepo.Progress(ctx, job, errs); err != nil {
+		return fmt.Errorf("product import service: finish: %w", err)
+	}
+
+	if err := p.Storage.Delete(ctx, ProductImportPath+job.FileName); err != nil {
+		fmt.Printf("failed remove import file %s: %v\n", job.FileName, err)
+	}
+
+	return nil
+}
+
+// Export writes the whole catalog as csv, the writer is flushed after every
+// batch so the client receives the file while it is read.
+func (p *productImportServiceImpl) Export(ctx context.Context, w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(exportColumns); err != nil {
+		return fmt.Errorf("product import service: export header: %w", err)
+	}
+
+	err := p.ProductRepo.Export(ctx, exportBatch, func(products []*entity.Product) error {
+		for _, v := range products {
+			categories := make([]string, 0, len(v.Categories))
+			for _, c := range v.Categories {
+				categories = append(categories, strconv.FormatUint(uint64(c.ID), 10))
+			}
+
+			record := []string{
+				strconv.FormatUint(uint64(v.ID), 10),
+				helper.ProductSKU(v),
+				v.Name,
+				strconv.FormatFloat(v.Price, 'f', -1, 64),
+				strconv.Itoa(v.StockAt(v.InventoryID)),
+				v.Description,
+				strconv.FormatUint(uint64(v.InventoryID), 10),
+				v.Inventory.Location,
+				strings.Join(categories, "|"),
+			}
+
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+
+		writer.Flush()
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		return writer.Error()
+	})
+	if err != nil {
+		return fmt.Errorf("product import service: export: %w", err)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// importFileError is the message saved for an import whose file cannot be
+// used at all.
+func importFileError(err error) string {
+	if errors.Is(err, storage.ErrNotFound) {
+		return "import file not found"
+	}
+
+	return fmt.Sprintf("%v: %v", ErrImportFile, err)
+}
+
+func validationMessage(err validator.FieldError) string {
+	field, ok := importFields[err.Field()]
+	if !ok {
+		field = strings.ToLower(err.Field())
+	}
+
+	if err.Param() == "" {
+		return fmt.Sprintf("%s failed on %s", field, err.Tag())
+	}
+
+	return fmt.Sprintf("%s failed on %s=%s", field, err.Tag(), err.Param())
+}
+
+// truncate cuts s to max bytes without splitting a character.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	web "simple-toko/web/product"
+)
+
+type ProductPriceService interface {
+	CreateSchedule(ctx context.Context, req *web.ProductPriceScheduleRequest) (*web.PriceScheduleResponse, error)
+	FindSchedules(ctx context.Context, productId uint, status string) ([]*web.PriceScheduleResponse, error)
+	CancelSchedule(ctx context.Context, productId, id uint) (*web.PriceScheduleResponse, error)
+	Timeline(ctx context.Context, productId uint) (*web.PriceTimelineResponse, error)
+	ApplyScheduled(ctx context.Context) (int, error)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	"simple-toko/utils"
+	web "simple-toko/web/product"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type productPriceServiceImpl struct {
+	PriceRepo   repository.ProductPriceRepository
+	ProductRepo repository.ProductRepository
+	Validate    *validator.Validate
+	Redis       *redis.Client
+}
+
+func NewProductPriceServiceImpl(priceRepo repository.ProductPriceRepository, productRepo repository.ProductRepository, validate *validator.Validate, redis *redis.Client) *productPriceServiceImpl {
+	return &productPriceServiceImpl{
+		PriceRepo:   priceRepo,
+		ProductRepo: productRepo,
+		Validate:    validate,
+		Redis:       redis,
+	}
+}
+
+var (
+	ErrScheduleNotFound   = errors.New("price schedule not found")
+	ErrScheduleNotPending = errors.New("only a pending price schedule can be canceled")
+	ErrScheduleInPast     = errors.New("price schedule must start in the future")
+)
+
+func (p *productPriceServiceImpl) CreateSchedule(ctx context.Context, req *web.ProductPriceScheduleRequest) (*web.PriceScheduleResponse, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if !req.StartsAt.After(time.Now()) {
+		return nil, ErrScheduleInPast
+	}
+
+	if _, err := p.findProduct(ctx, req.ProductID); err != nil {
+		return nil, err
+	}
+
+	schedule := entity.ProductPriceSchedule{
+		ProductID: req.ProductID,
+		Price:     req.Price,
+		StartsAt:  req.StartsAt,
+		Note:      req.Note,
+	}
+
+	result, err := p.PriceRepo.CreateSchedule(ctx, &schedule)
+	if err != nil {
+		return nil, fmt.Errorf("product price service: create schedule: %w", err)
+	}
+
+	response := helper.ToPriceScheduleResponse(result)
+	return response, nil
+}
+
+func (p *productPriceServiceImpl) FindSchedules(ctx context.Context, productId uint, status string) ([]*web.PriceScheduleResponse, error) {
+	if err := p.Validate.Var(status, "omitempty,oneof=pending applied canceled"); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if _, err := p.findProduct(ctx, productId); err != nil {
+		return nil, err
+	}
+
+	result, err := p.PriceRepo.FindSchedules(ctx, productId, status)
+	if err != nil {
+		return nil, fmt.Errorf("product price service: find schedules: %w", err)
+	}
+
+	responses := make([]*web.PriceScheduleResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToPriceScheduleResponse(v))
+	}
+
+	return responses, nil
+}
+
+func (p *productPriceServiceImpl) CancelSchedule(ctx context.Context, productId, id uint) (*web.PriceScheduleResponse, error) {
+	result, err := p.PriceRepo.CancelSchedule(ctx, productId, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrScheduleNotFound):
+			return nil, ErrScheduleNotFound
+		case errors.Is(err, repository.ErrScheduleNotPending):
+			return nil, ErrScheduleNotPending
+		default:
+			return nil, fmt.Errorf("product price service: cancel schedule: %w", err)
+		}
+	}
+
+	response := helper.ToPriceScheduleResponse(result)
+	return response, nil
+}
+
+func (p *productPriceServiceImpl) Timeline(ctx context.Context, productId uint) (*web.PriceTimelineResponse, error) {
+	product, err := p.findProduct(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := p.PriceRepo.FindHistory(ctx, productId)
+	if err != nil {
+		return nil, fmt.Errorf("product price service: find history: %w", err)
+	}
+
+	scheduled, err := p.PriceRepo.FindSchedules(ctx, productId, repository.SchedulePending)
+	if err != nil {
+		return nil, fmt.Errorf("product price service: find schedules: %w", err)
+	}
+
+	response := helper.ToPriceTimelineResponse(product, history, scheduled)
+	return response, nil
+}
+
+// ApplyScheduled sets every price whose schedule has started and returns how
+// many were applied. A failing schedule is skipped, the cached products are
+// still dropped for the ones that were saved.
+func (p *productPriceServiceImpl) ApplyScheduled(ctx context.Context) (int, error) {
+	changed, err := p.PriceRepo.ApplyDue(ctx, time.Now())
+
+	if len(changed) > 0 {
+		utils.InvalidateProductsCached(ctx, p.Redis)
+	}
+
+	if err != nil {
+		return len(changed), fmt.Errorf("product price service: apply: %w", err)
+	}
+
+	return len(changed), nil
+}
+
+func (p *productPriceServiceImpl) findProduct(ctx context.Context, id uint) (*entity.Product, error) {
+	result, err := p.ProductRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrorIdNotFound) {
+			return nil, ErrorIdNotFound
+		}
+		return nil, fmt.Errorf("product price service: find product: %w", err)
+	}
+
+	return result, nil
+}
@@ -3,7 +3,7 @@ package service
 import (
 	"context"
 	web "simple-toko/web/product"
-	pg "simple-toko/web"
+	"time"
 )
 
 type ProductService interface {
@@ -11,8 +11,17 @@ type ProductService interface {
 	Update(ctx context.Context, req *web.ProductUpdateRequest) (*web.ProductResponse, error)
 	Delete(ctx context.Context, id uint) error
 	FindById(ctx context.Context, id uint) (*web.ProductResponse, error)
-	FindAll(ctx context.Context, page, pageSize int, search string) (*pg.PaginatedResponse, error)
+	FindAll(ctx context.Context, page, pageSize int, req *web.ProductFilterRequest) (*web.ProductListResponse, error)
 	AddStock(ctx context.Context, req *web.ProductStockUpdateRequest) (*web.ProductResponse, error)
 	ReduceStock(ctx context.Context, req *web.ProductStockUpdateRequest) (*web.ProductResponse, error)
-	UpdateImage(ctx context.Context, id uint, img string) (*web.ProductResponse, error)
+	AddImage(ctx context.Context, productId uint, fileName string, primary bool) (*web.ProductResponse, error)
+	ReorderImages(ctx context.Context, req *web.ProductImageOrderRequest) (*web.ProductResponse, error)
+	SetPrimaryImage(ctx context.Context, productId, imageId uint) (*web.ProductResponse, error)
+	DeleteImage(ctx context.Context, productId, imageId uint) (*web.ProductResponse, error)
+	FindImage(ctx context.Context, productId, imageId uint) (*web.ImageInfo, error)
+	CleanupImages(ctx context.Context, before time.Time) (int, error)
+	SaveOptions(ctx context.Context, req *web.ProductOptionRequest) (*web.ProductResponse, error)
+	CreateVariant(ctx context.Context, req *web.ProductVariantCreateRequest) (*web.ProductResponse, error)
+	UpdateVariant(ctx context.Context, req *web.ProductVariantUpdateRequest) (*web.ProductResponse, error)
+	DeleteVariant(ctx context.Context, productId, variantId, userId uint) (*web.ProductResponse, error)
 }
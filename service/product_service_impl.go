@@ -9,9 +9,11 @@ import (
 	"simple-toko/entity"
 	"simple-toko/helper"
 	"simple-toko/repository"
+	"simple-toko/storage"
 	"simple-toko/utils"
-	pg "simple-toko/web"
 	web "simple-toko/web/product"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
@@ -21,19 +23,37 @@ import (
 type productServiceImpl struct {
 	ProductRepo   repository.ProductRepository
 	InventoryRepo repository.InventoryRepository
+	CategoryRepo  repository.CategoryRepository
 	Validate      *validator.Validate
 	Redis         *redis.Client
+	Storage       storage.Storage
 }
 
-func NewProductServiceImpl(productRepo repository.ProductRepository, inventoryRepo repository.InventoryRepository, validate *validator.Validate, redis *redis.Client) *productServiceImpl {
+func NewProductServiceImpl(productRepo repository.ProductRepository, inventoryRepo repository.InventoryRepository, categoryRepo repository.CategoryRepository, validate *validator.Validate, redis *redis.Client, store storage.Storage) *productServiceImpl {
 	return &productServiceImpl{
 		ProductRepo:   productRepo,
 		InventoryRepo: inventoryRepo,
+		CategoryRepo:  categoryRepo,
 		Validate:      validate,
 		Redis:         redis,
+		Storage:       store,
 	}
 }
 
+var (
+	ErrVariantNotFound = errors.New("product variant not found")
+	ErrVariantRequired = errors.New("product has variants, variant is required")
+	ErrVariantExists   = errors.New("variant with these options already exists")
+	ErrSkuExists       = errors.New("sku already exists")
+	ErrInvalidOptions  = errors.New("options do not match the product options")
+
+	ErrProductStockLeft   = errors.New("product still holds stock of its own, empty it before the first variant")
+	ErrStockNotRestorable = errors.New("stock cannot be put back, the variant was removed or the product has variants now")
+
+	ErrImageNotFound     = errors.New("product image not found")
+	ErrInvalidImageOrder = errors.New("image order must list every image of the product once")
+)
+
 func (p *productServiceImpl) Create(ctx context.Context, req *web.ProductCreateRequest) (*web.ProductResponse, error) {
 	if err := p.Validate.Struct(req); err != nil {
 		return nil, ErrorValidation
@@ -46,15 +66,26 @@ func (p *productServiceImpl) Create(ctx context.Context, req *web.ProductCreateR
 		return nil, fmt.Errorf("product service: find inventory: %w", err)
 	}
 
+	categories, err := p.findCategories(ctx, req.CategoryIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	product := entity.Product{
 		InventoryID: req.InventoryID,
+		SKU:         productSku(req.SKU),
 		Name:        req.Name,
 		Price:       req.Price,
 		Stock:       req.Stock,
 		Description: req.Description,
+		Categories:  categories,
 	}
-	result, err := p.ProductRepo.Create(ctx, &product)
+	move := entity.StockMovement{Reason: repository.StockAdjustment, RefType: repository.RefProduct, UserID: stockActor(req.UserID), Note: "opening stock"}
+	result, err := p.ProductRepo.Create(ctx, &product, &move)
 	if err != nil {
+		if errors.Is(err, repository.ErrSkuExists) {
+			return nil, ErrSkuExists
+		}
 		return nil, fmt.Errorf("product service: create: %w", err)
 	}
 
@@ -88,6 +119,10 @@ func (p *productServiceImpl) Update(ctx context.Context, req *web.ProductUpdateR
 		prod.InventoryID = *req.InventoryID
 	}
 
+	if req.SKU != nil {
+		prod.SKU = productSku(*req.SKU)
+	}
+
 	if req.Name != nil {
 		prod.Name = *req.Name
 	}
@@ -100,8 +135,20 @@ func (p *productServiceImpl) Update(ctx context.Context, req *web.ProductUpdateR
 		prod.Description = *req.Description
 	}
 
+	if req.CategoryIDs != nil {
+		categories, err := p.findCategories(ctx, *req.CategoryIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		prod.Categories = categories
+	}
+
 	result, err := p.ProductRepo.Update(ctx, prod)
 	if err != nil {
+		if errors.Is(err, repository.ErrSkuExists) {
+			return nil, ErrSkuExists
+		}
 		return nil, fmt.Errorf("product service: update: %w", err)
 	}
 
@@ -112,6 +159,11 @@ func (p *productServiceImpl) Update(ctx context.Context, req *web.ProductUpdateR
 }
 
 func (p *productServiceImpl) Delete(ctx context.Context, id uint) error {
+	prod, err := p.findProduct(ctx, id)
+	if err != nil {
+		return err
+	}
+
 	if err := p.ProductRepo.Delete(ctx, id); err != nil {
 		if errors.Is(err, repository.ErrorIdNotFound) {
 			return ErrorIdNotFound
@@ -119,6 +171,12 @@ func (p *productServiceImpl) Delete(ctx context.Context, id uint) error {
 		return fmt.Errorf("product service: delete: %w", err)
 	}
 
+	//order lines keep the product row, the pictures are not needed anymore
+	utils.RemoveProductImage(ctx, p.Storage, prod.Image)
+	for _, v := range prod.Images {
+		utils.RemoveProductImage(ctx, p.Storage, v.FileName)
+	}
+
 	utils.InvalidateCached(ctx, p.Redis, id)
 
 	return nil
@@ -155,20 +213,52 @@ func (p *productServiceImpl) FindById(ctx context.Context, id uint) (*web.Produc
 	return response, nil
 }
 
-func (p *productServiceImpl) FindAll(ctx context.Context, page, pageSize int, search string) (*pg.PaginatedResponse, error) {
-	cacheKey := fmt.Sprintf("products:page=%d:size=%d:search=%s", page, pageSize, search)
+func (p *productServiceImpl) FindAll(ctx context.Context, page, pageSize int, req *web.ProductFilterRequest) (*web.ProductListResponse, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		return nil, ErrorValidation
+	}
+
+	cacheKey := fmt.Sprintf("products:page=%d:size=%d:search=%s:category=%d:inventory=%d:price=%s-%s:stock=%t:sort=%s",
+		page, pageSize, req.Search, req.CategoryID, req.InventoryID, priceKey(req.MinPrice), priceKey(req.MaxPrice), req.InStock, req.Sort)
 
 	cached, err := p.Redis.Get(ctx, cacheKey).Result()
 	if err == nil {
-		var paginateResp pg.PaginatedResponse
-		if err := json.Unmarshal([]byte(cached), &paginateResp); err == nil {
-			return &paginateResp, nil
+		var listResp web.ProductListResponse
+		if err := json.Unmarshal([]byte(cached), &listResp); err == nil {
+			return &listResp, nil
 		}
 	} else if err != redis.Nil {
 		fmt.Printf("Redis error: %v\n", err)
 	}
 
-	result, totalItems, err := p.ProductRepo.FindAll(ctx, page, pageSize, search)
+	filter := entity.ProductFilter{
+		Search:      req.Search,
+		InventoryID: req.InventoryID,
+		MinPrice:    req.MinPrice,
+		MaxPrice:    req.MaxPrice,
+		InStock:     req.InStock,
+		Sort:        req.Sort,
+	}
+
+	// a category also lists the products of all its sub categories
+	if req.CategoryID != 0 {
+		all, err := p.CategoryRepo.FindAll(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("product service: find category: %w", err)
+		}
+
+		if findCategory(all, req.CategoryID) == nil {
+			return nil, ErrCategoryNotFound
+		}
+
+		filter.CategoryIDs = categoryDescendants(all, req.CategoryID)
+	}
+
+	result, totalItems, err := p.ProductRepo.FindAll(ctx, page, pageSize, &filter)
 	if err != nil {
 		return nil, fmt.Errorf("product service: find all: %w", err)
 	}
@@ -181,28 +271,51 @@ func (p *productServiceImpl) FindAll(ctx context.Context, page, pageSize int, se
 			Inventory: web.InventInfo{
 				Location: v.Inventory.Location,
 			},
+			SKU:         helper.ProductSKU(v),
 			Name:        v.Name,
 			Price:       v.Price,
 			Stock:       v.Stock,
 			Description: v.Description,
 			Image:       v.Image,
+			Categories:  helper.ToProductCategories(v.Categories),
+			Options:     helper.ToProductOptions(v),
+			Variants:    helper.ToProductVariants(v),
+			Images:      helper.ToProductImages(v.Images),
+			Rating:      v.RatingAvg,
+			ReviewCount: v.ReviewCount,
 			CreatedAt:   v.CreatedAt,
 			UpdatedAt:   v.UpdatedAt,
 		}
 		responses = append(responses, &response)
 	}
 
+	facets, err := p.ProductRepo.Facets(ctx, &filter)
+	if err != nil {
+		return nil, fmt.Errorf("product service: facets: %w", err)
+	}
+
 	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
 
-	paginateResp := helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses)
+	listResp := web.ProductListResponse{
+		PaginatedResponse: *helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses),
+		Facets:            helper.ToProductFacets(facets),
+	}
 
-	jsonData, _ := json.Marshal(paginateResp)
+	jsonData, _ := json.Marshal(listResp)
 	err = p.Redis.Set(ctx, cacheKey, jsonData, 5*time.Minute).Err()
 	if err != nil {
 		fmt.Printf("Redis set error: %v\n", err)
 	}
 
-	return paginateResp, nil
+	return &listResp, nil
+}
+
+func priceKey(price *float64) string {
+	if price == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*price, 'f', -1, 64)
 }
 
 func (p *productServiceImpl) AddStock(ctx context.Context, req *web.ProductStockUpdateRequest) (*web.ProductResponse, error) {
@@ -219,11 +332,15 @@ func (p *productServiceImpl) AddStock(ctx context.Context, req *web.ProductStock
 		return nil, fmt.Errorf("product service: find id add stock: %w", err)
 	}
 
-	result, err := p.ProductRepo.AddStock(ctx, req.ID, req.Stock)
+	move := stockMovement(req, repository.StockPurchase)
+	result, err := p.ProductRepo.AddStock(ctx, req.ID, req.VariantID, req.InventoryID, req.Stock, move)
 	if err != nil {
 		if errors.Is(err, repository.ErrorIdNotFound) {
 			return nil, ErrorIdNotFound
 		}
+		if err := variantErr(err); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("product service: add stock: %w", err)
 	}
 
@@ -246,7 +363,8 @@ func (p *productServiceImpl) ReduceStock(ctx context.Context, req *web.ProductSt
 		return nil, fmt.Errorf("product service: find id reduce stock: %w", err)
 	}
 
-	result, err := p.ProductRepo.ReduceStock(ctx, req.ID, req.Stock)
+	move := stockMovement(req, repository.StockAdjustment)
+	result, err := p.ProductRepo.ReduceStock(ctx, req.ID, req.VariantID, req.InventoryID, req.Stock, move)
 	if err != nil {
 		if errors.Is(err, repository.ErrorIdNotFound) {
 			return nil, ErrorIdNotFound
@@ -254,6 +372,9 @@ func (p *productServiceImpl) ReduceStock(ctx context.Context, req *web.ProductSt
 		if errors.Is(err, repository.ErrNotEnoughStock) {
 			return nil, ErrNotEnoughStock
 		}
+		if err := variantErr(err); err != nil {
+			return nil, err
+		}
 		return nil, fmt.Errorf("product service: reduce stock: %w", err)
 	}
 
@@ -264,13 +385,246 @@ func (p *productServiceImpl) ReduceStock(ctx context.Context, req *web.ProductSt
 	return response, nil
 }
 
-func (p *productServiceImpl) UpdateImage(ctx context.Context, id uint, img string) (*web.ProductResponse, error) {
-	result, err := p.ProductRepo.UpdateImage(ctx, id, img)
+// AddImage puts an uploaded file into the gallery, the sizes must already be
+// generated next to it.
+func (p *productServiceImpl) AddImage(ctx context.Context, productId uint, fileName string, primary bool) (*web.ProductResponse, error) {
+	image := entity.ProductImage{
+		ProductID: productId,
+		FileName:  fileName,
+		IsPrimary: primary,
+	}
+
+	result, err := p.ProductRepo.AddImage(ctx, &image)
 	if err != nil {
 		if errors.Is(err, repository.ErrorIdNotFound) {
 			return nil, ErrorIdNotFound
 		}
-		return nil, fmt.Errorf("product service: update img: %w", err)
+		return nil, fmt.Errorf("product service: add image: %w", err)
+	}
+
+	utils.InvalidateCached(ctx, p.Redis, result.ID)
+
+	response := helper.ToProductResponse(result)
+	return response, nil
+}
+
+func (p *productServiceImpl) ReorderImages(ctx context.Context, req *web.ProductImageOrderRequest) (*web.ProductResponse, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if _, err := p.findProduct(ctx, req.ProductID); err != nil {
+		return nil, err
+	}
+
+	result, err := p.ProductRepo.ReorderImages(ctx, req.ProductID, req.ImageIDs)
+	if err != nil {
+		if errors.Is(err, repository.ErrInvalidImageOrder) {
+			return nil, ErrInvalidImageOrder
+		}
+		return nil, fmt.Errorf("product service: reorder images: %w", err)
+	}
+
+	utils.InvalidateCached(ctx, p.Redis, result.ID)
+
+	response := helper.ToProductResponse(result)
+	return response, nil
+}
+
+func (p *productServiceImpl) SetPrimaryImage(ctx context.Context, productId, imageId uint) (*web.ProductResponse, error) {
+	result, err := p.ProductRepo.SetPrimaryImage(ctx, productId, imageId)
+	if err != nil {
+		if errors.Is(err, repository.ErrImageNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("product service: set primary image: %w", err)
+	}
+
+	utils.InvalidateCached(ctx, p.Redis, result.ID)
+
+	response := helper.ToProductResponse(result)
+	return response, nil
+}
+
+func (p *productServiceImpl) DeleteImage(ctx context.Context, productId, imageId uint) (*web.ProductResponse, error) {
+	image, err := p.ProductRepo.DeleteImage(ctx, productId, imageId)
+	if err != nil {
+		if errors.Is(err, repository.ErrImageNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("product service: delete image: %w", err)
+	}
+
+	utils.RemoveProductImage(ctx, p.Storage, image.FileName)
+	utils.InvalidateCached(ctx, p.Redis, productId)
+
+	return p.FindById(ctx, productId)
+}
+
+func (p *productServiceImpl) FindImage(ctx context.Context, productId, imageId uint) (*web.ImageInfo, error) {
+	image, err := p.ProductRepo.FindImage(ctx, productId, imageId)
+	if err != nil {
+		if errors.Is(err, repository.ErrImageNotFound) {
+			return nil, ErrImageNotFound
+		}
+		return nil, fmt.Errorf("product service: find image: %w", err)
+	}
+
+	response := helper.ToProductImage(image)
+	return &response, nil
+}
+
+// CleanupImages removes files in the product folder of the storage that no
+// product or gallery row points to anymore. Only files older than before are
+// touched so an upload that is still being saved is left alone.
+func (p *productServiceImpl) CleanupImages(ctx context.Context, before time.Time) (int, error) {
+	files, err := utils.ProductImageFiles(ctx, p.Storage, before)
+	if err != nil {
+		return 0, fmt.Errorf("product service: list image files: %w", err)
+	}
+
+	if len(files) == 0 {
+		return 0, nil
+	}
+
+	used, err := p.ProductRepo.ImageFileNames(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("product service: find image names: %w", err)
+	}
+
+	inUse := make(map[string]bool, len(used))
+	for _, v := range used {
+		inUse[v] = true
+	}
+
+	removed := 0
+	for _, v := range files {
+		if inUse[v] {
+			continue
+		}
+
+		utils.RemoveProductImage(ctx, p.Storage, v)
+		removed++
+	}
+
+	return removed, nil
+}
+
+// SaveOptions replaces the option names, the number of options can only
+// change while the product has no variants.
+func (p *productServiceImpl) SaveOptions(ctx context.Context, req *web.ProductOptionRequest) (*web.ProductResponse, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	prod, err := p.findProduct(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(prod.Variants) > 0 && len(req.Options) != len(prod.Options) {
+		return nil, ErrInvalidOptions
+	}
+
+	options := make([]entity.ProductOption, 0, len(req.Options))
+	for i, v := range req.Options {
+		options = append(options, entity.ProductOption{
+			Position: i + 1,
+			Name:     v,
+		})
+	}
+
+	result, err := p.ProductRepo.SaveOptions(ctx, req.ProductID, options)
+	if err != nil {
+		return nil, fmt.Errorf("product service: save options: %w", err)
+	}
+
+	utils.InvalidateCached(ctx, p.Redis, result.ID)
+
+	response := helper.ToProductResponse(result)
+	return response, nil
+}
+
+func (p *productServiceImpl) CreateVariant(ctx context.Context, req *web.ProductVariantCreateRequest) (*web.ProductResponse, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	prod, err := p.findProduct(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	variant := entity.ProductVariant{
+		ProductID: req.ProductID,
+		SKU:       req.SKU,
+		Price:     req.Price,
+		Stock:     req.Stock,
+	}
+
+	if err := setVariantOptions(prod, &variant, req.Options); err != nil {
+		return nil, err
+	}
+
+	move := entity.StockMovement{Reason: repository.StockAdjustment, RefType: repository.RefProduct, RefID: req.ProductID, UserID: stockActor(req.UserID), Note: "variant created"}
+	result, err := p.ProductRepo.CreateVariant(ctx, &variant, &move)
+	if err != nil {
+		if err := variantErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product service: create variant: %w", err)
+	}
+
+	utils.InvalidateCached(ctx, p.Redis, result.ID)
+
+	response := helper.ToProductResponse(result)
+	return response, nil
+}
+
+func (p *productServiceImpl) UpdateVariant(ctx context.Context, req *web.ProductVariantUpdateRequest) (*web.ProductResponse, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	prod, err := p.findProduct(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	var variant *entity.ProductVariant
+	for i := range prod.Variants {
+		if prod.Variants[i].ID == req.ID {
+			variant = &prod.Variants[i]
+		}
+	}
+
+	if variant == nil {
+		return nil, ErrVariantNotFound
+	}
+
+	if req.SKU != nil {
+		variant.SKU = *req.SKU
+	}
+
+	if req.Options != nil {
+		if err := setVariantOptions(prod, variant, *req.Options); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Price != nil {
+		variant.Price = req.Price
+		if *req.Price == 0 {
+			variant.Price = nil
+		}
+	}
+
+	result, err := p.ProductRepo.UpdateVariant(ctx, variant)
+	if err != nil {
+		if err := variantErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product service: update variant: %w", err)
 	}
 
 	utils.InvalidateCached(ctx, p.Redis, result.ID)
@@ -278,3 +632,121 @@ func (p *productServiceImpl) UpdateImage(ctx context.Context, id uint, img strin
 	response := helper.ToProductResponse(result)
 	return response, nil
 }
+
+func (p *productServiceImpl) DeleteVariant(ctx context.Context, productId, variantId, userId uint) (*web.ProductResponse, error) {
+	move := entity.StockMovement{Reason: repository.StockAdjustment, RefType: repository.RefProduct, RefID: productId, UserID: stockActor(userId), Note: "variant deleted"}
+	result, err := p.ProductRepo.DeleteVariant(ctx, productId, variantId, &move)
+	if err != nil {
+		if err := variantErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("product service: delete variant: %w", err)
+	}
+
+	utils.InvalidateCached(ctx, p.Redis, result.ID)
+
+	response := helper.ToProductResponse(result)
+	return response, nil
+}
+
+func (p *productServiceImpl) findProduct(ctx context.Context, id uint) (*entity.Product, error) {
+	prod, err := p.ProductRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrorIdNotFound) {
+			return nil, ErrorIdNotFound
+		}
+		return nil, fmt.Errorf("product service: find product: %w", err)
+	}
+
+	return prod, nil
+}
+
+// setVariantOptions needs one value per product option and rejects a
+// combination another variant of the product already has.
+func setVariantOptions(prod *entity.Product, variant *entity.ProductVariant, values []string) error {
+	if len(prod.Options) == 0 || len(values) != len(prod.Options) {
+		return ErrInvalidOptions
+	}
+
+	padded := make([]string, 3)
+	copy(padded, values)
+	variant.Option1, variant.Option2, variant.Option3 = padded[0], padded[1], padded[2]
+
+	for _, v := range prod.Variants {
+		if v.ID != variant.ID && v.Option1 == variant.Option1 && v.Option2 == variant.Option2 && v.Option3 == variant.Option3 {
+			return ErrVariantExists
+		}
+	}
+
+	return nil
+}
+
+// variantErr maps the variant errors of the repository, nil means err is
+// something else.
+func variantErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrVariantNotFound):
+		return ErrVariantNotFound
+	case errors.Is(err, repository.ErrVariantRequired):
+		return ErrVariantRequired
+	case errors.Is(err, repository.ErrSkuExists):
+		return ErrSkuExists
+	case errors.Is(err, repository.ErrInventoryNotFound):
+		return ErrInventoryNotFound
+	case errors.Is(err, repository.ErrProductStockLeft):
+		return fmt.Errorf("%w: %v", ErrProductStockLeft, strings.TrimPrefix(err.Error(), repository.ErrProductStockLeft.Error()+": "))
+	default:
+		return nil
+	}
+}
+
+// stockMovement describes a manual stock change, reason is used when the
+// request names none.
+func stockMovement(req *web.ProductStockUpdateRequest, reason string) *entity.StockMovement {
+	if req.Reason != "" {
+		reason = req.Reason
+	}
+
+	return &entity.StockMovement{
+		Reason: reason,
+		UserID: stockActor(req.UserID),
+		Note:   req.Note,
+	}
+}
+
+// productSku stores an empty sku as null, the unique index allows many of
+// those.
+func productSku(sku string) *string {
+	sku = strings.TrimSpace(sku)
+	if sku == "" {
+		return nil
+	}
+
+	return &sku
+}
+
+func (p *productServiceImpl) findCategories(ctx context.Context, ids []uint) ([]entity.Category, error) {
+	categories := []entity.Category{}
+	if len(ids) == 0 {
+		return categories, nil
+	}
+
+	result, err := p.CategoryRepo.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("product service: find categories: %w", err)
+	}
+
+	found := map[uint]bool{}
+	for _, v := range result {
+		found[v.ID] = true
+		categories = append(categories, *v)
+	}
+
+	for _, v := range ids {
+		if !found[v] {
+			return nil, ErrCategoryNotFound
+		}
+	}
+
+	return categories, nil
+}
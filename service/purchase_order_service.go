@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	pg "simple-toko/web"
+	web "simple-toko/web/purchase"
+)
+
+type PurchaseOrderService interface {
+	Create(ctx context.Context, req *web.PurchaseOrderCreateRequest) (*web.PurchaseOrderResponse, error)
+	Update(ctx context.Context, req *web.PurchaseOrderCreateRequest) (*web.PurchaseOrderResponse, error)
+	FindById(ctx context.Context, id uint) (*web.PurchaseOrderResponse, error)
+	FindAll(ctx context.Context, page, pageSize int, req *web.PurchaseOrderFilterRequest) (*pg.PaginatedResponse, error)
+	Approve(ctx context.Context, id, userId uint) (*web.PurchaseOrderResponse, error)
+	Receive(ctx context.Context, req *web.GoodsReceiptRequest) (*web.PurchaseOrderResponse, error)
+	Cancel(ctx context.Context, id uint) (*web.PurchaseOrderResponse, error)
+}
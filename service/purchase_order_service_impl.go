@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	"simple-toko/utils"
+	pg "simple-toko/web"
+	web "simple-toko/web/purchase"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type purchaseOrderServiceImpl struct {
+	PurchaseRepo repository.PurchaseOrderRepository
+	Validate     *validator.Validate
+	Redis        *redis.Client
+}
+
+func NewPurchaseOrderServiceImpl(purchaseRepo repository.PurchaseOrderRepository, validate *validator.Validate, redis *redis.Client) *purchaseOrderServiceImpl {
+	return &purchaseOrderServiceImpl{
+		PurchaseRepo: purchaseRepo,
+		Validate:     validate,
+		Redis:        redis,
+	}
+}
+
+var (
+	ErrPurchaseNotFound      = errors.New("purchase order not found")
+	ErrPurchaseStatusChanged = errors.New("purchase order status has changed")
+	ErrPurchaseLineNotFound  = errors.New("purchase order line not found")
+	ErrPurchaseQtyExceeded   = errors.New("received qty exceeds the qty left on the order line")
+	ErrPurchaseNotReceived   = errors.New("nothing was received on the purchase order, cancel it instead")
+	ErrPurchaseDuplicateLine = errors.New("line is listed more than once")
+)
+
+func (p *purchaseOrderServiceImpl) Create(ctx context.Context, req *web.PurchaseOrderCreateRequest) (*web.PurchaseOrderResponse, error) {
+	order, err := p.toOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := p.PurchaseRepo.Create(ctx, order)
+	if err != nil {
+		if err := purchaseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order service: create: %w", err)
+	}
+
+	return helper.ToPurchaseOrderResponse(result), nil
+}
+
+func (p *purchaseOrderServiceImpl) Update(ctx context.Context, req *web.PurchaseOrderCreateRequest) (*web.PurchaseOrderResponse, error) {
+	order, err := p.toOrder(req)
+	if err != nil {
+		return nil, err
+	}
+
+	order.ID = req.ID
+
+	result, err := p.PurchaseRepo.Update(ctx, order)
+	if err != nil {
+		if err := purchaseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order service: update: %w", err)
+	}
+
+	return helper.ToPurchaseOrderResponse(result), nil
+}
+
+func (p *purchaseOrderServiceImpl) FindById(ctx context.Context, id uint) (*web.PurchaseOrderResponse, error) {
+	result, err := p.PurchaseRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrPurchaseNotFound) {
+			return nil, ErrPurchaseNotFound
+		}
+		return nil, fmt.Errorf("purchase order service: find by id: %w", err)
+	}
+
+	return helper.ToPurchaseOrderResponse(result), nil
+}
+
+func (p *purchaseOrderServiceImpl) FindAll(ctx context.Context, page, pageSize int, req *web.PurchaseOrderFilterRequest) (*pg.PaginatedResponse, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	filter := entity.PurchaseOrderFilter{
+		Status:      req.Status,
+		SupplierID:  req.SupplierID,
+		InventoryID: req.InventoryID,
+	}
+
+	result, totalItems, err := p.PurchaseRepo.FindAll(ctx, page, pageSize, &filter)
+	if err != nil {
+		return nil, fmt.Errorf("purchase order service: find all: %w", err)
+	}
+
+	responses := make([]*web.PurchaseOrderResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToPurchaseOrderResponse(v))
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	return helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses), nil
+}
+
+func (p *purchaseOrderServiceImpl) Approve(ctx context.Context, id, userId uint) (*web.PurchaseOrderResponse, error) {
+	result, err := p.PurchaseRepo.Approve(ctx, id, userId)
+	if err != nil {
+		if err := purchaseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order service: approve: %w", err)
+	}
+
+	return helper.ToPurchaseOrderResponse(result), nil
+}
+
+// Receive books a goods receipt, a line without unit cost comes in at the
+// cost on the order. Lines of an approved order cannot change, so reading the
+// cost before the receipt is locked is safe.
+func (p *purchaseOrderServiceImpl) Receive(ctx context.Context, req *web.GoodsReceiptRequest) (*web.PurchaseOrderResponse, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if len(req.Lines) == 0 && !req.Close {
+		return nil, ErrorValidation
+	}
+
+	order, err := p.PurchaseRepo.FindById(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPurchaseNotFound) {
+			return nil, ErrPurchaseNotFound
+		}
+		return nil, fmt.Errorf("purchase order service: find by id: %w", err)
+	}
+
+	costs := map[uint]float64{}
+	for _, v := range order.Lines {
+		costs[v.ID] = v.UnitCost
+	}
+
+	receipt := entity.GoodsReceipt{
+		UserID: req.UserID,
+		Note:   req.Note,
+	}
+
+	seen := map[uint]bool{}
+	for _, v := range req.Lines {
+		if seen[v.LineID] {
+			return nil, ErrPurchaseDuplicateLine
+		}
+		seen[v.LineID] = true
+
+		cost, ok := costs[v.LineID]
+		if !ok {
+			return nil, ErrPurchaseLineNotFound
+		}
+
+		if v.UnitCost != nil {
+			cost = *v.UnitCost
+		}
+
+		receipt.Lines = append(receipt.Lines, entity.GoodsReceiptLine{
+			PurchaseOrderLineID: v.LineID,
+			Qty:                 v.Qty,
+			UnitCost:            cost,
+		})
+	}
+
+	result, err := p.PurchaseRepo.Receive(ctx, req.ID, &receipt, req.Close)
+	if err != nil {
+		if err := purchaseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order service: receive: %w", err)
+	}
+
+	//only the received lines moved stock
+	for _, v := range result.Lines {
+		if seen[v.ID] {
+			utils.InvalidateCached(ctx, p.Redis, v.ProductID)
+		}
+	}
+
+	return helper.ToPurchaseOrderResponse(result), nil
+}
+
+func (p *purchaseOrderServiceImpl) Cancel(ctx context.Context, id uint) (*web.PurchaseOrderResponse, error) {
+	result, err := p.PurchaseRepo.Cancel(ctx, id)
+	if err != nil {
+		if err := purchaseErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("purchase order service: cancel: %w", err)
+	}
+
+	return helper.ToPurchaseOrderResponse(result), nil
+}
+
+func (p *purchaseOrderServiceImpl) toOrder(req *web.PurchaseOrderCreateRequest) (*entity.PurchaseOrder, error) {
+	if err := p.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	order := entity.PurchaseOrder{
+		SupplierID:  req.SupplierID,
+		InventoryID: req.InventoryID,
+		Note:        req.Note,
+		UserID:      req.UserID,
+	}
+
+	if req.ExpectedAt != "" {
+		expected, _ := time.ParseInLocation("2006-01-02", req.ExpectedAt, time.Local)
+		order.ExpectedAt = &expected
+	}
+
+	seen := map[[2]uint]bool{}
+	for _, v := range req.Lines {
+		key := [2]uint{v.ProductID, v.VariantID}
+		if seen[key] {
+			return nil, ErrPurchaseDuplicateLine
+		}
+		seen[key] = true
+
+		order.Lines = append(order.Lines, entity.PurchaseOrderLine{
+			ProductID: v.ProductID,
+			VariantID: v.VariantID,
+			Qty:       v.Qty,
+			UnitCost:  v.UnitCost,
+		})
+	}
+
+	return &order, nil
+}
+
+// purchaseErr maps the repository errors of a purchase order, nil means the
+// error is unexpected.
+func purchaseErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrPurchaseNotFound):
+		return ErrPurchaseNotFound
+	case errors.Is(err, repository.ErrPurchaseStatusChanged):
+		return ErrPurchaseStatusChanged
+	case errors.Is(err, repository.ErrPurchaseLineNotFound):
+		return ErrPurchaseLineNotFound
+	case errors.Is(err, repository.ErrPurchaseQtyExceeded):
+		return ErrPurchaseQtyExceeded
+	case errors.Is(err, repository.ErrPurchaseNotReceived):
+		return ErrPurchaseNotReceived
+	case errors.Is(err, repository.ErrSupplierNotFound):
+		return ErrSupplierNotFound
+	case errors.Is(err, repository.ErrProductNotFound):
+		return ErrProductNotFound
+	default:
+		return variantErr(err)
+	}
+}
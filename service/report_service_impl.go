@@ -29,9 +29,12 @@ func (r *reportServiceImpl) MonthlySales(ctx context.Context, page, pageSize int
 	var responses []*entity.SalesReport
 	for _, v := range result {
 		response := entity.SalesReport{
-			Month:      v.Month,
-			TotalQty:   v.TotalQty,
-			TotalSales: v.TotalSales,
+			Month:       v.Month,
+			TotalQty:    v.TotalQty,
+			TotalSales:  v.TotalSales,
+			ReturnedQty: v.ReturnedQty,
+			TotalRefund: v.TotalRefund,
+			NetSales:    v.NetSales,
 		}
 		responses = append(responses, &response)
 	}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	pg "simple-toko/web"
+	web "simple-toko/web/returns"
+)
+
+type ReturnService interface {
+	Create(ctx context.Context, req *web.ReturnCreateRequest) (*web.ReturnResponse, error)
+	FindById(ctx context.Context, id, userId uint) (*web.ReturnResponse, error)
+	FindAll(ctx context.Context, page, pageSize int, userId uint, status string) (*pg.PaginatedResponse, error)
+	Approve(ctx context.Context, req *web.ReturnReviewRequest) (*web.ReturnResponse, error)
+	Reject(ctx context.Context, req *web.ReturnReviewRequest) (*web.ReturnResponse, error)
+	Receive(ctx context.Context, req *web.ReturnReceiveRequest) (*web.ReturnResponse, error)
+	Refund(ctx context.Context, req *web.ReturnRefundRequest) (*web.ReturnResponse, error)
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	"simple-toko/utils"
+	pg "simple-toko/web"
+	web "simple-toko/web/returns"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type returnServiceImpl struct {
+	ReturnRepo repository.ReturnRepository
+	Validate   *validator.Validate
+	Redis      *redis.Client
+}
+
+func NewReturnServiceImpl(returnRepo repository.ReturnRepository, validate *validator.Validate, redis *redis.Client) *returnServiceImpl {
+	return &returnServiceImpl{
+		ReturnRepo: returnRepo,
+		Validate:   validate,
+		Redis:      redis,
+	}
+}
+
+var (
+	ErrReturnNotFound       = errors.New("return request not found")
+	ErrReturnQtyExceeded    = errors.New("return qty exceeds the qty left on the order line")
+	ErrReturnStatusChanged  = errors.New("return status has changed")
+	ErrReturnNotAllowed     = errors.New("return request cannot be processed in its current status")
+	ErrOrderNotDelivered    = errors.New("only delivered orders can be returned")
+	ErrRefundAmountExceeded = errors.New("refund amount exceeds the value of the returned items")
+)
+
+func (r *returnServiceImpl) Create(ctx context.Context, req *web.ReturnCreateRequest) (*web.ReturnResponse, error) {
+	if err := r.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	item, err := r.ReturnRepo.FindOrderProduct(ctx, req.OrderProductID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderItemNotFound) {
+			return nil, ErrOrderItemNotFound
+		}
+		return nil, fmt.Errorf("return service: find order item: %w", err)
+	}
+
+	if item.Order.UserID != req.UserID {
+		return nil, ErrOrderItemNotFound
+	}
+
+	if item.Order.StatusDelivery != repository.Delivered {
+		return nil, ErrOrderNotDelivered
+	}
+
+	ret := entity.ReturnRequest{
+		OrderProductID: req.OrderProductID,
+		UserID:         req.UserID,
+		Qty:            req.Qty,
+		Reason:         req.Reason,
+		Image:          req.Image,
+	}
+
+	result, err := r.ReturnRepo.Create(ctx, &ret)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderItemNotFound):
+			return nil, ErrOrderItemNotFound
+		case errors.Is(err, repository.ErrReturnQtyExceeded):
+			return nil, ErrReturnQtyExceeded
+		default:
+			return nil, fmt.Errorf("return service: create: %w", err)
+		}
+	}
+
+	response := helper.ToReturnResponse(result)
+	return response, nil
+}
+
+func (r *returnServiceImpl) FindById(ctx context.Context, id, userId uint) (*web.ReturnResponse, error) {
+	result, err := r.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// userId 0 is an admin, who may read any return
+	if userId != 0 && result.UserID != userId {
+		return nil, ErrReturnNotFound
+	}
+
+	response := helper.ToReturnResponse(result)
+	return response, nil
+}
+
+func (r *returnServiceImpl) FindAll(ctx context.Context, page, pageSize int, userId uint, status string) (*pg.PaginatedResponse, error) {
+	if err := r.Validate.Var(status, "omitempty,oneof=requested approved rejected received refunded"); err != nil {
+		return nil, ErrorValidation
+	}
+
+	filter := entity.ReturnFilter{
+		UserID: userId,
+		Status: status,
+	}
+
+	result, totalItems, err := r.ReturnRepo.FindAll(ctx, page, pageSize, &filter)
+	if err != nil {
+		return nil, fmt.Errorf("return service: find all: %w", err)
+	}
+
+	responses := make([]*web.ReturnResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToReturnResponse(v))
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	paginateResp := helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses)
+	return paginateResp, nil
+}
+
+func (r *returnServiceImpl) Approve(ctx context.Context, req *web.ReturnReviewRequest) (*web.ReturnResponse, error) {
+	return r.review(ctx, req, repository.ReturnApproved)
+}
+
+func (r *returnServiceImpl) Reject(ctx context.Context, req *web.ReturnReviewRequest) (*web.ReturnResponse, error) {
+	return r.review(ctx, req, repository.ReturnRejected)
+}
+
+func (r *returnServiceImpl) Receive(ctx context.Context, req *web.ReturnReceiveRequest) (*web.ReturnResponse, error) {
+	if err := r.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	ret, err := r.find(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != repository.ReturnApproved {
+		return nil, ErrReturnNotAllowed
+	}
+
+	result, err := r.ReturnRepo.Receive(ctx, ret, req.Restock, req.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrReturnStatusChanged) {
+			return nil, ErrReturnStatusChanged
+		}
+		if errors.Is(err, repository.ErrStockNotRestorable) {
+			return nil, fmt.Errorf("%w: %v", ErrStockNotRestorable, err)
+		}
+		return nil, fmt.Errorf("return service: receive: %w", err)
+	}
+
+	if req.Restock {
+		utils.InvalidateCached(ctx, r.Redis, ret.OrderProduct.ProductID)
+	}
+
+	response := helper.ToReturnResponse(result)
+	return response, nil
+}
+
+func (r *returnServiceImpl) Refund(ctx context.Context, req *web.ReturnRefundRequest) (*web.ReturnResponse, error) {
+	if err := r.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	ret, err := r.find(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != repository.ReturnReceived {
+		return nil, ErrReturnNotAllowed
+	}
+
+	// default to a full refund of the returned items at the price paid
+	maxAmount := ret.OrderProduct.UnitPrice * float64(ret.Qty)
+	amount := req.Amount
+	if amount == 0 {
+		amount = maxAmount
+	}
+
+	if amount > maxAmount {
+		return nil, ErrRefundAmountExceeded
+	}
+
+	refund := entity.Refund{
+		Qty:    ret.Qty,
+		Amount: amount,
+	}
+
+	result, err := r.ReturnRepo.Refund(ctx, ret, &refund)
+	if err != nil {
+		if errors.Is(err, repository.ErrReturnStatusChanged) {
+			return nil, ErrReturnStatusChanged
+		}
+		return nil, fmt.Errorf("return service: refund: %w", err)
+	}
+
+	response := helper.ToReturnResponse(result)
+	return response, nil
+}
+
+func (r *returnServiceImpl) review(ctx context.Context, req *web.ReturnReviewRequest, status string) (*web.ReturnResponse, error) {
+	if err := r.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	ret, err := r.find(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != repository.ReturnRequested {
+		return nil, ErrReturnNotAllowed
+	}
+
+	result, err := r.ReturnRepo.ChangeStatus(ctx, ret, status, req.Note)
+	if err != nil {
+		if errors.Is(err, repository.ErrReturnStatusChanged) {
+			return nil, ErrReturnStatusChanged
+		}
+		return nil, fmt.Errorf("return service: change status: %w", err)
+	}
+
+	response := helper.ToReturnResponse(result)
+	return response, nil
+}
+
+func (r *returnServiceImpl) find(ctx context.Context, id uint) (*entity.ReturnRequest, error) {
+	result, err := r.ReturnRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReturnNotFound) {
+			return nil, ErrReturnNotFound
+		}
+		return nil, fmt.Errorf("return service: find return: %w", err)
+	}
+
+	return result, nil
+}
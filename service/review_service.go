@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	pg "simple-toko/web"
+	web "simple-toko/web/review"
+)
+
+type ReviewService interface {
+	Create(ctx context.Context, req *web.ReviewCreateRequest) (*web.ReviewResponse, error)
+	CheckReviewable(ctx context.Context, req *web.ReviewCreateRequest) error
+	FindById(ctx context.Context, id, userId uint) (*web.ReviewResponse, error)
+	FindByProduct(ctx context.Context, page, pageSize int, productId uint) (*pg.PaginatedResponse, error)
+	FindByUser(ctx context.Context, page, pageSize int, userId uint) (*pg.PaginatedResponse, error)
+	FindAll(ctx context.Context, page, pageSize int, hidden string) (*pg.PaginatedResponse, error)
+	Hide(ctx context.Context, req *web.ReviewHideRequest) (*web.ReviewResponse, error)
+	Unhide(ctx context.Context, id uint) (*web.ReviewResponse, error)
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	"simple-toko/utils"
+	pg "simple-toko/web"
+	web "simple-toko/web/review"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type reviewServiceImpl struct {
+	ReviewRepo repository.ReviewRepository
+	Validate   *validator.Validate
+	Redis      *redis.Client
+}
+
+func NewReviewServiceImpl(reviewRepo repository.ReviewRepository, validate *validator.Validate, redis *redis.Client) *reviewServiceImpl {
+	return &reviewServiceImpl{
+		ReviewRepo: reviewRepo,
+		Validate:   validate,
+		Redis:      redis,
+	}
+}
+
+const MaxReviewImages = 3
+
+var (
+	ErrReviewNotFound     = errors.New("review not found")
+	ErrReviewExists       = errors.New("order line already has a review")
+	ErrReviewNotDelivered = errors.New("only delivered orders can be reviewed")
+	ErrReviewImages       = fmt.Errorf("a review takes at most %d images", MaxReviewImages)
+)
+
+func (r *reviewServiceImpl) Create(ctx context.Context, req *web.ReviewCreateRequest) (*web.ReviewResponse, error) {
+	if err := r.CheckReviewable(ctx, req); err != nil {
+		return nil, err
+	}
+
+	review := entity.Review{
+		OrderProductID: req.OrderProductID,
+		UserID:         req.UserID,
+		Rating:         req.Rating,
+		Comment:        req.Comment,
+	}
+
+	for _, v := range req.Images {
+		review.Images = append(review.Images, entity.ReviewImage{FileName: v})
+	}
+
+	result, err := r.ReviewRepo.Create(ctx, &review)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderItemNotFound):
+			return nil, ErrOrderItemNotFound
+		case errors.Is(err, repository.ErrReviewExists):
+			return nil, ErrReviewExists
+		default:
+			return nil, fmt.Errorf("review service: create: %w", err)
+		}
+	}
+
+	utils.InvalidateCached(ctx, r.Redis, result.ProductID)
+
+	response := helper.ToReviewResponse(result)
+	return response, nil
+}
+
+// CheckReviewable reports whether the user of req may review its order line,
+// the handler calls it before the photos are stored.
+func (r *reviewServiceImpl) CheckReviewable(ctx context.Context, req *web.ReviewCreateRequest) error {
+	if err := r.Validate.Struct(req); err != nil {
+		return ErrorValidation
+	}
+
+	if len(req.Images) > MaxReviewImages {
+		return ErrReviewImages
+	}
+
+	item, err := r.ReviewRepo.FindOrderProduct(ctx, req.OrderProductID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderItemNotFound) {
+			return ErrOrderItemNotFound
+		}
+		return fmt.Errorf("review service: find order item: %w", err)
+	}
+
+	// a line of someone else's order is reported as missing
+	if item.Order.UserID != req.UserID {
+		return ErrOrderItemNotFound
+	}
+
+	if item.Order.StatusDelivery != repository.Delivered {
+		return ErrReviewNotDelivered
+	}
+
+	return nil
+}
+
+func (r *reviewServiceImpl) FindById(ctx context.Context, id, userId uint) (*web.ReviewResponse, error) {
+	result, err := r.find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// userId 0 is an admin, a hidden review is only left to its author
+	if userId != 0 && result.Hidden && result.UserID != userId {
+		return nil, ErrReviewNotFound
+	}
+
+	response := helper.ToReviewResponse(result)
+	return response, nil
+}
+
+func (r *reviewServiceImpl) FindByProduct(ctx context.Context, page, pageSize int, productId uint) (*pg.PaginatedResponse, error) {
+	hidden := false
+
+	filter := entity.ReviewFilter{
+		ProductID: productId,
+		Hidden:    &hidden,
+	}
+
+	return r.findAll(ctx, page, pageSize, &filter)
+}
+
+func (r *reviewServiceImpl) FindByUser(ctx context.Context, page, pageSize int, userId uint) (*pg.PaginatedResponse, error) {
+	filter := entity.ReviewFilter{
+		UserID: userId,
+	}
+
+	return r.findAll(ctx, page, pageSize, &filter)
+}
+
+func (r *reviewServiceImpl) FindAll(ctx context.Context, page, pageSize int, hidden string) (*pg.PaginatedResponse, error) {
+	if err := r.Validate.Var(hidden, "omitempty,oneof=true false"); err != nil {
+		return nil, ErrorValidation
+	}
+
+	filter := entity.ReviewFilter{}
+
+	if hidden != "" {
+		value, _ := strconv.ParseBool(hidden)
+		filter.Hidden = &value
+	}
+
+	return r.findAll(ctx, page, pageSize, &filter)
+}
+
+func (r *reviewServiceImpl) Hide(ctx context.Context, req *web.ReviewHideRequest) (*web.ReviewResponse, error) {
+	if err := r.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	return r.setHidden(ctx, req.ID, true, req.Reason)
+}
+
+func (r *reviewServiceImpl) Unhide(ctx context.Context, id uint) (*web.ReviewResponse, error) {
+	return r.setHidden(ctx, id, false, "")
+}
+
+func (r *reviewServiceImpl) setHidden(ctx context.Context, id uint, hidden bool, reason string) (*web.ReviewResponse, error) {
+	result, err := r.ReviewRepo.SetHidden(ctx, id, hidden, reason)
+	if err != nil {
+		if errors.Is(err, repository.ErrReviewNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("review service: set hidden: %w", err)
+	}
+
+	utils.InvalidateCached(ctx, r.Redis, result.ProductID)
+
+	response := helper.ToReviewResponse(result)
+	return response, nil
+}
+
+func (r *reviewServiceImpl) findAll(ctx context.Context, page, pageSize int, filter *entity.ReviewFilter) (*pg.PaginatedResponse, error) {
+	result, totalItems, err := r.ReviewRepo.FindAll(ctx, page, pageSize, filter)
+	if err != nil {
+		return nil, fmt.Errorf("review service: find all: %w", err)
+	}
+
+	responses := make([]*web.ReviewResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToReviewResponse(v))
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	paginateResp := helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses)
+	return paginateResp, nil
+}
+
+func (r *reviewServiceImpl) find(ctx context.Context, id uint) (*entity.Review, error) {
+	result, err := r.ReviewRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrReviewNotFound) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("review service: find review: %w", err)
+	}
+
+	return result, nil
+}
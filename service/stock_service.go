@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	pg "simple-toko/web"
+	web "simple-toko/web/stock"
+)
+
+type StockService interface {
+	FindByProduct(ctx context.Context, productId uint) ([]*web.StockResponse, error)
+	FindByInventory(ctx context.Context, inventoryId uint, page, pageSize int) (*pg.PaginatedResponse, error)
+	FindMovements(ctx context.Context, page, pageSize int, req *web.StockMovementFilterRequest) (*pg.PaginatedResponse, error)
+	Reconcile(ctx context.Context) ([]web.StockMismatchResponse, error)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	pg "simple-toko/web"
+	web "simple-toko/web/stock"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type stockServiceImpl struct {
+	StockRepo repository.StockRepository
+	Validate  *validator.Validate
+}
+
+func NewStockServiceImpl(stockRepo repository.StockRepository, validate *validator.Validate) *stockServiceImpl {
+	return &stockServiceImpl{
+		StockRepo: stockRepo,
+		Validate:  validate,
+	}
+}
+
+var ErrInventoryNotFound = errors.New("inventory not found")
+
+// stockActor is the user saved on a stock movement, 0 is the system.
+func stockActor(userId uint) *uint {
+	if userId == 0 {
+		return nil
+	}
+
+	return &userId
+}
+
+func (s *stockServiceImpl) FindByProduct(ctx context.Context, productId uint) ([]*web.StockResponse, error) {
+	result, err := s.StockRepo.FindByProduct(ctx, productId)
+	if err != nil {
+		if errors.Is(err, repository.ErrorIdNotFound) {
+			return nil, ErrorIdNotFound
+		}
+		return nil, fmt.Errorf("stock service: find by product: %w", err)
+	}
+
+	return helper.ToStockResponses(result), nil
+}
+
+func (s *stockServiceImpl) FindByInventory(ctx context.Context, inventoryId uint, page, pageSize int) (*pg.PaginatedResponse, error) {
+	result, totalItems, err := s.StockRepo.FindByInventory(ctx, inventoryId, page, pageSize)
+	if err != nil {
+		if errors.Is(err, repository.ErrorIdNotFound) {
+			return nil, ErrorIdNotFound
+		}
+		return nil, fmt.Errorf("stock service: find by inventory: %w", err)
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	return helper.ToPaginatedResponse(int64(page), totalPage, totalItems, helper.ToStockResponses(result)), nil
+}
+
+func (s *stockServiceImpl) FindMovements(ctx context.Context, page, pageSize int, req *web.StockMovementFilterRequest) (*pg.PaginatedResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	filter := entity.StockMovementFilter{
+		ProductID:   req.ProductID,
+		VariantID:   req.VariantID,
+		InventoryID: req.InventoryID,
+		Reason:      req.Reason,
+	}
+
+	if req.DateFrom != "" {
+		from, _ := time.ParseInLocation("2006-01-02", req.DateFrom, time.Local)
+		filter.CreatedFrom = &from
+	}
+
+	if req.DateTo != "" {
+		to, _ := time.ParseInLocation("2006-01-02", req.DateTo, time.Local)
+		to = to.AddDate(0, 0, 1)
+		filter.CreatedTo = &to
+	}
+
+	result, totalItems, err := s.StockRepo.FindMovements(ctx, page, pageSize, &filter)
+	if err != nil {
+		if errors.Is(err, repository.ErrorIdNotFound) {
+			return nil, ErrorIdNotFound
+		}
+		return nil, fmt.Errorf("stock service: find movements: %w", err)
+	}
+
+	responses := make([]*web.StockMovementResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToStockMovementResponse(v))
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	return helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses), nil
+}
+
+// Reconcile lists the stock that does not match its ledger, empty means the
+// ledger is complete.
+func (s *stockServiceImpl) Reconcile(ctx context.Context) ([]web.StockMismatchResponse, error) {
+	result, err := s.StockRepo.Reconcile(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("stock service: reconcile: %w", err)
+	}
+
+	responses := make([]web.StockMismatchResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToStockMismatchResponse(v))
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	pg "simple-toko/web"
+	web "simple-toko/web/stock"
+)
+
+type StockTransferService interface {
+	Create(ctx context.Context, req *web.StockTransferCreateRequest) (*web.StockTransferResponse, error)
+	Update(ctx context.Context, req *web.StockTransferCreateRequest) (*web.StockTransferResponse, error)
+	FindById(ctx context.Context, id uint) (*web.StockTransferResponse, error)
+	FindAll(ctx context.Context, page, pageSize int, req *web.StockTransferFilterRequest) (*pg.PaginatedResponse, error)
+	Ship(ctx context.Context, id, userId uint) (*web.StockTransferResponse, error)
+	Receive(ctx context.Context, req *web.StockTransferReceiveRequest) (*web.StockTransferResponse, error)
+	Cancel(ctx context.Context, id uint) (*web.StockTransferResponse, error)
+}
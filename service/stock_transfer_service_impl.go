@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	"simple-toko/utils"
+	pg "simple-toko/web"
+	web "simple-toko/web/stock"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type stockTransferServiceImpl struct {
+	TransferRepo repository.StockTransferRepository
+	Validate     *validator.Validate
+	Redis        *redis.Client
+}
+
+func NewStockTransferServiceImpl(transferRepo repository.StockTransferRepository, validate *validator.Validate, redis *redis.Client) *stockTransferServiceImpl {
+	return &stockTransferServiceImpl{
+		TransferRepo: transferRepo,
+		Validate:     validate,
+		Redis:        redis,
+	}
+}
+
+var (
+	ErrTransferNotFound      = errors.New("stock transfer not found")
+	ErrTransferStatusChanged = errors.New("stock transfer status has changed")
+	ErrTransferLineNotFound  = errors.New("stock transfer line not found")
+	ErrTransferQtyExceeded   = errors.New("received qty exceeds the qty shipped on the line")
+	ErrTransferSameLocation  = errors.New("source and destination must be different locations")
+	ErrTransferDuplicateLine = errors.New("product is listed more than once on the transfer")
+)
+
+func (s *stockTransferServiceImpl) Create(ctx context.Context, req *web.StockTransferCreateRequest) (*web.StockTransferResponse, error) {
+	transfer, err := s.toTransfer(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.TransferRepo.Create(ctx, transfer)
+	if err != nil {
+		if err := transferErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer service: create: %w", err)
+	}
+
+	return helper.ToStockTransferResponse(result), nil
+}
+
+func (s *stockTransferServiceImpl) Update(ctx context.Context, req *web.StockTransferCreateRequest) (*web.StockTransferResponse, error) {
+	transfer, err := s.toTransfer(req)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer.ID = req.ID
+
+	result, err := s.TransferRepo.Update(ctx, transfer)
+	if err != nil {
+		if err := transferErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer service: update: %w", err)
+	}
+
+	return helper.ToStockTransferResponse(result), nil
+}
+
+func (s *stockTransferServiceImpl) FindById(ctx context.Context, id uint) (*web.StockTransferResponse, error) {
+	result, err := s.TransferRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrTransferNotFound) {
+			return nil, ErrTransferNotFound
+		}
+		return nil, fmt.Errorf("stock transfer service: find by id: %w", err)
+	}
+
+	return helper.ToStockTransferResponse(result), nil
+}
+
+func (s *stockTransferServiceImpl) FindAll(ctx context.Context, page, pageSize int, req *web.StockTransferFilterRequest) (*pg.PaginatedResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	filter := entity.StockTransferFilter{
+		Status:      req.Status,
+		InventoryID: req.InventoryID,
+	}
+
+	result, totalItems, err := s.TransferRepo.FindAll(ctx, page, pageSize, &filter)
+	if err != nil {
+		return nil, fmt.Errorf("stock transfer service: find all: %w", err)
+	}
+
+	responses := make([]*web.StockTransferResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToStockTransferResponse(v))
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	return helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses), nil
+}
+
+func (s *stockTransferServiceImpl) Ship(ctx context.Context, id, userId uint) (*web.StockTransferResponse, error) {
+	result, err := s.TransferRepo.Ship(ctx, id, userId)
+	if err != nil {
+		if err := transferErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer service: ship: %w", err)
+	}
+
+	s.invalidate(ctx, result)
+
+	return helper.ToStockTransferResponse(result), nil
+}
+
+func (s *stockTransferServiceImpl) Receive(ctx context.Context, req *web.StockTransferReceiveRequest) (*web.StockTransferResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if len(req.Lines) == 0 && !req.Close {
+		return nil, ErrorValidation
+	}
+
+	receipts := make([]entity.StockTransferReceipt, 0, len(req.Lines))
+	seen := map[uint]bool{}
+	for _, v := range req.Lines {
+		if seen[v.LineID] {
+			return nil, ErrTransferDuplicateLine
+		}
+		seen[v.LineID] = true
+
+		receipts = append(receipts, entity.StockTransferReceipt{
+			LineID: v.LineID,
+			Qty:    v.Qty,
+			Note:   v.Note,
+		})
+	}
+
+	result, err := s.TransferRepo.Receive(ctx, req.ID, req.UserID, receipts, req.Close)
+	if err != nil {
+		if err := transferErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer service: receive: %w", err)
+	}
+
+	s.invalidate(ctx, result)
+
+	return helper.ToStockTransferResponse(result), nil
+}
+
+func (s *stockTransferServiceImpl) Cancel(ctx context.Context, id uint) (*web.StockTransferResponse, error) {
+	result, err := s.TransferRepo.Cancel(ctx, id)
+	if err != nil {
+		if err := transferErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stock transfer service: cancel: %w", err)
+	}
+
+	return helper.ToStockTransferResponse(result), nil
+}
+
+func (s *stockTransferServiceImpl) toTransfer(req *web.StockTransferCreateRequest) (*entity.StockTransfer, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	if req.SourceID == req.DestinationID {
+		return nil, ErrTransferSameLocation
+	}
+
+	transfer := entity.StockTransfer{
+		SourceID:      req.SourceID,
+		DestinationID: req.DestinationID,
+		Note:          req.Note,
+		UserID:        req.UserID,
+	}
+
+	seen := map[[2]uint]bool{}
+	for _, v := range req.Lines {
+		key := [2]uint{v.ProductID, v.VariantID}
+		if seen[key] {
+			return nil, ErrTransferDuplicateLine
+		}
+		seen[key] = true
+
+		transfer.Lines = append(transfer.Lines, entity.StockTransferLine{
+			ProductID: v.ProductID,
+			VariantID: v.VariantID,
+			Qty:       v.Qty,
+			Note:      v.Note,
+		})
+	}
+
+	return &transfer, nil
+}
+
+// transferErr maps the repository errors of a transfer, nil means the error
+// is unexpected.
+// invalidate drops the cached products of a transfer once its stock moved.
+func (s *stockTransferServiceImpl) invalidate(ctx context.Context, transfer *entity.StockTransfer) {
+	for _, v := range transfer.Lines {
+		utils.InvalidateCached(ctx, s.Redis, v.ProductID)
+	}
+}
+
+func transferErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrTransferNotFound):
+		return ErrTransferNotFound
+	case errors.Is(err, repository.ErrTransferStatusChanged):
+		return ErrTransferStatusChanged
+	case errors.Is(err, repository.ErrTransferLineNotFound):
+		return ErrTransferLineNotFound
+	case errors.Is(err, repository.ErrTransferQtyExceeded):
+		return ErrTransferQtyExceeded
+	case errors.Is(err, repository.ErrProductNotFound):
+		return ErrProductNotFound
+	case errors.Is(err, repository.ErrNotEnoughStock):
+		return ErrNotEnoughStock
+	default:
+		return variantErr(err)
+	}
+}
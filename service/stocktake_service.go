@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	pg "simple-toko/web"
+	web "simple-toko/web/stock"
+)
+
+type StocktakeService interface {
+	Create(ctx context.Context, req *web.StocktakeCreateRequest) (*web.StocktakeResponse, error)
+	FindById(ctx context.Context, id uint, varianceOnly bool) (*web.StocktakeResponse, error)
+	FindAll(ctx context.Context, page, pageSize int, req *web.StocktakeFilterRequest) (*pg.PaginatedResponse, error)
+	Count(ctx context.Context, req *web.StocktakeCountsRequest) (*web.StocktakeResponse, error)
+	UploadCounts(ctx context.Context, id, userId uint, fileName string) (*web.StocktakeResponse, error)
+	Approve(ctx context.Context, id, userId uint) (*web.StocktakeResponse, error)
+	Cancel(ctx context.Context, id uint) (*web.StocktakeResponse, error)
+}
@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	"simple-toko/storage"
+	"simple-toko/utils"
+	pg "simple-toko/web"
+	web "simple-toko/web/stock"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/redis/go-redis/v9"
+)
+
+type stocktakeServiceImpl struct {
+	StocktakeRepo repository.StocktakeRepository
+	Validate      *validator.Validate
+	Redis         *redis.Client
+	Storage       storage.Storage
+}
+
+func NewStocktakeServiceImpl(stocktakeRepo repository.StocktakeRepository, validate *validator.Validate, redis *redis.Client, store storage.Storage) *stocktakeServiceImpl {
+	return &stocktakeServiceImpl{
+		StocktakeRepo: stocktakeRepo,
+		Validate:      validate,
+		Redis:         redis,
+		Storage:       store,
+	}
+}
+
+const StocktakePath = "stocktake/"
+
+var (
+	ErrStocktakeNotFound      = errors.New("stocktake not found")
+	ErrStocktakeStatusChanged = errors.New("stocktake status has changed")
+	ErrStocktakeOpen          = errors.New("inventory already has an open stocktake")
+	ErrStocktakeLineNotFound  = errors.New("stocktake line not found")
+	ErrStocktakeSkuNotFound   = errors.New("sku not found")
+	ErrStocktakeDuplicate     = errors.New("line is counted more than once")
+	ErrStocktakeFile          = errors.New("count file cannot be read")
+)
+
+func (s *stocktakeServiceImpl) Create(ctx context.Context, req *web.StocktakeCreateRequest) (*web.StocktakeResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	stocktake := entity.Stocktake{
+		InventoryID: req.InventoryID,
+		Note:        req.Note,
+		UserID:      req.UserID,
+	}
+
+	result, err := s.StocktakeRepo.Create(ctx, &stocktake)
+	if err != nil {
+		if err := stocktakeErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stocktake service: create: %w", err)
+	}
+
+	return helper.ToStocktakeDetail(result, false), nil
+}
+
+func (s *stocktakeServiceImpl) FindById(ctx context.Context, id uint, varianceOnly bool) (*web.StocktakeResponse, error) {
+	result, err := s.StocktakeRepo.FindById(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrStocktakeNotFound) {
+			return nil, ErrStocktakeNotFound
+		}
+		return nil, fmt.Errorf("stocktake service: find by id: %w", err)
+	}
+
+	return helper.ToStocktakeDetail(result, varianceOnly), nil
+}
+
+func (s *stocktakeServiceImpl) FindAll(ctx context.Context, page, pageSize int, req *web.StocktakeFilterRequest) (*pg.PaginatedResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	filter := entity.StocktakeFilter{
+		Status:      req.Status,
+		InventoryID: req.InventoryID,
+	}
+
+	result, totalItems, err := s.StocktakeRepo.FindAll(ctx, page, pageSize, &filter)
+	if err != nil {
+		return nil, fmt.Errorf("stocktake service: find all: %w", err)
+	}
+
+	responses := make([]*web.StocktakeResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToStocktakeResponse(v))
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	return helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses), nil
+}
+
+func (s *stocktakeServiceImpl) Count(ctx context.Context, req *web.StocktakeCountsRequest) (*web.StocktakeResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	counts := make([]entity.StocktakeCount, 0, len(req.Counts))
+	lines := map[uint]bool{}
+	skus := map[string]bool{}
+	for _, v := range req.Counts {
+		sku := strings.TrimSpace(v.SKU)
+
+		if (v.LineID != 0 && lines[v.LineID]) || (v.LineID == 0 && skus[sku]) {
+			return nil, ErrStocktakeDuplicate
+		}
+		lines[v.LineID] = true
+		skus[sku] = true
+
+		counts = append(counts, entity.StocktakeCount{
+			LineID:  v.LineID,
+			SKU:     sku,
+			Counted: *v.Counted,
+		})
+	}
+
+	return s.count(ctx, req.ID, req.UserID, counts)
+}
+
+// UploadCounts reads the counts from a csv with the columns sku and counted,
+// the whole file is saved or, when one row is wrong, none of it.
+func (s *stocktakeServiceImpl) UploadCounts(ctx context.Context, id, userId uint, fileName string) (*web.StocktakeResponse, error) {
+	defer func() {
+		if err := s.Storage.Delete(ctx, StocktakePath+fileName); err != nil {
+			fmt.Printf("failed remove stocktake file %s: %v\n", fileName, err)
+		}
+	}()
+
+	records, err := readCsv(ctx, s.Storage, StocktakePath+fileName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStocktakeFile, err)
+	}
+
+	header := map[string]int{}
+	for i, v := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(v))] = i
+	}
+
+	for _, v := range []string{"sku", "counted"} {
+		if _, ok := header[v]; !ok {
+			return nil, fmt.Errorf("%w: column %s is missing", ErrStocktakeFile, v)
+		}
+	}
+
+	counts := make([]entity.StocktakeCount, 0, len(records)-1)
+	seen := map[string]int{}
+	for i, v := range records[1:] {
+		row := i + 2 //the header is the first line of the file
+
+		value := func(column string) string {
+			index := header[column]
+			if index >= len(v) {
+				return ""
+			}
+			return strings.TrimSpace(v[index])
+		}
+
+		sku := value("sku")
+		if sku == "" {
+			return nil, fmt.Errorf("%w: row %d: sku is required", ErrStocktakeFile, row)
+		}
+
+		if first, ok := seen[sku]; ok {
+			return nil, fmt.Errorf("%w: row %d: sku %s is already counted on row %d", ErrStocktakeFile, row, sku, first)
+		}
+		seen[sku] = row
+
+		counted, err := strconv.Atoi(value("counted"))
+		if err != nil || counted < 0 {
+			return nil, fmt.Errorf("%w: row %d: counted must be a whole number of 0 or more", ErrStocktakeFile, row)
+		}
+
+		counts = append(counts, entity.StocktakeCount{SKU: sku, Counted: counted})
+	}
+
+	if len(counts) == 0 {
+		return nil, fmt.Errorf("%w: file has no rows", ErrStocktakeFile)
+	}
+
+	return s.count(ctx, id, userId, counts)
+}
+
+func (s *stocktakeServiceImpl) Approve(ctx context.Context, id, userId uint) (*web.StocktakeResponse, error) {
+	result, err := s.StocktakeRepo.Approve(ctx, id, userId)
+	if err != nil {
+		if err := stocktakeErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stocktake service: approve: %w", err)
+	}
+
+	//a stocktake can cover a whole location, every product key goes at once
+	utils.InvalidateProductsCached(ctx, s.Redis)
+
+	return helper.ToStocktakeDetail(result, false), nil
+}
+
+func (s *stocktakeServiceImpl) Cancel(ctx context.Context, id uint) (*web.StocktakeResponse, error) {
+	result, err := s.StocktakeRepo.Cancel(ctx, id)
+	if err != nil {
+		if err := stocktakeErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stocktake service: cancel: %w", err)
+	}
+
+	return helper.ToStocktakeDetail(result, false), nil
+}
+
+func (s *stocktakeServiceImpl) count(ctx context.Context, id, userId uint, counts []entity.StocktakeCount) (*web.StocktakeResponse, error) {
+	result, err := s.StocktakeRepo.Count(ctx, id, userId, counts)
+	if err != nil {
+		if err := stocktakeErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("stocktake service: count: %w", err)
+	}
+
+	return helper.ToStocktakeDetail(result, false), nil
+}
+
+// stocktakeErr maps the repository errors of a stocktake, nil means the error
+// is unexpected.
+func stocktakeErr(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrStocktakeNotFound):
+		return ErrStocktakeNotFound
+	case errors.Is(err, repository.ErrStocktakeStatusChanged):
+		return ErrStocktakeStatusChanged
+	case errors.Is(err, repository.ErrStocktakeOpen):
+		return ErrStocktakeOpen
+	case errors.Is(err, repository.ErrStocktakeLineNotFound):
+		return ErrStocktakeLineNotFound
+	case errors.Is(err, repository.ErrStocktakeSkuNotFound):
+		//keep the sku named by the repository
+		sku := strings.TrimPrefix(err.Error(), repository.ErrStocktakeSkuNotFound.Error())
+		return fmt.Errorf("%w%s", ErrStocktakeSkuNotFound, sku)
+	case errors.Is(err, repository.ErrNotEnoughStock):
+		return ErrNotEnoughStock
+	default:
+		return variantErr(err)
+	}
+}
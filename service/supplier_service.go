@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	pg "simple-toko/web"
+	web "simple-toko/web/supplier"
+)
+
+type SupplierService interface {
+	Create(ctx context.Context, req *web.SupplierCreateRequest) (*web.SupplierResponse, error)
+	Update(ctx context.Context, supplierId uint, req *web.SupplierCreateRequest) (*web.SupplierResponse, error)
+	Delete(ctx context.Context, supplierId uint) error
+	FindById(ctx context.Context, supplierId uint) (*web.SupplierResponse, error)
+	FindAll(ctx context.Context, page, pageSize int, search string) (*pg.PaginatedResponse, error)
+}
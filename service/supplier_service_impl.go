@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"simple-toko/entity"
+	"simple-toko/helper"
+	"simple-toko/repository"
+	pg "simple-toko/web"
+	web "simple-toko/web/supplier"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type supplierServiceImpl struct {
+	SupplierRepo repository.SupplierRepository
+	Validate     *validator.Validate
+}
+
+func NewSupplierServiceImpl(supplierRepo repository.SupplierRepository, validate *validator.Validate) *supplierServiceImpl {
+	return &supplierServiceImpl{
+		SupplierRepo: supplierRepo,
+		Validate:     validate,
+	}
+}
+
+var (
+	ErrSupplierNotFound = errors.New("supplier not found")
+	ErrSupplierInUse    = errors.New("supplier still has open purchase orders")
+)
+
+func (s *supplierServiceImpl) Create(ctx context.Context, req *web.SupplierCreateRequest) (*web.SupplierResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	supplier := entity.Supplier{
+		Name:    strings.TrimSpace(req.Name),
+		Email:   req.Email,
+		Phone:   req.Phone,
+		Address: req.Address,
+	}
+
+	result, err := s.SupplierRepo.Create(ctx, &supplier)
+	if err != nil {
+		return nil, fmt.Errorf("supplier service: create: %w", err)
+	}
+
+	return helper.ToSupplierResponse(result), nil
+}
+
+func (s *supplierServiceImpl) Update(ctx context.Context, supplierId uint, req *web.SupplierCreateRequest) (*web.SupplierResponse, error) {
+	if err := s.Validate.Struct(req); err != nil {
+		return nil, ErrorValidation
+	}
+
+	update := entity.Supplier{
+		Name:    strings.TrimSpace(req.Name),
+		Email:   req.Email,
+		Phone:   req.Phone,
+		Address: req.Address,
+	}
+
+	result, err := s.SupplierRepo.Update(ctx, supplierId, &update)
+	if err != nil {
+		if errors.Is(err, repository.ErrSupplierNotFound) {
+			return nil, ErrSupplierNotFound
+		}
+		return nil, fmt.Errorf("supplier service: update: %w", err)
+	}
+
+	return helper.ToSupplierResponse(result), nil
+}
+
+func (s *supplierServiceImpl) Delete(ctx context.Context, supplierId uint) error {
+	if err := s.SupplierRepo.Delete(ctx, supplierId); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSupplierNotFound):
+			return ErrSupplierNotFound
+		case errors.Is(err, repository.ErrSupplierInUse):
+			return ErrSupplierInUse
+		default:
+			return fmt.Errorf("supplier service: delete: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *supplierServiceImpl) FindById(ctx context.Context, supplierId uint) (*web.SupplierResponse, error) {
+	result, err := s.SupplierRepo.FindById(ctx, supplierId)
+	if err != nil {
+		if errors.Is(err, repository.ErrSupplierNotFound) {
+			return nil, ErrSupplierNotFound
+		}
+		return nil, fmt.Errorf("supplier service: find by id: %w", err)
+	}
+
+	return helper.ToSupplierResponse(result), nil
+}
+
+func (s *supplierServiceImpl) FindAll(ctx context.Context, page, pageSize int, search string) (*pg.PaginatedResponse, error) {
+	result, totalItems, err := s.SupplierRepo.FindAll(ctx, page, pageSize, strings.TrimSpace(search))
+	if err != nil {
+		return nil, fmt.Errorf("supplier service: find all: %w", err)
+	}
+
+	responses := make([]*web.SupplierResponse, 0, len(result))
+	for _, v := range result {
+		responses = append(responses, helper.ToSupplierResponse(v))
+	}
+
+	totalPage := int(math.Ceil(float64(totalItems) / float64(pageSize)))
+
+	return helper.ToPaginatedResponse(int64(page), totalPage, totalItems, responses), nil
+}
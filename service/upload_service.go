@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	"mime/multipart"
+	"simple-toko/storage"
+)
+
+type UploadService interface {
+	Save(ctx context.Context, file *multipart.FileHeader, dir string, allowed ...string) (string, error)
+	SaveImage(ctx context.Context, file *multipart.FileHeader, dir string) (string, error)
+	MaxBodySize(files int) int64
+	Open(ctx context.Context, dir, name string) (*storage.Object, error)
+	Remove(ctx context.Context, dir, name string)
+	RemoveImage(ctx context.Context, dir, name string)
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"simple-toko/imaging"
+	"simple-toko/storage"
+	"simple-toko/utils"
+	"strconv"
+)
+
+type uploadServiceImpl struct {
+	Storage storage.Storage
+	MaxSize int64
+}
+
+func NewUploadServiceImpl(store storage.Storage) *uploadServiceImpl {
+	return &uploadServiceImpl{
+		Storage: store,
+		MaxSize: uploadMaxSize(),
+	}
+}
+
+const (
+	MimeJpeg = "image/jpeg"
+	MimePng  = "image/png"
+	MimeGif  = "image/gif"
+	MimePdf  = "application/pdf"
+	MimeCsv  = "text/plain; charset=utf-8" //a csv has no signature, it is sniffed as utf-8 text
+)
+
+// ImageTypes can be decoded for thumbnails, ProofTypes also take a PDF like a
+// bank transfer receipt, CsvTypes are for imports.
+var (
+	ImageTypes = []string{MimeJpeg, MimePng, MimeGif}
+	ProofTypes = []string{MimeJpeg, MimePng, MimePdf}
+	CsvTypes   = []string{MimeCsv}
+)
+
+var uploadExtensions = map[string]string{
+	MimeJpeg: ".jpg",
+	MimePng:  ".png",
+	MimeGif:  ".gif",
+	MimePdf:  ".pdf",
+	MimeCsv:  ".csv",
+}
+
+var (
+	ErrFileEmpty    = errors.New("uploaded file is empty")
+	ErrFileTooLarge = errors.New("uploaded file is too large")
+	ErrFileType     = errors.New("file type is not allowed")
+	ErrFileNotFound = errors.New("file not found")
+)
+
+// Save checks the real content of the upload instead of the name or header the
+// client sent, strips image metadata and stores it in dir under a random name
+// with the extension of the detected type. Only the returned name is kept.
+func (u *uploadServiceImpl) Save(ctx context.Context, file *multipart.FileHeader, dir string, allowed ...string) (string, error) {
+	data, mime, err := u.read(file, allowed)
+	if err != nil {
+		return "", err
+	}
+
+	name, err := uploadName(uploadExtensions[mime])
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.Storage.Put(ctx, dir+name, data, mime); err != nil {
+		return "", fmt.Errorf("upload service: put: %w", err)
+	}
+
+	return name, nil
+}
+
+// SaveImage stores an image like Save together with every size of
+// imaging.Sizes, a file that cannot be decoded is not stored at all.
+func (u *uploadServiceImpl) SaveImage(ctx context.Context, file *multipart.FileHeader, dir string) (string, error) {
+	data, mime, err := u.read(file, ImageTypes)
+	if err != nil {
+		return "", err
+	}
+
+	sizes, err := imaging.GenerateSizes(data)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupported) {
+			return "", fmt.Errorf("%w: damaged %s", ErrFileType, mime)
+		}
+		if errors.Is(err, imaging.ErrTooLarge) {
+			return "", fmt.Errorf("%w: %v", ErrFileTooLarge, err)
+		}
+		return "", fmt.Errorf("upload service: resize: %w", err)
+	}
+
+	name, err := uploadName(uploadExtensions[mime])
+	if err != nil {
+		return "", err
+	}
+
+	if err := u.Storage.Put(ctx, dir+name, data, mime); err != nil {
+		return "", fmt.Errorf("upload service: put: %w", err)
+	}
+
+	for i, s := range imaging.Sizes {
+		if err := u.Storage.Put(ctx, dir+s.Dir+name, sizes[i], mime); err != nil {
+			u.RemoveImage(ctx, dir, name)
+			return "", fmt.Errorf("upload service: put %s: %w", s.Name, err)
+		}
+	}
+
+	return name, nil
+}
+
+// Open returns the stored file, the caller closes the body.
+func (u *uploadServiceImpl) Open(ctx context.Context, dir, name string) (*storage.Object, error) {
+	if name == "" {
+		return nil, ErrFileNotFound
+	}
+
+	result, err := u.Storage.Get(ctx, dir+name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrFileNotFound
+		}
+		return nil, fmt.Errorf("upload service: get: %w", err)
+	}
+
+	return result, nil
+}
+
+// Remove is used to roll back an upload whose row could not be saved, a
+// failure is only logged.
+func (u *uploadServiceImpl) Remove(ctx context.Context, dir, name string) {
+	if name == "" {
+		return
+	}
+
+	if err := u.Storage.Delete(ctx, dir+name); err != nil {
+		fmt.Printf("failed remove file %s: %v\n", dir+name, err)
+	}
+}
+
+func (u *uploadServiceImpl) RemoveImage(ctx context.Context, dir, name string) {
+	utils.RemoveImage(ctx, u.Storage, dir, name)
+}
+
+// read returns the checked and cleaned content of the upload with its
+// detected type.
+func (u *uploadServiceImpl) read(file *multipart.FileHeader, allowed []string) ([]byte, string, error) {
+	if file.Size == 0 {
+		return nil, "", ErrFileEmpty
+	}
+
+	if file.Size > u.MaxSize {
+		return nil, "", fmt.Errorf("%w: max %d bytes", ErrFileTooLarge, u.MaxSize)
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, "", fmt.Errorf("upload service: open: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, u.MaxSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("upload service: read: %w", err)
+	}
+
+	if len(data) == 0 {
+		return nil, "", ErrFileEmpty
+	}
+
+	if int64(len(data)) > u.MaxSize {
+		return nil, "", fmt.Errorf("%w: max %d bytes", ErrFileTooLarge, u.MaxSize)
+	}
+
+	mime := http.DetectContentType(data)
+	if !allowedType(mime, allowed) {
+		return nil, "", fmt.Errorf("%w: %s", ErrFileType, mime)
+	}
+
+	data, err = imaging.StripMetadata(data, mime)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupported) {
+			return nil, "", fmt.Errorf("%w: damaged %s", ErrFileType, mime)
+		}
+		if errors.Is(err, imaging.ErrTooLarge) {
+			return nil, "", fmt.Errorf("%w: %v", ErrFileTooLarge, err)
+		}
+		return nil, "", fmt.Errorf("upload service: strip metadata: %w", err)
+	}
+
+	return data, mime, nil
+}
+
+func allowedType(mime string, allowed []string) bool {
+	for _, v := range allowed {
+		if v == mime {
+			return true
+		}
+	}
+
+	return false
+}
+
+// uploadName draws 128 random bits, a clash with a stored file is not
+// expected so the storage does not need to check for it.
+func uploadName(ext string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", fmt.Errorf("upload service: random name: %w", err)
+	}
+
+	return hex.EncodeToString(random) + ext, nil
+}
+
+// formOverhead leaves room for the text fields and multipart headers sent
+// along with the files.
+const formOverhead = 1 << 20
+
+// MaxBodySize is the largest request body that can carry files uploads.
+func (u *uploadServiceImpl) MaxBodySize(files int) int64 {
+	return int64(files)*u.MaxSize + formOverhead
+}
+
+func uploadMaxSize() int64 {
+	size, err := strconv.Atoi(os.Getenv("UPLOAD_MAX_SIZE"))
+	if err != nil || size < 1 {
+		size = 5
+	}
+
+	return int64(size) << 20
+}
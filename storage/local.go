@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localStorage keeps the files in a folder of the app, only usable while a
+// single container serves the uploads.
+type localStorage struct {
+	Root string
+}
+
+func NewLocalStorage(root string) (*localStorage, error) {
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("local storage: make root: %w", err)
+	}
+
+	return &localStorage{
+		Root: root,
+	}, nil
+}
+
+func (l *localStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return fmt.Errorf("local storage: make folder: %w", err)
+	}
+
+	//write next to the target first so a reader never sees half a file
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("local storage: write: %w", err)
+	}
+
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("local storage: rename: %w", err)
+	}
+
+	return nil
+}
+
+func (l *localStorage) Get(ctx context.Context, key string) (*Object, error) {
+	file, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("local storage: open: %w", err)
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("local storage: stat: %w", err)
+	}
+
+	if stat.IsDir() {
+		f.Close()
+		return nil, ErrNotFound
+	}
+
+	return &Object{
+		Info: Info{
+			Key:         key,
+			Size:        stat.Size(),
+			ContentType: mime.TypeByExtension(path.Ext(key)),
+			ModTime:     stat.ModTime(),
+		},
+		Body: f,
+	}, nil
+}
+
+// Delete ignores a file that is already gone.
+func (l *localStorage) Delete(ctx context.Context, key string) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("local storage: remove: %w", err)
+	}
+
+	return nil
+}
+
+// SignedURL is not available, local files have no address outside the app and
+// are served by the handlers.
+func (l *localStorage) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return "", ErrNoSignedURL
+}
+
+// List walks every folder below prefix.
+func (l *localStorage) List(ctx context.Context, prefix string) ([]Info, error) {
+	dir, err := l.path(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Info
+
+	err = filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() || strings.HasSuffix(file, ".tmp") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		rel, err := filepath.Rel(l.Root, file)
+		if err != nil {
+			return err
+		}
+
+		key := filepath.ToSlash(rel)
+		result = append(result, Info{
+			Key:         key,
+			Size:        info.Size(),
+			ContentType: mime.TypeByExtension(path.Ext(key)),
+			ModTime:     info.ModTime(),
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("local storage: list: %w", err)
+	}
+
+	return result, nil
+}
+
+// path keeps every key inside the root folder.
+func (l *localStorage) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return filepath.Join(l.Root, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,407 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type S3Config struct {
+	Endpoint  string //scheme and host, for example https://s3.ap-southeast-1.amazonaws.com or http://localhost:9000
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool //bucket in the path instead of the host name, needed by most local stand-ins
+}
+
+// s3Storage talks to any S3 compatible service, requests are signed with
+// signature version 4.
+type s3Storage struct {
+	Endpoint  *url.URL
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	Client    *http.Client
+	now       func() time.Time
+}
+
+func NewS3Storage(cfg S3Config) (*s3Storage, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3 storage: invalid endpoint %q", cfg.Endpoint)
+	}
+
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("s3 storage: bucket is required")
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &s3Storage{
+		Endpoint:  endpoint,
+		Region:    region,
+		Bucket:    cfg.Bucket,
+		AccessKey: cfg.AccessKey,
+		SecretKey: cfg.SecretKey,
+		PathStyle: cfg.PathStyle,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+		now:       time.Now,
+	}, nil
+}
+
+const (
+	amzDateFormat   = "20060102T150405Z"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, nil, header, data)
+	if err != nil {
+		return fmt.Errorf("s3 storage: put: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("s3 storage: put %s: %w", key, s3Error(resp))
+	}
+
+	return nil
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) (*Object, error) {
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("s3 storage: get: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("s3 storage: get %s: %w", key, s3Error(resp))
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return &Object{
+		Info: Info{
+			Key:         key,
+			Size:        resp.ContentLength,
+			ContentType: resp.Header.Get("Content-Type"),
+			ModTime:     modTime,
+		},
+		Body: resp.Body,
+	}, nil
+}
+
+// Delete succeeds for a key that does not exist, like S3 itself.
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	if key == "" {
+		return ErrInvalidKey
+	}
+
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("s3 storage: delete: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("s3 storage: delete %s: %w", key, s3Error(resp))
+	}
+
+	return nil
+}
+
+// SignedURL returns a presigned GET url, anyone holding it can download the
+// object until it expires. S3 accepts at most 7 days.
+func (s *s3Storage) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if key == "" {
+		return "", ErrInvalidKey
+	}
+
+	if expires < time.Second || expires > 7*24*time.Hour {
+		return "", fmt.Errorf("s3 storage: expiry must be between 1 second and 7 days")
+	}
+
+	now := s.now().UTC()
+	u := s.objectURL(key)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.AccessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(amzDateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	header := http.Header{}
+	header.Set("Host", u.Host)
+
+	signature := s.signature(now, http.MethodGet, u.EscapedPath(), query, header, unsignedPayload)
+	query.Set("X-Amz-Signature", signature)
+
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through ListObjectsV2 until every key below prefix is read.
+func (s *s3Storage) List(ctx context.Context, prefix string) ([]Info, error) {
+	var result []Info
+	token := ""
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("s3 storage: list: %w", err)
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := s3Error(resp)
+			resp.Body.Close()
+			return nil, fmt.Errorf("s3 storage: list %s: %w", prefix, err)
+		}
+
+		page := listBucketResult{}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("s3 storage: decode list: %w", err)
+		}
+
+		for _, v := range page.Contents {
+			result = append(result, Info{
+				Key:     v.Key,
+				Size:    v.Size,
+				ModTime: v.LastModified,
+			})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return result, nil
+		}
+
+		token = page.NextContinuationToken
+	}
+}
+
+func (s *s3Storage) objectURL(key string) *url.URL {
+	u := *s.Endpoint
+	u.RawQuery = ""
+
+	base := strings.TrimSuffix(u.Path, "/")
+	if s.PathStyle {
+		base += "/" + uriEncode(s.Bucket, false)
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+	}
+
+	escaped := base + "/" + uriEncode(key, false)
+	u.Path, _ = url.PathUnescape(escaped)
+	u.RawPath = escaped
+
+	return &u
+}
+
+// do sends a request signed in the Authorization header, the payload hash is
+// part of the signature so a changed body is rejected.
+func (s *s3Storage) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	now := s.now().UTC()
+	u := s.objectURL(key)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if body == nil {
+		req.Body = http.NoBody
+	}
+	req.ContentLength = int64(len(body))
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	req.Header.Set("Host", u.Host)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signature := s.signature(now, method, u.EscapedPath(), query, req.Header, payloadHash)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, s.scope(now), signedHeaders(req.Header), signature))
+	req.Header.Del("Host")
+
+	return s.Client.Do(req)
+}
+
+func (s *s3Storage) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.Region + "/s3/aws4_request"
+}
+
+func (s *s3Storage) signature(now time.Time, method, path string, query url.Values, header http.Header, payloadHash string) string {
+	canonical := strings.Join([]string{
+		method,
+		path,
+		canonicalQuery(query),
+		canonicalHeaders(header),
+		signedHeaders(header),
+		payloadHash,
+	}, "\n")
+
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format(amzDateFormat),
+		s.scope(now),
+		hex.EncodeToString(sum[:]),
+	}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+s.SecretKey), now.Format("20060102"))
+	key = hmacSha256(key, s.Region)
+	key = hmacSha256(key, "s3")
+	key = hmacSha256(key, "aws4_request")
+
+	return hex.EncodeToString(hmacSha256(key, toSign))
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// canonicalHeaders signs host and every content or x-amz header that is set.
+func canonicalHeaders(header http.Header) string {
+	var b strings.Builder
+	for _, k := range signedHeaderNames(header) {
+		b.WriteString(k)
+		b.WriteString(":")
+		b.WriteString(strings.TrimSpace(header.Get(k)))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func signedHeaders(header http.Header) string {
+	return strings.Join(signedHeaderNames(header), ";")
+}
+
+func signedHeaderNames(header http.Header) []string {
+	var names []string
+	for k := range header {
+		name := strings.ToLower(k)
+		if name == "host" || name == "content-type" || name == "content-md5" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// uriEncode escapes everything except the unreserved characters, as the
+// signature requires. A slash is kept inside object paths.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+
+	return b.String()
+}
+
+type s3ErrorBody struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func s3Error(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+	body := s3ErrorBody{}
+	if err := xml.Unmarshal(data, &body); err == nil && body.Code != "" {
+		return fmt.Errorf("status %d: %s: %s", resp.StatusCode, body.Code, body.Message)
+	}
+
+	return fmt.Errorf("status %d", resp.StatusCode)
+}
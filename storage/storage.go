@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	ErrNotFound    = errors.New("object not found")
+	ErrNoSignedURL = errors.New("storage has no signed url")
+	ErrInvalidKey  = errors.New("invalid object key")
+)
+
+// Storage keeps uploaded files under a slash separated key like
+// "product/abc.jpg", so the same key works on disk and in a bucket.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+	List(ctx context.Context, prefix string) ([]Info, error)
+}
+
+type Info struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Object is an opened file, the caller must close Body.
+type Object struct {
+	Info
+	Body io.ReadCloser
+}
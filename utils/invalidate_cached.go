@@ -7,13 +7,29 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// InvalidateCached drops a product and every listing that may contain it,
+// category listings carry product counts so they go as well.
 func InvalidateCached(ctx context.Context, rds *redis.Client, id uint) {
 	keyId := fmt.Sprintf("products:%d", id)
 	if err := rds.Del(ctx, keyId).Err(); err != nil {
 		fmt.Printf("failed delete cache find by id on key %s: %v\n", keyId, err)
 	}
 
-	i := rds.Scan(ctx, 0, "products:page*", 0).Iterator()
+	deletePattern(ctx, rds, "products:page*")
+	deletePattern(ctx, rds, "categories:*")
+}
+
+func InvalidateOrderCached(ctx context.Context, rds *redis.Client, id uint) {
+	keyId := fmt.Sprintf("orders:%d", id)
+	if err := rds.Del(ctx, keyId).Err(); err != nil {
+		fmt.Printf("failed delete cache find by id on key %s: %v\n", keyId, err)
+	}
+
+	deletePattern(ctx, rds, "orders:page*")
+}
+
+func deletePattern(ctx context.Context, rds *redis.Client, pattern string) {
+	i := rds.Scan(ctx, 0, pattern, 0).Iterator()
 	for i.Next(ctx) {
 		keys := i.Val()
 		if err := rds.Del(ctx, keys).Err(); err != nil {
@@ -25,3 +41,16 @@ func InvalidateCached(ctx context.Context, rds *redis.Client, id uint) {
 		fmt.Printf("iterator err: %v\n", err)
 	}
 }
+
+// InvalidateCategoryCached drops category listings and every product key,
+// since a moved or renamed category changes filters and embedded names.
+func InvalidateCategoryCached(ctx context.Context, rds *redis.Client) {
+	deletePattern(ctx, rds, "categories:*")
+	deletePattern(ctx, rds, "products:*")
+}
+
+// InvalidateProductsCached drops every product key at once, for jobs that
+// change many products like the price scheduler.
+func InvalidateProductsCached(ctx context.Context, rds *redis.Client) {
+	deletePattern(ctx, rds, "products:*")
+}
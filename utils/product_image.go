@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"simple-toko/imaging"
+	"simple-toko/storage"
+	"time"
+)
+
+const ProductImagePath = "product/"
+
+// RemoveImage deletes an uploaded image with its thumbnail and medium size, a
+// failure is only logged since the row is already gone.
+func RemoveImage(ctx context.Context, store storage.Storage, dir, name string) {
+	if name == "" {
+		return
+	}
+
+	keys := []string{dir + name}
+	for _, s := range imaging.Sizes {
+		keys = append(keys, dir+s.Dir+name)
+	}
+
+	for _, v := range keys {
+		if err := store.Delete(ctx, v); err != nil {
+			fmt.Printf("failed remove image %s: %v\n", v, err)
+		}
+	}
+}
+
+func RemoveProductImage(ctx context.Context, store storage.Storage, name string) {
+	RemoveImage(ctx, store, ProductImagePath, name)
+}
+
+// ProductImageFiles lists the names found in the product folder or in one of
+// the size folders that were last written before the given time.
+func ProductImageFiles(ctx context.Context, store storage.Storage, before time.Time) ([]string, error) {
+	files, err := store.List(ctx, ProductImagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	var names []string
+
+	for _, v := range files {
+		name := path.Base(v.Key)
+		if seen[name] || !v.ModTime.Before(before) {
+			continue
+		}
+
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	return names, nil
+}
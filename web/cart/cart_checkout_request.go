@@ -0,0 +1,6 @@
+package web
+
+type CartCheckoutRequest struct {
+	UserID    uint `validate:"required"`
+	AddressID uint `validate:"required" json:"address_id"`
+}
@@ -0,0 +1,8 @@
+package web
+
+type CartItemRequest struct {
+	UserID    uint `validate:"required"`
+	ProductID uint `validate:"required" json:"product_id"`
+	VariantID uint `json:"variant_id"`
+	Qty       int  `validate:"required,gt=0" json:"qty"`
+}
@@ -0,0 +1,21 @@
+package web
+
+type CartItemInfo struct {
+	ProductID   uint    `json:"product_id"`
+	VariantID   uint    `json:"variant_id,omitempty"`
+	Name        string  `json:"name"`
+	Sku         string  `json:"sku,omitempty"`
+	VariantName string  `json:"variant_name,omitempty"`
+	Image       string  `json:"image"`
+	Price       float64 `json:"price"`
+	Stock       int     `json:"stock"`
+	Qty         int     `json:"qty"`
+	Subtotal    float64 `json:"subtotal"`
+	Warning     string  `json:"warning,omitempty"`
+}
+
+type CartResponse struct {
+	Items      []CartItemInfo `json:"items"`
+	TotalQty   int            `json:"total_qty"`
+	TotalPrice float64        `json:"total_price"`
+}
@@ -0,0 +1,7 @@
+package web
+
+type CategoryCreateRequest struct {
+	ParentID    *uint  `validate:"omitempty,gt=0" json:"parent_id,omitempty"`
+	Name        string `validate:"required,min=1,max=100" json:"name"`
+	Description string `validate:"omitempty,max=255" json:"description"`
+}
@@ -0,0 +1,14 @@
+package web
+
+import "time"
+
+type CategoryResponse struct {
+	ID           uint                `json:"id"`
+	ParentID     *uint               `json:"parent_id"`
+	Name         string              `json:"name"`
+	Description  string              `json:"description"`
+	ProductCount int64               `json:"product_count"`
+	Children     []*CategoryResponse `json:"children,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
@@ -0,0 +1,9 @@
+package web
+
+// ParentID 0 moves the category to the top level.
+type CategoryUpdateRequest struct {
+	ID          uint    `validate:"required"`
+	ParentID    *uint   `validate:"omitempty" json:"parent_id,omitempty"`
+	Name        *string `validate:"omitempty,min=1,max=100" json:"name,omitempty"`
+	Description *string `validate:"omitempty,max=255" json:"description,omitempty"`
+}
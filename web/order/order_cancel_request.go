@@ -0,0 +1,7 @@
+package web
+
+type OrderCancelRequest struct {
+	ID     uint   `validate:"required"`
+	UserID uint   `validate:"required"`
+	Reason string `validate:"omitempty,max=255" json:"reason"`
+}
@@ -2,7 +2,8 @@ package web
 
 type ProductItem struct {
 	ProductID uint `validate:"required" json:"product_id"`
-	Qty      int  `validate:"required,gt=0" json:"qty"`
+	VariantID uint `json:"variant_id"`
+	Qty       int  `validate:"required,gt=0" json:"qty"`
 }
 
 type OrderCreateRequest struct {
@@ -0,0 +1,6 @@
+package web
+
+type OrderDocumentResponse struct {
+	FileName string
+	Content  []byte
+}
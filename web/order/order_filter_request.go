@@ -0,0 +1,9 @@
+package web
+
+type OrderFilterRequest struct {
+	UserID         uint   `validate:"required"`
+	StatusOrder    string `validate:"omitempty,oneof=waiting confirmed canceled" json:"status_order"`
+	StatusDelivery string `validate:"omitempty,oneof=waiting on_process delivered canceled" json:"status_delivery"`
+	DateFrom       string `validate:"omitempty,datetime=2006-01-02" json:"date_from"`
+	DateTo         string `validate:"omitempty,datetime=2006-01-02" json:"date_to"`
+}
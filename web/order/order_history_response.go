@@ -0,0 +1,14 @@
+package web
+
+import (
+	web "simple-toko/web/address"
+	"time"
+)
+
+type OrderHistoryResponse struct {
+	FromStatus string        `json:"from_status"`
+	ToStatus   string        `json:"to_status"`
+	Reason     string        `json:"reason"`
+	ChangedBy  *web.UserInfo `json:"changed_by"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
@@ -0,0 +1,10 @@
+package web
+
+type OrderItemRequest struct {
+	ID        uint `validate:"required"`
+	UserID    uint
+	ActorID   uint
+	ProductID uint `validate:"required" json:"product_id"`
+	VariantID uint `json:"variant_id"`
+	Qty       int  `validate:"required,gt=0" json:"qty"`
+}
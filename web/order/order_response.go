@@ -16,21 +16,29 @@ type ProductInfo struct {
 }
 
 type OrderProductInfo struct {
-	ProductID uint        `json:"product_id"`
-	Product   ProductInfo `json:"product"`
-	Qty       int         `json:"qty"`
-	UnitPrice float64     `json:"unit_price"`
+	ProductID   uint        `json:"product_id"`
+	Product     ProductInfo `json:"product"`
+	VariantID   uint        `json:"variant_id,omitempty"`
+	Sku         string      `json:"sku,omitempty"`
+	VariantName string      `json:"variant_name,omitempty"`
+	InventoryID uint        `json:"inventory_id,omitempty"`
+	Qty         int         `json:"qty"`
+	UnitPrice   float64     `json:"unit_price"`
 }
 
 type OrderResponse struct {
 	ID             uint               `json:"id"`
+	Code           string             `json:"code"`
+	InvoiceNumber  string             `json:"invoice_number,omitempty"`
 	AmountPay      float64            `json:"amount_pay"`
+	UserID         uint               `json:"user_id"`
 	User           web.UserInfo       `json:"user"`
 	AddressID      uint               `json:"address_id"`
 	Address        AddressInfo        `json:"address"`
 	OrderProducts  []OrderProductInfo `json:"order_product"`
 	StatusOrder    string             `json:"status_order"`
 	StatusDelivery string             `json:"status_delivery"`
+	PayBefore      *time.Time         `json:"pay_before,omitempty"`
 	CreatedAt      time.Time          `json:"created_at"`
 	UpdatedAt      time.Time          `json:"updated_at"`
 }
@@ -2,5 +2,6 @@ package web
 
 type OrderUpdateRequest struct {
 	ID        uint `validate:"required"`
+	UserID    uint
 	AddressID uint `validate:"required" json:"address_id"`
 }
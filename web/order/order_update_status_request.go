@@ -1,7 +1,8 @@
 package web
 
 type OrderUpdateStatusRequest struct {
-	ID             uint    `validate:"required"`
-	StatusOrder    *string `validate:"omitempty,oneof=waiting confirmed canceled" json:"status_order,omitempty"`
-	StatusDelivery *string `validate:"omitempty,oneof=on_process delivered canceled" json:"status_delivery,omitempty"`
+	ID     uint   `validate:"required"`
+	UserID uint   `validate:"required"`
+	Status string `validate:"required,oneof=confirmed on_process delivered canceled" json:"status"`
+	Reason string `validate:"omitempty,max=255" json:"reason"`
 }
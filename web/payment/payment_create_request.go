@@ -2,5 +2,6 @@ package web
 
 type PaymentCreateRequest struct {
 	OrderID uint   `form:"order_id" binding:"required"`
+	UserID  uint   `form:"-"`
 	Image   string `form:"-"`
 }
@@ -0,0 +1,8 @@
+package web
+
+type PaymentLinkRequest struct {
+	OrderID     uint `validate:"required"`
+	UserID      uint //owner check, 0 for admin
+	ScopeUserID uint //admin only, limits the link to one customer
+	ExpiresIn   int  `validate:"omitempty,min=1,max=10080"` //minutes
+}
@@ -0,0 +1,12 @@
+package web
+
+import "time"
+
+type PaymentLinkResponse struct {
+	OrderID   uint      `json:"order_id"`
+	Image     string    `json:"image"`
+	URL       string    `json:"url"`
+	UserID    uint      `json:"user_id,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Query     string    `json:"-"`
+}
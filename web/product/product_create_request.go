@@ -1,9 +1,12 @@
 package web
 
 type ProductCreateRequest struct {
+	UserID      uint
 	InventoryID uint    `validate:"required" json:"inventory_id"`
+	SKU         string  `validate:"omitempty,max=64" json:"sku"`
 	Name        string  `validate:"required,min=1,max=100" json:"name"`
 	Price       float64 `validate:"required" json:"price"`
 	Stock       int     `validate:"required,gt=0" json:"stock"`
 	Description string  `validate:"required,min=1,max=225" json:"description"`
+	CategoryIDs []uint  `validate:"omitempty,dive,gt=0" json:"category_ids"`
 }
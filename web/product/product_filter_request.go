@@ -0,0 +1,11 @@
+package web
+
+type ProductFilterRequest struct {
+	Search      string   `json:"search"`
+	CategoryID  uint     `json:"category"`
+	InventoryID uint     `json:"inventory"`
+	MinPrice    *float64 `validate:"omitempty,gte=0" json:"min_price"`
+	MaxPrice    *float64 `validate:"omitempty,gte=0" json:"max_price"`
+	InStock     bool     `json:"in_stock"`
+	Sort        string   `validate:"omitempty,oneof=relevance newest price_asc price_desc best_selling" json:"sort"`
+}
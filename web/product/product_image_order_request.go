@@ -0,0 +1,6 @@
+package web
+
+type ProductImageOrderRequest struct {
+	ProductID uint   `validate:"required"`
+	ImageIDs  []uint `validate:"required,min=1,dive,gt=0" json:"image_ids"`
+}
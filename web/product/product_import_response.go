@@ -0,0 +1,24 @@
+package web
+
+import "time"
+
+type ImportErrorInfo struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku"`
+	Message string `json:"message"`
+}
+
+type ProductImportResponse struct {
+	ID         uint              `json:"id"`
+	Status     string            `json:"status"`
+	TotalRows  int               `json:"total_rows"`
+	Processed  int               `json:"processed"`
+	Created    int               `json:"created"`
+	Updated    int               `json:"updated"`
+	Failed     int               `json:"failed"`
+	Error      string            `json:"error,omitempty"`
+	Errors     []ImportErrorInfo `json:"errors"`
+	StartedAt  *time.Time        `json:"started_at"`
+	FinishedAt *time.Time        `json:"finished_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
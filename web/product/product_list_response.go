@@ -0,0 +1,28 @@
+package web
+
+import pg "simple-toko/web"
+
+// ProductListResponse is the usual page with the facets of the whole filtered
+// result next to it.
+type ProductListResponse struct {
+	pg.PaginatedResponse
+	Facets ProductFacets `json:"facets"`
+}
+
+type ProductFacets struct {
+	Categories  []FacetInfo  `json:"categories"`
+	Inventories []FacetInfo  `json:"inventories"`
+	Prices      []PriceFacet `json:"prices"`
+}
+
+type FacetInfo struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Total int64  `json:"total"`
+}
+
+type PriceFacet struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max"`
+	Total int64    `json:"total"`
+}
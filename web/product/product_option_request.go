@@ -0,0 +1,6 @@
+package web
+
+type ProductOptionRequest struct {
+	ProductID uint     `validate:"required"`
+	Options   []string `validate:"max=3,dive,required,max=50" json:"options"`
+}
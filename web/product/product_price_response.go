@@ -0,0 +1,33 @@
+package web
+
+import "time"
+
+type PriceScheduleResponse struct {
+	ID        uint       `json:"id"`
+	ProductID uint       `json:"product_id"`
+	Price     float64    `json:"price"`
+	StartsAt  time.Time  `json:"starts_at"`
+	Status    string     `json:"status"`
+	Note      string     `json:"note"`
+	AppliedAt *time.Time `json:"applied_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type PriceHistoryInfo struct {
+	VariantID  uint      `json:"variant_id"`
+	OldPrice   float64   `json:"old_price"`
+	NewPrice   float64   `json:"new_price"`
+	Source     string    `json:"source"`
+	ScheduleID *uint     `json:"schedule_id"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// PriceTimelineResponse lists the past changes oldest first, followed by the
+// prices that are still scheduled.
+type PriceTimelineResponse struct {
+	ProductID uint                    `json:"product_id"`
+	Name      string                  `json:"name"`
+	Price     float64                 `json:"price"`
+	History   []PriceHistoryInfo      `json:"history"`
+	Scheduled []PriceScheduleResponse `json:"scheduled"`
+}
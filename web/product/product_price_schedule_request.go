@@ -0,0 +1,10 @@
+package web
+
+import "time"
+
+type ProductPriceScheduleRequest struct {
+	ProductID uint      `validate:"required"`
+	Price     float64   `validate:"required,gt=0" json:"price"`
+	StartsAt  time.Time `validate:"required" json:"starts_at"`
+	Note      string    `validate:"omitempty,max=255" json:"note"`
+}
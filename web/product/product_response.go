@@ -8,15 +8,56 @@ type InventInfo struct {
 	Location string `json:"location"`
 }
 
+type CategoryInfo struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+type OptionInfo struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+type VariantInfo struct {
+	ID            uint     `json:"id"`
+	SKU           string   `json:"sku"`
+	Options       []string `json:"options"`
+	Price         float64  `json:"price"`
+	PriceOverride *float64 `json:"price_override"`
+	Stock         int      `json:"stock"`
+}
+
+// LocationInfo is the stock of the product at one location, variants summed.
+type LocationInfo struct {
+	InventoryID uint   `json:"inventory_id"`
+	Location    string `json:"location"`
+	Quantity    int    `json:"quantity"`
+}
+
+type ImageInfo struct {
+	ID        uint   `json:"id"`
+	Image     string `json:"image"`
+	Position  int    `json:"position"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
 type ProductResponse struct {
-	ID          uint       `json:"id"`
-	InventoryID uint       `json:"inventory_id"`
-	Inventory   InventInfo `json:"inventory"`
-	Name        string     `json:"name"`
-	Price       float64    `json:"price"`
-	Stock       int        `json:"stock"`
-	Description string     `json:"description"`
-	Image       string     `json:"image"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID          uint           `json:"id"`
+	InventoryID uint           `json:"inventory_id"`
+	Inventory   InventInfo     `json:"inventory"`
+	SKU         string         `json:"sku"`
+	Name        string         `json:"name"`
+	Price       float64        `json:"price"`
+	Stock       int            `json:"stock"`
+	Locations   []LocationInfo `json:"locations"`
+	Description string         `json:"description"`
+	Image       string         `json:"image"`
+	Categories  []CategoryInfo `json:"categories"`
+	Options     []OptionInfo   `json:"options"`
+	Variants    []VariantInfo  `json:"variants"`
+	Images      []ImageInfo    `json:"images"`
+	Rating      float64        `json:"rating"`
+	ReviewCount int            `json:"review_count"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
 }
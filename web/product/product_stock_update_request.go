@@ -1,6 +1,14 @@
 package web
 
+// ProductStockUpdateRequest moves stock at one location, InventoryID 0 is the
+// home location of the product. An empty Reason is purchase when adding and
+// adjustment when reducing.
 type ProductStockUpdateRequest struct {
-	ID    uint `validate:"required" json:"id"`
-	Stock int  `validate:"required,gt=0" json:"stock"`
+	ID          uint   `validate:"required" json:"id"`
+	UserID      uint
+	VariantID   uint   `json:"variant_id"`
+	InventoryID uint   `json:"inventory_id"`
+	Stock       int    `validate:"required,gt=0" json:"stock"`
+	Reason      string `validate:"omitempty,oneof=purchase adjustment return" json:"reason"`
+	Note        string `validate:"omitempty,max=255" json:"note"`
 }
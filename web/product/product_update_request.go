@@ -3,7 +3,9 @@ package web
 type ProductUpdateRequest struct {
 	ID          uint     `validate:"required"`
 	InventoryID *uint    `validate:"omitempty" json:"inventory_id,omitempty"`
+	SKU         *string  `validate:"omitempty,max=64" json:"sku,omitempty"`
 	Name        *string  `validate:"omitempty,min=1,max=100" json:"name,omitempty"`
 	Price       *float64 `validate:"omitempty" json:"price,omitempty"`
 	Description *string  `validate:"omitempty,min=1,max=255" json:"description,omitempty"`
+	CategoryIDs *[]uint  `validate:"omitempty,dive,gt=0" json:"category_ids,omitempty"`
 }
@@ -0,0 +1,10 @@
+package web
+
+type ProductVariantCreateRequest struct {
+	ProductID uint     `validate:"required"`
+	UserID    uint
+	SKU       string   `validate:"required,max=64" json:"sku"`
+	Options   []string `validate:"required,max=3,dive,required,max=50" json:"options"`
+	Price     *float64 `validate:"omitempty,gt=0" json:"price,omitempty"`
+	Stock     int      `validate:"gte=0" json:"stock"`
+}
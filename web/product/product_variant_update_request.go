@@ -0,0 +1,11 @@
+package web
+
+// ProductVariantUpdateRequest uses price 0 to drop the override and go back
+// to the product price.
+type ProductVariantUpdateRequest struct {
+	ProductID uint      `validate:"required"`
+	ID        uint      `validate:"required"`
+	SKU       *string   `validate:"omitempty,min=1,max=64" json:"sku,omitempty"`
+	Options   *[]string `validate:"omitempty,max=3,dive,required,max=50" json:"options,omitempty"`
+	Price     *float64  `validate:"omitempty,gte=0" json:"price,omitempty"`
+}
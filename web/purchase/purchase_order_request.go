@@ -0,0 +1,42 @@
+package web
+
+type PurchaseOrderLineRequest struct {
+	ProductID uint    `validate:"required" json:"product_id"`
+	VariantID uint    `json:"variant_id"`
+	Qty       int     `validate:"required,gt=0" json:"qty"`
+	UnitCost  float64 `validate:"gte=0" json:"unit_cost"`
+}
+
+type PurchaseOrderCreateRequest struct {
+	ID          uint                       `json:"-"`
+	SupplierID  uint                       `validate:"required" json:"supplier_id"`
+	InventoryID uint                       `validate:"required" json:"inventory_id"`
+	ExpectedAt  string                     `validate:"omitempty,datetime=2006-01-02" json:"expected_at"`
+	Note        string                     `validate:"omitempty,max=500" json:"note"`
+	UserID      uint                       `json:"-"`
+	Lines       []PurchaseOrderLineRequest `validate:"required,min=1,dive" json:"lines"`
+}
+
+// GoodsReceiptLineRequest receives qty of a line, without unit_cost the cost
+// on the order line is used.
+type GoodsReceiptLineRequest struct {
+	LineID   uint     `validate:"required" json:"line_id"`
+	Qty      int      `validate:"required,gt=0" json:"qty"`
+	UnitCost *float64 `validate:"omitempty,gte=0" json:"unit_cost"`
+}
+
+// GoodsReceiptRequest books one delivery, Close ends the order even when lines
+// are still short.
+type GoodsReceiptRequest struct {
+	ID     uint                      `validate:"required" json:"-"`
+	UserID uint                      `json:"-"`
+	Note   string                    `validate:"omitempty,max=500" json:"note"`
+	Lines  []GoodsReceiptLineRequest `validate:"omitempty,dive" json:"lines"`
+	Close  bool                      `json:"close"`
+}
+
+type PurchaseOrderFilterRequest struct {
+	Status      string `validate:"omitempty,oneof=draft approved partially_received received canceled open" json:"status"`
+	SupplierID  uint   `json:"supplier_id"`
+	InventoryID uint   `json:"inventory_id"`
+}
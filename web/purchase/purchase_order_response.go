@@ -0,0 +1,56 @@
+package web
+
+import "time"
+
+type PurchaseOrderLineResponse struct {
+	ID          uint    `json:"id"`
+	ProductID   uint    `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	VariantID   uint    `json:"variant_id"`
+	SKU         string  `json:"sku"`
+	Variant     string  `json:"variant"`
+	Qty         int     `json:"qty"`
+	ReceivedQty int     `json:"received_qty"`
+	OpenQty     int     `json:"open_qty"`
+	UnitCost    float64 `json:"unit_cost"`
+	Subtotal    float64 `json:"subtotal"`
+}
+
+type GoodsReceiptLineResponse struct {
+	LineID    uint    `json:"line_id"`
+	ProductID uint    `json:"product_id"`
+	VariantID uint    `json:"variant_id"`
+	Qty       int     `json:"qty"`
+	UnitCost  float64 `json:"unit_cost"`
+}
+
+type GoodsReceiptResponse struct {
+	ID        uint                       `json:"id"`
+	Code      string                     `json:"code"`
+	Note      string                     `json:"note,omitempty"`
+	UserID    uint                       `json:"user_id"`
+	UserName  string                     `json:"user_name"`
+	Lines     []GoodsReceiptLineResponse `json:"lines"`
+	CreatedAt time.Time                  `json:"created_at"`
+}
+
+type PurchaseOrderResponse struct {
+	ID           uint                        `json:"id"`
+	Code         string                      `json:"code"`
+	SupplierID   uint                        `json:"supplier_id"`
+	SupplierName string                      `json:"supplier_name"`
+	InventoryID  uint                        `json:"inventory_id"`
+	Location     string                      `json:"location"`
+	Status       string                      `json:"status"`
+	ExpectedAt   *time.Time                  `json:"expected_at"`
+	Note         string                      `json:"note,omitempty"`
+	Total        float64                     `json:"total"`
+	UserID       uint                        `json:"user_id"`
+	UserName     string                      `json:"user_name"`
+	Lines        []PurchaseOrderLineResponse `json:"lines"`
+	Receipts     []GoodsReceiptResponse      `json:"receipts,omitempty"`
+	ApprovedBy   *uint                       `json:"approved_by"`
+	ApprovedAt   *time.Time                  `json:"approved_at"`
+	CreatedAt    time.Time                   `json:"created_at"`
+	UpdatedAt    time.Time                   `json:"updated_at"`
+}
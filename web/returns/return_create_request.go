@@ -0,0 +1,9 @@
+package web
+
+type ReturnCreateRequest struct {
+	OrderProductID uint   `form:"order_product_id" binding:"required" validate:"required"`
+	Qty            int    `form:"qty" binding:"required" validate:"required,gt=0"`
+	Reason         string `form:"reason" binding:"required" validate:"required,max=500"`
+	UserID         uint   `form:"-"`
+	Image          string `form:"-"`
+}
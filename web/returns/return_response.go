@@ -0,0 +1,29 @@
+package web
+
+import "time"
+
+type RefundInfo struct {
+	Qty       int       `json:"qty"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ReturnResponse struct {
+	ID             uint        `json:"id"`
+	OrderID        uint        `json:"order_id"`
+	OrderCode      string      `json:"order_code"`
+	OrderProductID uint        `json:"order_product_id"`
+	ProductID      uint        `json:"product_id"`
+	ProductName    string      `json:"product_name"`
+	UnitPrice      float64     `json:"unit_price"`
+	UserID         uint        `json:"user_id"`
+	Qty            int         `json:"qty"`
+	Reason         string      `json:"reason"`
+	Image          string      `json:"image"`
+	Status         string      `json:"status"`
+	AdminNote      string      `json:"admin_note"`
+	Restock        bool        `json:"restock"`
+	Refund         *RefundInfo `json:"refund,omitempty"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package web
+
+type ReturnReviewRequest struct {
+	ID   uint   `validate:"required"`
+	Note string `validate:"omitempty,max=500" json:"note"`
+}
+
+type ReturnReceiveRequest struct {
+	ID      uint `validate:"required"`
+	UserID  uint
+	Restock bool `json:"restock"`
+}
+
+type ReturnRefundRequest struct {
+	ID     uint    `validate:"required"`
+	Amount float64 `validate:"omitempty,gt=0" json:"amount"`
+}
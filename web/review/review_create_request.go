@@ -0,0 +1,9 @@
+package web
+
+type ReviewCreateRequest struct {
+	OrderProductID uint     `form:"order_product_id" binding:"required" validate:"required"`
+	Rating         int      `form:"rating" binding:"required" validate:"required,min=1,max=5"`
+	Comment        string   `form:"comment" validate:"omitempty,max=1000"`
+	UserID         uint     `form:"-"`
+	Images         []string `form:"-"`
+}
@@ -0,0 +1,6 @@
+package web
+
+type ReviewHideRequest struct {
+	ID     uint   `validate:"required"`
+	Reason string `validate:"omitempty,max=500" json:"reason"`
+}
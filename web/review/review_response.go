@@ -0,0 +1,24 @@
+package web
+
+import "time"
+
+type ReviewImageInfo struct {
+	ID    uint   `json:"id"`
+	Image string `json:"image"`
+}
+
+type ReviewResponse struct {
+	ID             uint              `json:"id"`
+	ProductID      uint              `json:"product_id"`
+	ProductName    string            `json:"product_name"`
+	OrderProductID uint              `json:"order_product_id"`
+	UserID         uint              `json:"user_id"`
+	UserName       string            `json:"user_name"`
+	Rating         int               `json:"rating"`
+	Comment        string            `json:"comment"`
+	Images         []ReviewImageInfo `json:"images"`
+	Hidden         bool              `json:"hidden"`
+	HiddenReason   string            `json:"hidden_reason,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
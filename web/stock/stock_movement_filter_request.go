@@ -0,0 +1,10 @@
+package web
+
+type StockMovementFilterRequest struct {
+	ProductID   uint   `validate:"required"`
+	VariantID   *uint  `json:"variant_id"`
+	InventoryID uint   `json:"inventory_id"`
+	Reason      string `validate:"omitempty,oneof=purchase sale adjustment return cancel transfer" json:"reason"`
+	DateFrom    string `validate:"omitempty,datetime=2006-01-02" json:"date_from"`
+	DateTo      string `validate:"omitempty,datetime=2006-01-02" json:"date_to"`
+}
@@ -0,0 +1,43 @@
+package web
+
+import "time"
+
+// StockResponse is the quantity of a product, or of one variant, at one
+// location. VariantID 0 is the product itself.
+type StockResponse struct {
+	ID          uint      `json:"id"`
+	ProductID   uint      `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	VariantID   uint      `json:"variant_id"`
+	SKU         string    `json:"sku"`
+	Variant     string    `json:"variant"`
+	InventoryID uint      `json:"inventory_id"`
+	Location    string    `json:"location"`
+	Quantity    int       `json:"quantity"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type StockMovementResponse struct {
+	ID          uint      `json:"id"`
+	ProductID   uint      `json:"product_id"`
+	VariantID   uint      `json:"variant_id"`
+	InventoryID uint      `json:"inventory_id"`
+	Location    string    `json:"location"`
+	Delta       int       `json:"delta"`
+	Balance     int       `json:"balance"`
+	Reason      string    `json:"reason"`
+	RefType     string    `json:"ref_type,omitempty"`
+	RefID       uint      `json:"ref_id,omitempty"`
+	UserID      *uint     `json:"user_id"`
+	UserName    string    `json:"user_name,omitempty"`
+	Note        string    `json:"note,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type StockMismatchResponse struct {
+	ProductID   uint `json:"product_id"`
+	VariantID   uint `json:"variant_id"`
+	InventoryID uint `json:"inventory_id"`
+	Quantity    int  `json:"quantity"`
+	Ledger      int  `json:"ledger"`
+}
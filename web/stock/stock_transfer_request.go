@@ -0,0 +1,37 @@
+package web
+
+type StockTransferLineRequest struct {
+	ProductID uint   `validate:"required" json:"product_id"`
+	VariantID uint   `json:"variant_id"`
+	Qty       int    `validate:"required,gt=0" json:"qty"`
+	Note      string `validate:"omitempty,max=255" json:"note"`
+}
+
+type StockTransferCreateRequest struct {
+	ID            uint                       `json:"-"`
+	SourceID      uint                       `validate:"required" json:"source_id"`
+	DestinationID uint                       `validate:"required" json:"destination_id"`
+	Note          string                     `validate:"omitempty,max=500" json:"note"`
+	UserID        uint                       `json:"-"`
+	Lines         []StockTransferLineRequest `validate:"required,min=1,dive" json:"lines"`
+}
+
+type StockTransferReceiptRequest struct {
+	LineID uint   `validate:"required" json:"line_id"`
+	Qty    int    `validate:"gte=0" json:"qty"`
+	Note   string `validate:"omitempty,max=255" json:"note"`
+}
+
+// StockTransferReceiveRequest books one delivery, Close ends the transfer
+// even when lines are still short.
+type StockTransferReceiveRequest struct {
+	ID     uint                          `validate:"required" json:"-"`
+	UserID uint                          `json:"-"`
+	Lines  []StockTransferReceiptRequest `validate:"omitempty,dive" json:"lines"`
+	Close  bool                          `json:"close"`
+}
+
+type StockTransferFilterRequest struct {
+	Status      string `validate:"omitempty,oneof=draft in_transit received canceled" json:"status"`
+	InventoryID uint   `json:"inventory_id"`
+}
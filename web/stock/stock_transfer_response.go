@@ -0,0 +1,36 @@
+package web
+
+import "time"
+
+type StockTransferLineResponse struct {
+	ID          uint   `json:"id"`
+	ProductID   uint   `json:"product_id"`
+	ProductName string `json:"product_name"`
+	VariantID   uint   `json:"variant_id"`
+	SKU         string `json:"sku"`
+	Variant     string `json:"variant"`
+	Qty         int    `json:"qty"`
+	ReceivedQty int    `json:"received_qty"`
+	Discrepancy int    `json:"discrepancy"`
+	Note        string `json:"note,omitempty"`
+}
+
+type StockTransferResponse struct {
+	ID            uint                        `json:"id"`
+	Code          string                      `json:"code"`
+	SourceID      uint                        `json:"source_id"`
+	Source        string                      `json:"source"`
+	DestinationID uint                        `json:"destination_id"`
+	Destination   string                      `json:"destination"`
+	Status        string                      `json:"status"`
+	Note          string                      `json:"note,omitempty"`
+	UserID        uint                        `json:"user_id"`
+	UserName      string                      `json:"user_name"`
+	Lines         []StockTransferLineResponse `json:"lines"`
+	ShippedBy     *uint                       `json:"shipped_by"`
+	ShippedAt     *time.Time                  `json:"shipped_at"`
+	ReceivedBy    *uint                       `json:"received_by"`
+	ReceivedAt    *time.Time                  `json:"received_at"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	UpdatedAt     time.Time                   `json:"updated_at"`
+}
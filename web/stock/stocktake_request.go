@@ -0,0 +1,26 @@
+package web
+
+type StocktakeCreateRequest struct {
+	InventoryID uint   `validate:"required" json:"inventory_id"`
+	Note        string `validate:"omitempty,max=500" json:"note"`
+	UserID      uint   `json:"-"`
+}
+
+// StocktakeCountRequest names the line by line_id or by sku, a sku that has no
+// line yet is added to the session.
+type StocktakeCountRequest struct {
+	LineID  uint   `validate:"required_without=SKU" json:"line_id"`
+	SKU     string `validate:"required_without=LineID,max=64" json:"sku"`
+	Counted *int   `validate:"required,gte=0" json:"counted"`
+}
+
+type StocktakeCountsRequest struct {
+	ID     uint                    `validate:"required" json:"-"`
+	UserID uint                    `json:"-"`
+	Counts []StocktakeCountRequest `validate:"required,min=1,dive" json:"counts"`
+}
+
+type StocktakeFilterRequest struct {
+	Status      string `validate:"omitempty,oneof=open approved canceled" json:"status"`
+	InventoryID uint   `json:"inventory_id"`
+}
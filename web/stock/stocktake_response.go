@@ -0,0 +1,44 @@
+package web
+
+import "time"
+
+type StocktakeLineResponse struct {
+	ID          uint       `json:"id"`
+	ProductID   uint       `json:"product_id"`
+	ProductName string     `json:"product_name"`
+	VariantID   uint       `json:"variant_id"`
+	SKU         string     `json:"sku"`
+	Variant     string     `json:"variant"`
+	Expected    int        `json:"expected"`
+	Counted     *int       `json:"counted"`
+	Variance    *int       `json:"variance"` //counted - expected, null until counted
+	Adjusted    int        `json:"adjusted"`
+	CountedBy   *uint      `json:"counted_by"`
+	CountedAt   *time.Time `json:"counted_at"`
+}
+
+type StocktakeSummary struct {
+	Lines     int `json:"lines"`
+	Counted   int `json:"counted"`
+	Uncounted int `json:"uncounted"`
+	Variances int `json:"variances"` //counted lines that differ from expected
+	Surplus   int `json:"surplus"`
+	Shortage  int `json:"shortage"`
+}
+
+type StocktakeResponse struct {
+	ID          uint                    `json:"id"`
+	Code        string                  `json:"code"`
+	InventoryID uint                    `json:"inventory_id"`
+	Location    string                  `json:"location"`
+	Status      string                  `json:"status"`
+	Note        string                  `json:"note,omitempty"`
+	UserID      uint                    `json:"user_id"`
+	UserName    string                  `json:"user_name"`
+	Summary     *StocktakeSummary       `json:"summary,omitempty"`
+	Lines       []StocktakeLineResponse `json:"lines,omitempty"`
+	ApprovedBy  *uint                   `json:"approved_by"`
+	ApprovedAt  *time.Time              `json:"approved_at"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
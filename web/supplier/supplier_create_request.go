@@ -0,0 +1,8 @@
+package web
+
+type SupplierCreateRequest struct {
+	Name    string `validate:"required,min=1,max=100" json:"name"`
+	Email   string `validate:"omitempty,email,max=100" json:"email"`
+	Phone   string `validate:"omitempty,max=30" json:"phone"`
+	Address string `validate:"omitempty,max=255" json:"address"`
+}
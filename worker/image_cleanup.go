@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"os"
+	"simple-toko/service"
+	"strconv"
+	"time"
+)
+
+// StartImageCleanup removes product image files that lost their row, for
+// example when a request failed between saving the file and the database.
+func StartImageCleanup(ctx context.Context, productService service.ProductService) {
+	interval, err := strconv.Atoi(os.Getenv("IMAGE_CLEANUP_INTERVAL"))
+	if err != nil || interval < 1 {
+		interval = 24
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Hour)
+		defer ticker.Stop()
+
+		for {
+			removed, err := productService.CleanupImages(ctx, time.Now().Add(-time.Hour))
+			if err != nil {
+				log.Printf("image cleanup: %v\n", err)
+			}
+
+			if removed > 0 {
+				log.Printf("image cleanup: removed %d orphaned files\n", removed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
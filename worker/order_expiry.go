@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"os"
+	"simple-toko/service"
+	"strconv"
+	"time"
+)
+
+func StartOrderExpiry(ctx context.Context, orderService service.OrderService) {
+	interval, err := strconv.Atoi(os.Getenv("ORDER_EXPIRY_INTERVAL"))
+	if err != nil || interval < 1 {
+		interval = 5
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			expired, err := orderService.ExpireOrders(ctx)
+			if err != nil {
+				log.Printf("order expiry: %v\n", err)
+			}
+
+			if expired > 0 {
+				log.Printf("order expiry: canceled %d unpaid orders\n", expired)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
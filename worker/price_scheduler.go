@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"os"
+	"simple-toko/service"
+	"strconv"
+	"time"
+)
+
+// StartPriceScheduler applies the scheduled product prices once they start.
+func StartPriceScheduler(ctx context.Context, priceService service.ProductPriceService) {
+	interval, err := strconv.Atoi(os.Getenv("PRICE_SCHEDULE_INTERVAL"))
+	if err != nil || interval < 1 {
+		interval = 60
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			applied, err := priceService.ApplyScheduled(ctx)
+			if err != nil {
+				log.Printf("price scheduler: %v\n", err)
+			}
+
+			if applied > 0 {
+				log.Printf("price scheduler: applied %d scheduled prices\n", applied)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
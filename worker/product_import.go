@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"os"
+	"simple-toko/service"
+	"strconv"
+	"time"
+)
+
+// StartProductImport runs the uploaded catalog imports, every app instance
+// polls and an import is only taken by one of them.
+func StartProductImport(ctx context.Context, importService service.ProductImportService) {
+	interval, err := strconv.Atoi(os.Getenv("PRODUCT_IMPORT_INTERVAL"))
+	if err != nil || interval < 1 {
+		interval = 5
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Duration(interval) * time.Second)
+		defer ticker.Stop()
+
+		for {
+			done, err := importService.RunPending(ctx)
+			if err != nil {
+				log.Printf("product import: %v\n", err)
+			}
+
+			if done > 0 {
+				log.Printf("product import: finished %d imports\n", done)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}